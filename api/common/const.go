@@ -9,4 +9,12 @@ const (
 	NodeAffinityScoringPluginName Plugin = iota
 	// ENoExecPlugin checks the ENoExecEvent resources.
 	ExecFormatErrorMonitorPluginName
+	// ImagePrefetcherPluginName warms up the image inspection cache from the workloads' pod templates.
+	ImagePrefetcherPluginName
+	// CredentialProvidersPluginName authenticates the image inspections with the kubelet credential providers.
+	CredentialProvidersPluginName
+	// EmulationPluginName allows the pods to run on the nodes emulating the architectures of their images.
+	EmulationPluginName
+	// ImageSubstitutionPluginName swaps the images of the pods for equivalents supporting more architectures.
+	ImageSubstitutionPluginName
 )
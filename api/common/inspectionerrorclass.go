@@ -0,0 +1,32 @@
+package common
+
+// ImageInspectionErrorClass is a type derived from string used to represent the class of an image inspection error.
+// +kubebuilder:validation:Enum=AuthDenied;NotFound;PolicyRejected;Unreachable;RateLimited;MalformedManifest;Unknown
+type ImageInspectionErrorClass string
+
+const (
+	// ImageInspectionErrorAuthDenied is used when the registry denies the access to the image with the given credentials.
+	ImageInspectionErrorAuthDenied ImageInspectionErrorClass = "AuthDenied"
+	// ImageInspectionErrorNotFound is used when the registry does not know the repository or the manifest.
+	ImageInspectionErrorNotFound ImageInspectionErrorClass = "NotFound"
+	// ImageInspectionErrorPolicyRejected is used when the signature policy does not allow the image.
+	ImageInspectionErrorPolicyRejected ImageInspectionErrorClass = "PolicyRejected"
+	// ImageInspectionErrorUnreachable is used when the registry cannot be reached or fails to serve the request.
+	ImageInspectionErrorUnreachable ImageInspectionErrorClass = "Unreachable"
+	// ImageInspectionErrorRateLimited is used when the registry rejects the request with a rate limit error.
+	ImageInspectionErrorRateLimited ImageInspectionErrorClass = "RateLimited"
+	// ImageInspectionErrorMalformedManifest is used when the manifest or the config of the image cannot be parsed.
+	ImageInspectionErrorMalformedManifest ImageInspectionErrorClass = "MalformedManifest"
+	// ImageInspectionErrorUnknown is used for any other error.
+	ImageInspectionErrorUnknown ImageInspectionErrorClass = "Unknown"
+)
+
+// IsFailFast returns true if retrying the inspection cannot succeed until the image or the cluster configuration changes.
+func (class ImageInspectionErrorClass) IsFailFast() bool {
+	return class == ImageInspectionErrorNotFound || class == ImageInspectionErrorPolicyRejected
+}
+
+// IsRateLimited returns true if the inspection should be retried with a backoff.
+func (class ImageInspectionErrorClass) IsRateLimited() bool {
+	return class == ImageInspectionErrorRateLimited
+}
@@ -24,6 +24,8 @@ import "github.com/openshift/multiarch-tuning-operator/api/common"
 // +kubebuilder:object:generate=true
 type LocalPlugins struct {
 	NodeAffinityScoring *NodeAffinityScoring `json:"nodeAffinityScoring,omitempty"`
+
+	ImageSubstitution *ImageSubstitution `json:"imageSubstitution,omitempty"`
 }
 
 // localPluginChecks is a map that associates a plugin name with a function that can
@@ -32,6 +34,9 @@ var localPluginChecks = map[common.Plugin]func(lp *LocalPlugins) bool{
 	common.NodeAffinityScoringPluginName: func(lp *LocalPlugins) bool {
 		return lp.NodeAffinityScoring != nil && lp.NodeAffinityScoring.IsEnabled()
 	},
+	common.ImageSubstitutionPluginName: func(lp *LocalPlugins) bool {
+		return lp.ImageSubstitution != nil && lp.ImageSubstitution.IsEnabled()
+	},
 }
 
 // PluginEnabled provides a generic and safe way to check if a specific plugin is enabled.
@@ -49,6 +54,14 @@ type Plugins struct {
 	NodeAffinityScoring *NodeAffinityScoring `json:"nodeAffinityScoring,omitempty"`
 
 	ExecFormatErrorMonitor *ExecFormatErrorMonitor `json:"execFormatErrorMonitor,omitempty"`
+
+	ImagePrefetcher *ImagePrefetcher `json:"imagePrefetcher,omitempty"`
+
+	CredentialProviders *CredentialProviders `json:"credentialProviders,omitempty"`
+
+	Emulation *Emulation `json:"emulation,omitempty"`
+
+	ImageSubstitution *ImageSubstitution `json:"imageSubstitution,omitempty"`
 }
 
 // pluginChecks is a map that associates a plugin name with a function that can
@@ -60,6 +73,18 @@ var pluginChecks = map[common.Plugin]func(p *Plugins) bool{
 	common.ExecFormatErrorMonitorPluginName: func(p *Plugins) bool {
 		return p.ExecFormatErrorMonitor != nil && p.ExecFormatErrorMonitor.IsEnabled()
 	},
+	common.ImagePrefetcherPluginName: func(p *Plugins) bool {
+		return p.ImagePrefetcher != nil && p.ImagePrefetcher.IsEnabled()
+	},
+	common.CredentialProvidersPluginName: func(p *Plugins) bool {
+		return p.CredentialProviders != nil && p.CredentialProviders.IsEnabled()
+	},
+	common.EmulationPluginName: func(p *Plugins) bool {
+		return p.Emulation != nil && p.Emulation.IsEnabled()
+	},
+	common.ImageSubstitutionPluginName: func(p *Plugins) bool {
+		return p.ImageSubstitution != nil && p.ImageSubstitution.IsEnabled()
+	},
 }
 
 // PluginEnabled provides a generic and safe way to check if a specific plugin is enabled.
@@ -0,0 +1,67 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+const (
+	// CredentialProvidersPluginName stores the name for the CredentialProviders.
+	CredentialProvidersPluginName = "credentialProviders"
+
+	// DefaultCredentialProvidersConfigDir is the default directory of the nodes holding the CredentialProviderConfig
+	// of the kubelet.
+	DefaultCredentialProvidersConfigDir = "/etc/kubernetes/credential-providers"
+	// DefaultCredentialProvidersBinDir is the default directory of the nodes holding the credential provider binaries.
+	DefaultCredentialProvidersBinDir = "/usr/libexec/kubelet-image-credential-provider-plugins"
+)
+
+// CredentialProviders is a plugin that authenticates the image inspections with the kubelet credential provider exec
+// plugins of the nodes (e.g., for ECR, ACR or Artifact Registry), in addition to the global pull secret and the pods'
+// imagePullSecrets. The directories are mounted from the nodes running the pod placement controller and must exist.
+type CredentialProviders struct {
+	BasePlugin `json:",inline"`
+
+	// ConfigDir is the directory of the nodes holding the kubelet CredentialProviderConfig files.
+	// +kubebuilder:validation:Pattern=`^/.*`
+	// +optional
+	ConfigDir string `json:"configDir,omitempty"`
+
+	// BinDir is the directory of the nodes holding the credential provider binaries.
+	// +kubebuilder:validation:Pattern=`^/.*`
+	// +optional
+	BinDir string `json:"binDir,omitempty"`
+}
+
+// Name returns the name of the CredentialProvidersPluginName.
+func (b *CredentialProviders) Name() string {
+	return CredentialProvidersPluginName
+}
+
+// ConfigDirOrDefault returns the configured directory of the CredentialProviderConfig files or the default one,
+// if unset.
+func (b *CredentialProviders) ConfigDirOrDefault() string {
+	if b.ConfigDir != "" {
+		return b.ConfigDir
+	}
+	return DefaultCredentialProvidersConfigDir
+}
+
+// BinDirOrDefault returns the configured directory of the credential provider binaries or the default one, if unset.
+func (b *CredentialProviders) BinDirOrDefault() string {
+	if b.BinDir != "" {
+		return b.BinDir
+	}
+	return DefaultCredentialProvidersBinDir
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import corev1 "k8s.io/api/core/v1"
+
+const (
+	// EmulationPluginName stores the name for the Emulation.
+	EmulationPluginName = "emulation"
+	// DefaultEmulationNativeWeight is the default weight of the preferred affinity for the native nodes.
+	DefaultEmulationNativeWeight = 10
+)
+
+// Emulation is a plugin that allows the pods to run on the nodes able to emulate the architectures of their images,
+// e.g., through registered qemu-user binfmt_misc handlers. The required node affinity of the pods includes the
+// emulating nodes, and a low-weight preferred affinity keeps the nodes running the images natively first.
+// The emulating nodes are always allowed, regardless of the capacity of the native nodes: the scheduler only falls
+// back to them when the preferred native nodes cannot fit the pods.
+// The pods that may run emulated are labelled with multiarch.openshift.io/emulation=allowed.
+type Emulation struct {
+	BasePlugin `json:",inline"`
+
+	// Nodes lists the emulating nodes and the foreign architectures they emulate.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	// +listType=atomic
+	Nodes []EmulatingNodes `json:"nodes"`
+
+	// NativeWeight is the weight of the preferred affinity for the nodes of the architectures of the images, in the
+	// range 1-100. Defaults to 10.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	NativeWeight int32 `json:"nativeWeight,omitempty"`
+}
+
+// EmulatingNodes defines a set of nodes able to emulate foreign architectures.
+type EmulatingNodes struct {
+	// MatchExpressions is the list of node selector requirements selecting the emulating nodes, e.g., by the label
+	// set on the nodes with registered qemu-user binfmt_misc handlers.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	// +listType=atomic
+	MatchExpressions []corev1.NodeSelectorRequirement `json:"matchExpressions"`
+
+	// Architectures are the foreign architectures the nodes can emulate.
+	// In the ClusterPodPlacementConfig, they must be supported architectures.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9_]+$`
+	Architectures []string `json:"architectures"`
+}
+
+// Name returns the name of the EmulationPluginName.
+func (b *Emulation) Name() string {
+	return EmulationPluginName
+}
+
+// NativeWeightOrDefault returns the configured weight of the preferred affinity for the native nodes or the default
+// one, if unset.
+func (b *Emulation) NativeWeightOrDefault() int32 {
+	if b.NativeWeight > 0 {
+		return b.NativeWeight
+	}
+	return DefaultEmulationNativeWeight
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+const (
+	// ImagePrefetcherPluginName stores the name for the ImagePrefetcher.
+	ImagePrefetcherPluginName = "imagePrefetcher"
+
+	// DefaultImagePrefetcherQueueSize is the default maximum number of images waiting to be inspected.
+	DefaultImagePrefetcherQueueSize = 1024
+	// DefaultImagePrefetcherInspectionsPerSecond is the default rate of the warm-up inspections.
+	DefaultImagePrefetcherInspectionsPerSecond = 5
+	// DefaultImagePrefetcherBurst is the default number of warm-up inspections allowed to exceed the rate.
+	DefaultImagePrefetcherBurst = 10
+)
+
+// ImagePrefetcher is a plugin that inspects the images of the workloads' pod templates (Deployments, StatefulSets,
+// Jobs, CronJobs and DeploymentConfigs) when they change, so that the image inspection cache is warm when their pods
+// are created. The pod templates whose pods are ignored by the operator are skipped, and the images are inspected
+// with the secrets of the PodPlacementConfigs matching the pod templates.
+type ImagePrefetcher struct {
+	BasePlugin `json:",inline"`
+
+	// QueueSize is the maximum number of images waiting to be inspected. Images extracted when the queue is full are
+	// dropped and will be inspected when the pods are created.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65536
+	// +optional
+	QueueSize int32 `json:"queueSize,omitempty"`
+
+	// InspectionsPerSecond is the maximum rate of the warm-up inspections.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	InspectionsPerSecond int32 `json:"inspectionsPerSecond,omitempty"`
+
+	// Burst is the maximum number of warm-up inspections that can exceed InspectionsPerSecond.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// Name returns the name of the ImagePrefetcherPluginName.
+func (b *ImagePrefetcher) Name() string {
+	return ImagePrefetcherPluginName
+}
+
+// QueueSizeOrDefault returns the configured queue size or the default one, if unset.
+func (b *ImagePrefetcher) QueueSizeOrDefault() int {
+	if b.QueueSize > 0 {
+		return int(b.QueueSize)
+	}
+	return DefaultImagePrefetcherQueueSize
+}
+
+// InspectionsPerSecondOrDefault returns the configured rate of the warm-up inspections or the default one, if unset.
+func (b *ImagePrefetcher) InspectionsPerSecondOrDefault() int {
+	if b.InspectionsPerSecond > 0 {
+		return int(b.InspectionsPerSecond)
+	}
+	return DefaultImagePrefetcherInspectionsPerSecond
+}
+
+// BurstOrDefault returns the configured burst of the warm-up inspections or the default one, if unset.
+func (b *ImagePrefetcher) BurstOrDefault() int {
+	if b.Burst > 0 {
+		return int(b.Burst)
+	}
+	return DefaultImagePrefetcherBurst
+}
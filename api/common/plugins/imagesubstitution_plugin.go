@@ -0,0 +1,73 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import "fmt"
+
+const (
+	// ImageSubstitutionPluginName stores the name for the ImageSubstitution.
+	ImageSubstitutionPluginName = "imageSubstitution"
+)
+
+// ImageSubstitution is a plugin that swaps the images of the containers of the gated pods for known equivalents,
+// e.g., foo:1.2 for foo:1.2-arm64 or an image rebuilt in an internal registry. An image is only substituted when the
+// architectures of its substitute, as inspected, are a strict superset of its own: the substitution widens the
+// placement of the pod. The original images are recorded in the multiarch.openshift.io/original-images annotation
+// of the pods.
+type ImageSubstitution struct {
+	BasePlugin `json:",inline"`
+
+	// Rules maps the images to their substitutes. The first rule matching an image applies.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	// +listType=atomic
+	Rules []ImageSubstitutionRule `json:"rules"`
+}
+
+// ImageSubstitutionRule maps an image to its substitute.
+type ImageSubstitutionRule struct {
+	// Image is the reference of the image to substitute, e.g., quay.io/myorg/foo:1.2. The references are compared
+	// after normalization: foo:1.2 matches docker.io/library/foo:1.2.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Image string `json:"image"`
+
+	// Substitute is the reference of the image replacing the matching images, e.g., quay.io/myorg/foo:1.2-arm64.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Substitute string `json:"substitute"`
+}
+
+// Name returns the name of the ImageSubstitutionPluginName.
+func (b *ImageSubstitution) Name() string {
+	return ImageSubstitutionPluginName
+}
+
+// ValidateRules checks whether an image is mapped to itself or mapped several times in ImageSubstitution.
+func (b *ImageSubstitution) ValidateRules() (bool, error) {
+	seen := make(map[string]struct{})
+	for _, rule := range b.Rules {
+		if rule.Image == rule.Substitute {
+			return false, fmt.Errorf("image %q is substituted with itself in imageSubstitution.rules", rule.Image)
+		}
+		if _, exists := seen[rule.Image]; exists {
+			return false, fmt.Errorf("duplicate image %q found in imageSubstitution.rules", rule.Image)
+		}
+		seen[rule.Image] = struct{}{}
+	}
+	return true, nil
+}
@@ -48,7 +48,8 @@ func (n *NodeAffinityScoring) ValidateArchitecturesSet() (bool, error) {
 // NodeAffinityScoringPlatformTerm holds configuration for specific platforms, with required fields validated.
 type NodeAffinityScoringPlatformTerm struct {
 	// Architecture must be a list of non-empty string of arch names.
-	// +kubebuilder:validation:Enum=arm64;amd64;ppc64le;s390x
+	// In the ClusterPodPlacementConfig, it must be one of the supported architectures.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9_]+$`
 	Architecture string `json:"architecture" protobuf:"bytes,1,rep,name=architecture"`
 
 	// weight associated with matching the corresponding NodeAffinityScoringPlatformTerm,
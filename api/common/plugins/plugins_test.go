@@ -45,3 +45,112 @@ func TestExecFormatErrorMonitor_Name(t *testing.T) {
 		t.Errorf("Expected plugin name %s, but got %s", ExecFormatErrorMonitorPluginName, plugin.Name())
 	}
 }
+
+func TestImagePrefetcher_Defaults(t *testing.T) {
+	tests := []struct {
+		name                 string
+		plugin               ImagePrefetcher
+		queueSize            int
+		inspectionsPerSecond int
+		burst                int
+	}{
+		{
+			name:                 "unset fields use the defaults",
+			plugin:               ImagePrefetcher{BasePlugin: BasePlugin{Enabled: true}},
+			queueSize:            DefaultImagePrefetcherQueueSize,
+			inspectionsPerSecond: DefaultImagePrefetcherInspectionsPerSecond,
+			burst:                DefaultImagePrefetcherBurst,
+		},
+		{
+			name:                 "configured fields override the defaults",
+			plugin:               ImagePrefetcher{QueueSize: 10, InspectionsPerSecond: 2, Burst: 3},
+			queueSize:            10,
+			inspectionsPerSecond: 2,
+			burst:                3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.plugin.QueueSizeOrDefault(); got != tt.queueSize {
+				t.Errorf("Expected queue size %d, got %d", tt.queueSize, got)
+			}
+			if got := tt.plugin.InspectionsPerSecondOrDefault(); got != tt.inspectionsPerSecond {
+				t.Errorf("Expected %d inspections per second, got %d", tt.inspectionsPerSecond, got)
+			}
+			if got := tt.plugin.BurstOrDefault(); got != tt.burst {
+				t.Errorf("Expected burst %d, got %d", tt.burst, got)
+			}
+		})
+	}
+}
+
+func TestCredentialProviders_Defaults(t *testing.T) {
+	plugin := CredentialProviders{BasePlugin: BasePlugin{Enabled: true}}
+	if got := plugin.ConfigDirOrDefault(); got != DefaultCredentialProvidersConfigDir {
+		t.Errorf("Expected config dir %s, got %s", DefaultCredentialProvidersConfigDir, got)
+	}
+	if got := plugin.BinDirOrDefault(); got != DefaultCredentialProvidersBinDir {
+		t.Errorf("Expected bin dir %s, got %s", DefaultCredentialProvidersBinDir, got)
+	}
+	plugin = CredentialProviders{ConfigDir: "/etc/credential-providers", BinDir: "/opt/credential-providers"}
+	if got := plugin.ConfigDirOrDefault(); got != "/etc/credential-providers" {
+		t.Errorf("Expected config dir /etc/credential-providers, got %s", got)
+	}
+	if got := plugin.BinDirOrDefault(); got != "/opt/credential-providers" {
+		t.Errorf("Expected bin dir /opt/credential-providers, got %s", got)
+	}
+}
+
+func TestEmulation_NativeWeightOrDefault(t *testing.T) {
+	plugin := Emulation{BasePlugin: BasePlugin{Enabled: true}}
+	if got := plugin.Name(); got != EmulationPluginName {
+		t.Errorf("Expected name %s, got %s", EmulationPluginName, got)
+	}
+	if got := plugin.NativeWeightOrDefault(); got != DefaultEmulationNativeWeight {
+		t.Errorf("Expected native weight %d, got %d", DefaultEmulationNativeWeight, got)
+	}
+	plugin.NativeWeight = 1
+	if got := plugin.NativeWeightOrDefault(); got != 1 {
+		t.Errorf("Expected native weight 1, got %d", got)
+	}
+}
+
+func TestImageSubstitution_ValidateRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []ImageSubstitutionRule
+		want  bool
+	}{
+		{
+			name: "valid rules",
+			rules: []ImageSubstitutionRule{
+				{Image: "quay.io/myorg/foo:1.2", Substitute: "quay.io/myorg/foo:1.2-arm64"},
+				{Image: "quay.io/myorg/bar:1.0", Substitute: "registry.example.com/myorg/bar:1.0"},
+			},
+			want: true,
+		},
+		{
+			name:  "image substituted with itself",
+			rules: []ImageSubstitutionRule{{Image: "quay.io/myorg/foo:1.2", Substitute: "quay.io/myorg/foo:1.2"}},
+		},
+		{
+			name: "duplicate image",
+			rules: []ImageSubstitutionRule{
+				{Image: "quay.io/myorg/foo:1.2", Substitute: "quay.io/myorg/foo:1.2-arm64"},
+				{Image: "quay.io/myorg/foo:1.2", Substitute: "quay.io/myorg/foo:1.2-s390x"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := ImageSubstitution{BasePlugin: BasePlugin{Enabled: true}, Rules: tt.rules}
+			if got := plugin.Name(); got != ImageSubstitutionPluginName {
+				t.Errorf("Expected name %s, got %s", ImageSubstitutionPluginName, got)
+			}
+			got, err := plugin.ValidateRules()
+			if got != tt.want || (err == nil) != tt.want {
+				t.Errorf("ValidateRules() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
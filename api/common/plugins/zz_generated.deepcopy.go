@@ -20,6 +20,10 @@ limitations under the License.
 
 package plugins
 
+import (
+	"k8s.io/api/core/v1"
+)
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BasePlugin) DeepCopyInto(out *BasePlugin) {
 	*out = *in
@@ -35,6 +39,72 @@ func (in *BasePlugin) DeepCopy() *BasePlugin {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialProviders) DeepCopyInto(out *CredentialProviders) {
+	*out = *in
+	out.BasePlugin = in.BasePlugin
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialProviders.
+func (in *CredentialProviders) DeepCopy() *CredentialProviders {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialProviders)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EmulatingNodes) DeepCopyInto(out *EmulatingNodes) {
+	*out = *in
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]v1.NodeSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmulatingNodes.
+func (in *EmulatingNodes) DeepCopy() *EmulatingNodes {
+	if in == nil {
+		return nil
+	}
+	out := new(EmulatingNodes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Emulation) DeepCopyInto(out *Emulation) {
+	*out = *in
+	out.BasePlugin = in.BasePlugin
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]EmulatingNodes, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Emulation.
+func (in *Emulation) DeepCopy() *Emulation {
+	if in == nil {
+		return nil
+	}
+	out := new(Emulation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExecFormatErrorMonitor) DeepCopyInto(out *ExecFormatErrorMonitor) {
 	*out = *in
@@ -51,6 +121,58 @@ func (in *ExecFormatErrorMonitor) DeepCopy() *ExecFormatErrorMonitor {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagePrefetcher) DeepCopyInto(out *ImagePrefetcher) {
+	*out = *in
+	out.BasePlugin = in.BasePlugin
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePrefetcher.
+func (in *ImagePrefetcher) DeepCopy() *ImagePrefetcher {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagePrefetcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageSubstitution) DeepCopyInto(out *ImageSubstitution) {
+	*out = *in
+	out.BasePlugin = in.BasePlugin
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]ImageSubstitutionRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageSubstitution.
+func (in *ImageSubstitution) DeepCopy() *ImageSubstitution {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageSubstitution)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageSubstitutionRule) DeepCopyInto(out *ImageSubstitutionRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageSubstitutionRule.
+func (in *ImageSubstitutionRule) DeepCopy() *ImageSubstitutionRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageSubstitutionRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalPlugins) DeepCopyInto(out *LocalPlugins) {
 	*out = *in
@@ -59,6 +181,11 @@ func (in *LocalPlugins) DeepCopyInto(out *LocalPlugins) {
 		*out = new(NodeAffinityScoring)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ImageSubstitution != nil {
+		in, out := &in.ImageSubstitution, &out.ImageSubstitution
+		*out = new(ImageSubstitution)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalPlugins.
@@ -120,6 +247,26 @@ func (in *Plugins) DeepCopyInto(out *Plugins) {
 		*out = new(ExecFormatErrorMonitor)
 		**out = **in
 	}
+	if in.ImagePrefetcher != nil {
+		in, out := &in.ImagePrefetcher, &out.ImagePrefetcher
+		*out = new(ImagePrefetcher)
+		**out = **in
+	}
+	if in.CredentialProviders != nil {
+		in, out := &in.CredentialProviders, &out.CredentialProviders
+		*out = new(CredentialProviders)
+		**out = **in
+	}
+	if in.Emulation != nil {
+		in, out := &in.Emulation, &out.Emulation
+		*out = new(Emulation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageSubstitution != nil {
+		in, out := &in.ImageSubstitution, &out.ImageSubstitution
+		*out = new(ImageSubstitution)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Plugins.
@@ -18,14 +18,18 @@ package v1beta1
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 
 	"github.com/openshift/multiarch-tuning-operator/api/common"
 	"github.com/openshift/multiarch-tuning-operator/api/common/plugins"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
 )
 
 // ClusterPodPlacementConfigSpec defines the desired state of ClusterPodPlacementConfig
@@ -52,10 +56,410 @@ type ClusterPodPlacementConfigSpec struct {
 	// FallbackArchitecture defines the architecture to use if the image inspector cannot determine the image's architecture.
 	// If configured, the PodPlacementController will set the node affinity of the pod to the fallback architecture
 	// if the image inspector cannot determine the image's architecture.
+	// It must be one of the supported architectures.
 	// +optional
 	// +kubebuilder:default=""
-	// +kubebuilder:validation:Enum=arm64;amd64;ppc64le;s390x;""
+	// +kubebuilder:validation:Pattern=`^([a-z0-9_]+)?$`
 	FallbackArchitecture string `json:"fallbackArchitecture,omitempty"`
+
+	// FallbackArchitectureErrorClasses restricts the fallback architecture to the image inspection errors of the
+	// given classes. Valid values are: "AuthDenied", "NotFound", "PolicyRejected", "Unreachable", "RateLimited",
+	// "MalformedManifest", "Unknown". If empty, the fallback architecture applies to all the error classes.
+	// +optional
+	// +listType=set
+	FallbackArchitectureErrorClasses []common.ImageInspectionErrorClass `json:"fallbackArchitectureErrorClasses,omitempty"`
+
+	// ImageInspectionCache configures the cache of the image inspection results of the pod placement controller.
+	// The cache has two levels: the first one maps the image references (tags) to the digests they resolve to,
+	// and it is revalidated with a manifest HEAD request when the entries expire or the pod's imagePullPolicy is
+	// Always. The second one maps the digests to the set of architectures they support and, as digests are immutable,
+	// its entries are long-lived.
+	// +optional
+	ImageInspectionCache *ImageInspectionCacheConfig `json:"imageInspectionCache,omitempty"`
+
+	// PlatformVariants maps the CPU variants (microarchitecture levels) the images are built for, e.g., amd64/v3, to
+	// the node selector requirements that identify the nodes able to run them. When all the builds an image ships
+	// for an architecture target a given variant, the pods using it are restricted to the nodes of that architecture
+	// matching the requirements of that variant and of the lower ones.
+	// If empty, the CPU feature labels published by Node Feature Discovery are used: amd64/v2 requires SSE4.2 and
+	// POPCNT, amd64/v3 requires AVX2 and amd64/v4 requires AVX512F. As the requirements of the lower variants apply,
+	// the attributeBlacklist of Node Feature Discovery must let the SSE42 and POPCNT labels through.
+	// +optional
+	// +listType=atomic
+	PlatformVariants []PlatformVariant `json:"platformVariants,omitempty"`
+
+	// SupportedArchitectures is the set of architectures, as reported by the kubernetes.io/arch node label, the pod
+	// placement operand labels the pods for and considers supported by the images that are not tied to a specific
+	// architecture, like the operator bundle images. The fallback architecture, the architectures in the
+	// nodeAffinityScoring plugin and the platform variants must be in this set.
+	// If empty, defaults to amd64, arm64, ppc64le and s390x.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9_]+$`
+	SupportedArchitectures []string `json:"supportedArchitectures,omitempty"`
+
+	// ArchitectureAliases extends the table used to normalize the non-canonical architecture names reported by some
+	// images, e.g., aarch64 or x86_64, to the ones reported by the kubernetes.io/arch node label.
+	// The entries of this list override the built-in ones with the same name.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ArchitectureAliases []ArchitectureAlias `json:"architectureAliases,omitempty"`
+
+	// RegistryPolicies configures how the pod placement controller accesses the registries during the image
+	// inspection: the maximum number of concurrent inspections, the timeout and the rate of the requests, and the
+	// circuit breaker that fails the inspections fast while a registry is unhealthy.
+	// The first policy whose host matches the registry of the image applies. The registries not matching any policy
+	// are only subject to the default circuit breaker.
+	// +optional
+	// +listType=map
+	// +listMapKey=host
+	RegistryPolicies []RegistryPolicy `json:"registryPolicies,omitempty"`
+
+	// OfflineImageSources configures a volume of OCI image layouts and oci-archive files the pod placement
+	// controller inspects the images from, before or instead of the registries, e.g., in the disconnected clusters
+	// where the controller cannot reach any registry.
+	// +optional
+	OfflineImageSources *OfflineImageSourcesConfig `json:"offlineImageSources,omitempty"`
+
+	// ManifestListValidation is Shallow to trust the platforms listed in the manifest lists, or Deep to fetch the
+	// manifest and the config of each of their entries and drop the platforms whose manifest or config is missing
+	// from the registry or whose config reports a different operating system or architecture. Each discrepancy is
+	// recorded as an event of the pod and counted in a metric. The inspection fails if any other error prevents
+	// fetching an entry, or if all the entries are dropped. Deep validation requires more requests to the registries.
+	// Defaults to Shallow.
+	// +optional
+	// +kubebuilder:default=Shallow
+	ManifestListValidation ManifestListValidationMode `json:"manifestListValidation,omitempty"`
+
+	// BinaryVerification configures the verification of the binaries of the images: their entrypoint and the
+	// executables in their PATH are read from the layers, and the architectures of their ELF headers are intersected
+	// with the architectures declared by the image. It catches the images whose config reports a wrong architecture
+	// and the ones mixing binaries of several architectures. As it downloads the layers of the images, it is only
+	// enabled for the images matching the given patterns.
+	// +optional
+	BinaryVerification *BinaryVerificationConfig `json:"binaryVerification,omitempty"`
+
+	// RuntimeClassMappings maps the platforms of the images that do not run as native Linux containers, e.g.,
+	// wasi/wasm for the WebAssembly images, to the RuntimeClasses able to run them. The pods whose images all
+	// support a mapped platform get the node affinity of the mapping instead of an architecture requirement, and
+	// their runtimeClassName is set at admission when the platforms of their images are already known.
+	// +optional
+	// +listType=map
+	// +listMapKey=platform
+	RuntimeClassMappings []RuntimeClassMapping `json:"runtimeClassMappings,omitempty"`
+
+	// ArchitectureAgnosticImages are the rules marking the images as architecture-agnostic, like the operator
+	// bundle images: the images matching any of them, e.g., the file-based catalog and the data-only images, are
+	// considered to support all the supported architectures, whatever the architecture they were built on.
+	// The first matching rule, in order, applies and the pods using the matched images are labelled with its name.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ArchitectureAgnosticImages []ArchitectureAgnosticImageRule `json:"architectureAgnosticImages,omitempty"`
+
+	// StaticImageArchitectures is the ordered list of the rules mapping the images matching a glob to a static set
+	// of architectures, or excluding them from the inspection, e.g., for the images that cannot be inspected from
+	// the cluster or the well-known multi-architecture images. The first matching rule applies; the rules of the
+	// PodPlacementConfigs matching a pod are evaluated before these ones. The pods whose images matched a rule are
+	// labelled with multiarch.openshift.io/arch-inference-source=static-rule.
+	// The architectures of the rules must be in the supported architectures.
+	// +optional
+	// +listType=atomic
+	StaticImageArchitectures []StaticImageArchitecture `json:"staticImageArchitectures,omitempty"`
+
+	// DigestPinning is Enabled to rewrite the images of the containers and the init containers of the gated pods to
+	// the digest they were inspected at, e.g., quay.io/myorg/foo@sha256:... for quay.io/myorg/foo:1.2, before removing
+	// the scheduling gate. A tag re-pushed with a different set of architectures after the inspection cannot then
+	// make the pod run an image other than the one its node affinity was computed for. The original images are
+	// recorded in the multiarch.openshift.io/pinned-images annotation of the pods. The images already referenced by
+	// digest, the images of the image volumes and the images that were not inspected, e.g., the ones matching a
+	// static image architecture rule, are not pinned. Neither are the images whose tags may be resolved by a mirror or a
+	// location other than their registry, e.g., the mirrors of the ImageTagMirrorSets: the nodes only pull the references by
+	// digest from their registry and the mirrors of the ImageDigestMirrorSets. The PodPlacementConfigs can override the
+	// mode for the pods they match. Defaults to Disabled.
+	// +optional
+	// +kubebuilder:default=Disabled
+	DigestPinning DigestPinningMode `json:"digestPinning,omitempty"`
+}
+
+// ArchitectureAgnosticImageRule matches the architecture-agnostic images by a label of their config, an
+// annotation of their manifest or manifest list, or a media type. Exactly one of the matchers must be set.
+type ArchitectureAgnosticImageRule struct {
+	// Name identifies the rule in the multiarch.openshift.io/arch-agnostic-rule label of the pods using the matched
+	// images.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Label matches the images whose config has the given label.
+	// +optional
+	Label *ImageMetadataMatcher `json:"label,omitempty"`
+
+	// Annotation matches the images whose manifest or manifest list has the given annotation.
+	// +optional
+	Annotation *ImageMetadataMatcher `json:"annotation,omitempty"`
+
+	// MediaType matches the images whose artifact type, config media type or any layer media type is the given
+	// one, e.g., the artifacts with no image config.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	MediaType string `json:"mediaType,omitempty"`
+}
+
+// ImageMetadataMatcher matches a label or an annotation of the images.
+type ImageMetadataMatcher struct {
+	// Key is the key of the label or the annotation.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+
+	// Value is the value of the label or the annotation. When empty, any value matches.
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// matches returns true if the given labels or annotations hold the key of the matcher, with its value if set.
+func (m *ImageMetadataMatcher) matches(metadata map[string]string) bool {
+	value, ok := metadata[m.Key]
+	return ok && (m.Value == "" || m.Value == value)
+}
+
+// Matches returns true if the given config labels, manifest annotations or media types of an image match the rule.
+func (r *ArchitectureAgnosticImageRule) Matches(labels, annotations map[string]string, mediaTypes []string) bool {
+	switch {
+	case r.Label != nil:
+		return r.Label.matches(labels)
+	case r.Annotation != nil:
+		return r.Annotation.matches(annotations)
+	case r.MediaType != "":
+		return slices.Contains(mediaTypes, r.MediaType)
+	}
+	return false
+}
+
+// RuntimeClassMapping maps a non-native platform to the RuntimeClass running its images.
+type RuntimeClassMapping struct {
+	// Platform is the os/architecture pair reported by the image inspection for the images of the mapping,
+	// e.g., wasi/wasm. The Linux platforms run as native containers and cannot be mapped.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9_]+/[a-z0-9_]+$`
+	// +kubebuilder:validation:Required
+	Platform string `json:"platform"`
+
+	// MediaTypes are the artifact types, config media types or layer media types identifying the images of the
+	// platform, in addition to the ones reporting it in their config or manifest list. The WebAssembly media types
+	// are always recognized as the wasi/wasm platform.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:items:MinLength=1
+	MediaTypes []string `json:"mediaTypes,omitempty"`
+
+	// RuntimeClassName is the name of the RuntimeClass running the images of the platform.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	RuntimeClassName string `json:"runtimeClassName"`
+
+	// MatchExpressions is the list of node selector requirements the nodes must satisfy to run the images of the
+	// platform. When empty, the pods are only constrained by the scheduling of the RuntimeClass.
+	// +optional
+	// +listType=atomic
+	MatchExpressions []corev1.NodeSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+// BinaryVerificationConfig defines the images whose binaries are verified during the image inspection.
+type BinaryVerificationConfig struct {
+	// ImagePatterns are the patterns of the images whose binaries are verified. A pattern is a registry host, with
+	// an optional port, followed by an optional repository path prefix, e.g., quay.io/myorg or *.example.com. Each
+	// dot-separated part of the host name may contain glob wildcards.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	// +listType=set
+	// +kubebuilder:validation:items:MinLength=1
+	ImagePatterns []string `json:"imagePatterns"`
+}
+
+// ManifestListValidationMode defines how thoroughly the manifest lists are validated during the image inspection.
+// +kubebuilder:validation:Enum=Shallow;Deep
+type ManifestListValidationMode string
+
+const (
+	// ManifestListValidationModeShallow trusts the platforms listed in the manifest lists.
+	ManifestListValidationModeShallow ManifestListValidationMode = "Shallow"
+	// ManifestListValidationModeDeep verifies the manifest and the config of each entry of the manifest lists.
+	ManifestListValidationModeDeep ManifestListValidationMode = "Deep"
+)
+
+// RegistryPolicy defines the inspection policy of the registries matching a host.
+type RegistryPolicy struct {
+	// Host is the registry host, with an optional port, the policy applies to. Each dot-separated part of the host
+	// name may contain glob wildcards, e.g., *.docker.io or registry-?.example.com:5000.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Host string `json:"host"`
+
+	// MaxConcurrentInspections is the maximum number of inspections of the images of the registry that can run
+	// concurrently. If unset, the concurrent inspections are not limited.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrentInspections int32 `json:"maxConcurrentInspections,omitempty"`
+
+	// Timeout is the maximum duration of the inspection of an image of the registry, including the time spent
+	// waiting for the concurrency and rate limits. If unset, the inspections are only bound by the reconciliation.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RequestsPerSecond is the rate of the token bucket limiting the inspections of the images of the registry.
+	// If unset, the inspections are not rate limited.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RequestsPerSecond int32 `json:"requestsPerSecond,omitempty"`
+
+	// Burst is the size of the token bucket limiting the inspections of the images of the registry.
+	// Defaults to RequestsPerSecond.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Burst int32 `json:"burst,omitempty"`
+
+	// CircuitBreaker configures the circuit breaker of the registry. If unset, the default circuit breaker applies.
+	// +optional
+	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker,omitempty"`
+}
+
+// CircuitBreakerConfig defines when the inspections of the images of a registry fail fast.
+// The circuit opens after FailureThreshold consecutive inspections fail because the registry is unreachable or
+// throttling. While open, the inspections fail immediately. Once OpenDuration elapses, a single inspection is
+// let through: the circuit closes if it succeeds and opens again otherwise.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit. Zero disables the circuit
+	// breaker. Defaults to 5.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+
+	// OpenDuration is the time the circuit stays open before an inspection is let through. Defaults to 30s.
+	// +optional
+	OpenDuration *metav1.Duration `json:"openDuration,omitempty"`
+}
+
+// OfflineImageSourceMode defines whether the registries are accessed for the images missing from an offline source.
+// +kubebuilder:validation:Enum=PreferOffline;OfflineOnly
+type OfflineImageSourceMode string
+
+const (
+	// OfflineImageSourceModePreferOffline inspects the images missing from the offline source in the registries.
+	OfflineImageSourceModePreferOffline OfflineImageSourceMode = "PreferOffline"
+	// OfflineImageSourceModeOfflineOnly never accesses the registries matching the prefix of the offline source.
+	OfflineImageSourceModeOfflineOnly OfflineImageSourceMode = "OfflineOnly"
+)
+
+// OfflineImageSourcesConfig defines the volume holding the OCI image layouts and oci-archive files of the offline
+// sources, and the repository prefixes they serve. Exactly one of PersistentVolumeClaimName and ConfigMapName must
+// be set. Only the manifests and the configs of the images are read: their layers can be omitted.
+type OfflineImageSourcesConfig struct {
+	// PersistentVolumeClaimName is the name of the PersistentVolumeClaim, in the namespace of the operator, holding
+	// the OCI image layouts and archives. It is mounted read-only by all the replicas of the pod placement
+	// controller: its access modes should include ReadOnlyMany.
+	// +optional
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName,omitempty"`
+
+	// ConfigMapName is the name of the ConfigMap, in the namespace of the operator, whose binaryData holds the
+	// oci-archive files, e.g., ubi.tar.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// Sources maps the repository prefixes to the directories of the volume holding their images. The image
+	// <prefix>/<repository>:<tag> is looked up in the OCI image layout directory <path>/<repository>, or in the
+	// oci-archive file <path>/<repository>.tar, by the org.opencontainers.image.ref.name annotation of its
+	// manifest. The images referenced by digest are looked up by digest. The longest matching prefix applies.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	// +listType=map
+	// +listMapKey=prefix
+	Sources []OfflineImageSource `json:"sources"`
+}
+
+// OfflineImageSource defines the directory of the offline source of the images of a repository prefix.
+type OfflineImageSource struct {
+	// Prefix is the repository prefix, e.g., quay.io/openshift or registry.example.com:5000, of the images looked up
+	// in the source.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?(:[0-9]+)?(/[a-z0-9._-]+)*$`
+	// +kubebuilder:validation:Required
+	Prefix string `json:"prefix"`
+
+	// Path is the path of the directory of the source, relative to the root of the volume. Defaults to the root of
+	// the volume.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Mode is PreferOffline to inspect the images missing from the source in the registries, or OfflineOnly to never
+	// access the registries for the images matching the prefix. Defaults to PreferOffline.
+	// +optional
+	// +kubebuilder:default=PreferOffline
+	Mode OfflineImageSourceMode `json:"mode,omitempty"`
+}
+
+// ArchitectureAlias maps a non-canonical architecture name to the canonical architecture and CPU variant.
+type ArchitectureAlias struct {
+	// Name is the non-canonical architecture name, as reported in the image manifests.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Architecture is the canonical architecture name, as reported by the kubernetes.io/arch node label.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9_]+$`
+	// +kubebuilder:validation:Required
+	Architecture string `json:"architecture"`
+
+	// Variant is the CPU variant implied by the name, if any. It is only applied when the image does not report one.
+	// +optional
+	Variant string `json:"variant,omitempty"`
+}
+
+// PlatformVariant defines the node selector requirements of the nodes able to run the images built for a
+// CPU variant of an architecture.
+type PlatformVariant struct {
+	// Architecture is the architecture of the variant. It must be one of the supported architectures.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9_]+$`
+	// +kubebuilder:validation:Required
+	Architecture string `json:"architecture"`
+
+	// Variant is the CPU variant as reported in the image manifests, e.g., v3 for amd64 or v8.2 for arm64.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	Variant string `json:"variant"`
+
+	// MatchExpressions is the list of node selector requirements the nodes must satisfy to run the images built
+	// for the variant.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	// +listType=atomic
+	MatchExpressions []corev1.NodeSelectorRequirement `json:"matchExpressions"`
+}
+
+// ImageInspectionCacheConfig defines the size and the TTLs of the image inspection cache.
+type ImageInspectionCacheConfig struct {
+	// TagCacheSize is the maximum number of image references kept in the tag-to-digest level of the cache.
+	// Defaults to 256.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TagCacheSize int32 `json:"tagCacheSize,omitempty"`
+
+	// TagTTL is the time after which the tag-to-digest entries are revalidated with a manifest HEAD request.
+	// Defaults to 6h.
+	// +optional
+	TagTTL *metav1.Duration `json:"tagTTL,omitempty"`
+
+	// DigestCacheSize is the maximum number of digests kept in the digest-to-architectures level of the cache.
+	// Defaults to 1024.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	DigestCacheSize int32 `json:"digestCacheSize,omitempty"`
+
+	// DigestTTL is the time after which the digest-to-architectures entries are evicted from the cache.
+	// Defaults to 168h (7 days).
+	// +optional
+	DigestTTL *metav1.Duration `json:"digestTTL,omitempty"`
 }
 
 // ClusterPodPlacementConfigStatus defines the observed state of ClusterPodPlacementConfig
@@ -203,6 +607,28 @@ func (s *ClusterPodPlacementConfigStatus) buildConditions() {
 	})
 }
 
+// SetUnhealthyRegistries sets the RegistriesUnhealthy condition given the registries whose circuit breaker is open.
+// It returns true if the condition changed.
+func (s *ClusterPodPlacementConfigStatus) SetUnhealthyRegistries(registries []string) bool {
+	condition := metav1.Condition{
+		Type:    RegistriesUnhealthyType,
+		Status:  metav1.ConditionFalse,
+		Reason:  AllRegistriesHealthyReason,
+		Message: RegistriesHealthyMsg,
+	}
+	if len(registries) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = CircuitBreakerOpenReason
+		condition.Message = fmt.Sprintf(RegistriesUnhealthyMsg, strings.Join(registries, ", "))
+	}
+	if current := v1helpers.FindCondition(s.Conditions, RegistriesUnhealthyType); current != nil &&
+		current.Status == condition.Status && current.Reason == condition.Reason && current.Message == condition.Message {
+		return false
+	}
+	v1helpers.SetCondition(&s.Conditions, condition)
+	return true
+}
+
 // ClusterPodPlacementConfig defines the configuration for the architecture aware pod placement operand.
 // Users can only deploy a single object named "cluster".
 // Creating the object enables the operand.
@@ -223,6 +649,88 @@ type ClusterPodPlacementConfig struct {
 	Status ClusterPodPlacementConfigStatus `json:"status,omitempty"`
 }
 
+// FallbackArchitectureFor returns the fallback architecture to apply for an image inspection error of the given
+// class, or an empty string if the fallback architecture does not apply to it.
+func (c *ClusterPodPlacementConfig) FallbackArchitectureFor(class common.ImageInspectionErrorClass) string {
+	if c == nil || c.Spec.FallbackArchitecture == "" {
+		return ""
+	}
+	if len(c.Spec.FallbackArchitectureErrorClasses) == 0 || slices.Contains(c.Spec.FallbackArchitectureErrorClasses, class) {
+		return c.Spec.FallbackArchitecture
+	}
+	return ""
+}
+
+// PlatformVariantsOrDefault returns the configured platform variants or, if none, the default ones based on the
+// Node Feature Discovery CPU feature labels.
+func (c *ClusterPodPlacementConfig) PlatformVariantsOrDefault() []PlatformVariant {
+	if c == nil || len(c.Spec.PlatformVariants) == 0 {
+		return DefaultPlatformVariants()
+	}
+	return c.Spec.PlatformVariants
+}
+
+// SupportedArchitecturesOrDefault returns the configured set of supported architectures or, if none,
+// the default one.
+func (c *ClusterPodPlacementConfig) SupportedArchitecturesOrDefault() sets.Set[string] {
+	if c == nil || len(c.Spec.SupportedArchitectures) == 0 {
+		return utils.AllSupportedArchitecturesSet()
+	}
+	return sets.New[string](c.Spec.SupportedArchitectures...)
+}
+
+// RuntimeClassMappings returns the configured RuntimeClass mappings, or nil if the ClusterPodPlacementConfig does not
+// exist.
+func (c *ClusterPodPlacementConfig) RuntimeClassMappings() []RuntimeClassMapping {
+	if c == nil {
+		return nil
+	}
+	return c.Spec.RuntimeClassMappings
+}
+
+// Emulation returns the configuration of the emulation plugin, or nil if the ClusterPodPlacementConfig does not exist
+// or the plugin is not enabled.
+func (c *ClusterPodPlacementConfig) Emulation() *plugins.Emulation {
+	if c == nil || !c.PluginsEnabled(common.EmulationPluginName) {
+		return nil
+	}
+	return c.Spec.Plugins.Emulation
+}
+
+// ImageSubstitution returns the configuration of the image substitution plugin, or nil if the
+// ClusterPodPlacementConfig does not exist or the plugin is not enabled.
+func (c *ClusterPodPlacementConfig) ImageSubstitution() *plugins.ImageSubstitution {
+	if c == nil || !c.PluginsEnabled(common.ImageSubstitutionPluginName) {
+		return nil
+	}
+	return c.Spec.Plugins.ImageSubstitution
+}
+
+// ArchitectureAgnosticImages returns the configured architecture-agnostic image rules, or nil if the
+// ClusterPodPlacementConfig does not exist.
+func (c *ClusterPodPlacementConfig) ArchitectureAgnosticImages() []ArchitectureAgnosticImageRule {
+	if c == nil {
+		return nil
+	}
+	return c.Spec.ArchitectureAgnosticImages
+}
+
+// StaticImageArchitectures returns the static image architecture rules of the ClusterPodPlacementConfig, if any.
+func (c *ClusterPodPlacementConfig) StaticImageArchitectures() []StaticImageArchitecture {
+	if c == nil {
+		return nil
+	}
+	return c.Spec.StaticImageArchitectures
+}
+
+// DigestPinning returns the digest pinning mode of the ClusterPodPlacementConfig, or Disabled if it does not exist.
+func (c *ClusterPodPlacementConfig) DigestPinning() DigestPinningMode {
+	if c == nil || c.Spec.DigestPinning == "" {
+		return DigestPinningModeDisabled
+	}
+	return c.Spec.DigestPinning
+}
+
 func (c *ClusterPodPlacementConfig) PluginsEnabled(plugin common.Plugin) bool {
 	if c.Spec.Plugins != nil {
 		return c.Spec.Plugins.PluginEnabled(plugin)
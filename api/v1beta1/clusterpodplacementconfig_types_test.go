@@ -1,9 +1,13 @@
 package v1beta1
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
 )
 
 func Test_conditionFromBool(t *testing.T) {
@@ -247,3 +251,75 @@ func TestClusterPodPlacementConfigStatus_Build(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterPodPlacementConfig_FallbackArchitectureFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		cppc  *ClusterPodPlacementConfig
+		class common.ImageInspectionErrorClass
+		want  string
+	}{
+		{
+			name:  "nil config",
+			cppc:  nil,
+			class: common.ImageInspectionErrorNotFound,
+			want:  "",
+		},
+		{
+			name:  "no fallback architecture",
+			cppc:  &ClusterPodPlacementConfig{},
+			class: common.ImageInspectionErrorNotFound,
+		},
+		{
+			name: "no error classes selects all the classes",
+			cppc: &ClusterPodPlacementConfig{Spec: ClusterPodPlacementConfigSpec{
+				FallbackArchitecture: "amd64",
+			}},
+			class: common.ImageInspectionErrorUnreachable,
+			want:  "amd64",
+		},
+		{
+			name: "selected error class",
+			cppc: &ClusterPodPlacementConfig{Spec: ClusterPodPlacementConfigSpec{
+				FallbackArchitecture:             "amd64",
+				FallbackArchitectureErrorClasses: []common.ImageInspectionErrorClass{common.ImageInspectionErrorUnreachable},
+			}},
+			class: common.ImageInspectionErrorUnreachable,
+			want:  "amd64",
+		},
+		{
+			name: "non-selected error class",
+			cppc: &ClusterPodPlacementConfig{Spec: ClusterPodPlacementConfigSpec{
+				FallbackArchitecture:             "amd64",
+				FallbackArchitectureErrorClasses: []common.ImageInspectionErrorClass{common.ImageInspectionErrorUnreachable},
+			}},
+			class: common.ImageInspectionErrorAuthDenied,
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cppc.FallbackArchitectureFor(tt.class); got != tt.want {
+				t.Errorf("FallbackArchitectureFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterPodPlacementConfigStatus_SetUnhealthyRegistries(t *testing.T) {
+	s := &ClusterPodPlacementConfigStatus{}
+	if !s.SetUnhealthyRegistries(nil) {
+		t.Fatal("expected the condition to be added")
+	}
+	if s.SetUnhealthyRegistries([]string{}) {
+		t.Fatal("expected the condition not to change")
+	}
+	if !s.SetUnhealthyRegistries([]string{"docker.io", "quay.io"}) {
+		t.Fatal("expected the condition to change")
+	}
+	condition := v1helpers.FindCondition(s.Conditions, RegistriesUnhealthyType)
+	if condition == nil || condition.Status != v1.ConditionTrue || condition.Reason != CircuitBreakerOpenReason ||
+		!strings.Contains(condition.Message, "docker.io, quay.io") {
+		t.Errorf("unexpected condition %v", condition)
+	}
+}
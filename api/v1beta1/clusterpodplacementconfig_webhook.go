@@ -20,10 +20,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common/plugins"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
 )
 
 // +kubebuilder:webhook:path=/validate-multiarch-openshift-io-v1beta1-clusterpodplacementconfig,mutating=false,failurePolicy=fail,sideEffects=None,groups=multiarch.openshift.io,resources=clusterpodplacementconfigs,verbs=create;update;delete,versions=v1beta1,name=validate-clusterpodplacementconfig.multiarch.openshift.io,admissionReviewVersions=v1
@@ -63,6 +71,44 @@ func (v *ClusterPodPlacementConfigValidator) ValidateDelete(ctx context.Context,
 }
 
 func (v *ClusterPodPlacementConfigValidator) validate(cppc *ClusterPodPlacementConfig) (warnings admission.Warnings, err error) {
+	if err := validateImageInspectionCache(cppc.Spec.ImageInspectionCache); err != nil {
+		return nil, err
+	}
+	if err := validatePlatformVariants(cppc.Spec.PlatformVariants); err != nil {
+		return nil, err
+	}
+	if err := validateSupportedArchitectures(cppc); err != nil {
+		return nil, err
+	}
+	if err := validateRegistryPolicies(cppc.Spec.RegistryPolicies); err != nil {
+		return nil, err
+	}
+	if err := validateOfflineImageSources(cppc.Spec.OfflineImageSources); err != nil {
+		return nil, err
+	}
+	if err := validateBinaryVerification(cppc.Spec.BinaryVerification); err != nil {
+		return nil, err
+	}
+	if err := validateRuntimeClassMappings(cppc.Spec.RuntimeClassMappings); err != nil {
+		return nil, err
+	}
+	if cppc.Spec.Plugins != nil {
+		if err := validateEmulation(cppc.Spec.Plugins.Emulation); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateArchitectureAgnosticImages(cppc.Spec.ArchitectureAgnosticImages); err != nil {
+		return nil, err
+	}
+	if err := ValidateStaticImageArchitectures(".spec.staticImageArchitectures",
+		cppc.Spec.StaticImageArchitectures); err != nil {
+		return nil, err
+	}
+	if substitution := cppc.ImageSubstitution(); substitution != nil {
+		if ok, err := substitution.ValidateRules(); !ok {
+			return nil, err
+		}
+	}
 	if cppc.Spec.Plugins == nil || cppc.Spec.Plugins.NodeAffinityScoring == nil {
 		return nil, nil
 	}
@@ -76,3 +122,191 @@ func (v *ClusterPodPlacementConfigValidator) validate(cppc *ClusterPodPlacementC
 	}
 	return nil, nil
 }
+
+func validateImageInspectionCache(cacheConfig *ImageInspectionCacheConfig) error {
+	if cacheConfig == nil {
+		return nil
+	}
+	if cacheConfig.TagTTL != nil && cacheConfig.TagTTL.Duration <= 0 {
+		return errors.New(".spec.imageInspectionCache.tagTTL must be a positive duration")
+	}
+	if cacheConfig.DigestTTL != nil && cacheConfig.DigestTTL.Duration <= 0 {
+		return errors.New(".spec.imageInspectionCache.digestTTL must be a positive duration")
+	}
+	return nil
+}
+
+func validatePlatformVariants(platformVariants []PlatformVariant) error {
+	variants := make(map[string]struct{})
+	for _, platformVariant := range platformVariants {
+		key := platformVariant.Architecture + "/" + platformVariant.Variant
+		if _, ok := variants[key]; ok {
+			return fmt.Errorf("duplicate variant %s in the .spec.platformVariants list", key)
+		}
+		variants[key] = struct{}{}
+	}
+	return nil
+}
+
+// validateRegistryPolicies verifies that the hosts of the registry policies are valid globs and that the durations
+// are positive.
+func validateRegistryPolicies(policies []RegistryPolicy) error {
+	for _, policy := range policies {
+		for _, part := range strings.Split(policy.Host, ".") {
+			if _, err := path.Match(part, ""); err != nil {
+				return fmt.Errorf(".spec.registryPolicies host %q is not a valid glob: %w", policy.Host, err)
+			}
+		}
+		if policy.Timeout != nil && policy.Timeout.Duration <= 0 {
+			return fmt.Errorf(".spec.registryPolicies timeout of %q must be a positive duration", policy.Host)
+		}
+		if policy.CircuitBreaker != nil && policy.CircuitBreaker.OpenDuration != nil &&
+			policy.CircuitBreaker.OpenDuration.Duration <= 0 {
+			return fmt.Errorf(".spec.registryPolicies circuitBreaker.openDuration of %q must be a positive duration",
+				policy.Host)
+		}
+	}
+	return nil
+}
+
+// validateOfflineImageSources verifies that the offline sources are backed by exactly one volume and that their paths
+// stay in the volume. The keys of a ConfigMap cannot hold directories: its sources must be at the root of the volume.
+func validateOfflineImageSources(config *OfflineImageSourcesConfig) error {
+	if config == nil {
+		return nil
+	}
+	if (config.PersistentVolumeClaimName == "") == (config.ConfigMapName == "") {
+		return errors.New(".spec.offlineImageSources must set exactly one of persistentVolumeClaimName and configMapName")
+	}
+	for _, source := range config.Sources {
+		if source.Path == "" {
+			continue
+		}
+		if !filepath.IsLocal(source.Path) {
+			return fmt.Errorf(".spec.offlineImageSources path of %q must be relative to the root of the volume",
+				source.Prefix)
+		}
+		if config.ConfigMapName != "" {
+			return fmt.Errorf(".spec.offlineImageSources path of %q must be empty with a ConfigMap", source.Prefix)
+		}
+	}
+	return nil
+}
+
+// validateBinaryVerification verifies that the hosts of the image patterns of the binary verification are valid
+// globs.
+func validateBinaryVerification(config *BinaryVerificationConfig) error {
+	if config == nil {
+		return nil
+	}
+	for _, pattern := range config.ImagePatterns {
+		host, _, _ := strings.Cut(pattern, "/")
+		for _, part := range strings.Split(host, ".") {
+			if _, err := path.Match(part, ""); err != nil {
+				return fmt.Errorf(".spec.binaryVerification image pattern %q is not a valid glob: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRuntimeClassMappings verifies that the mappings do not map the native Linux platforms, that the names of
+// their RuntimeClasses are valid and that a media type identifies a single platform.
+func validateRuntimeClassMappings(mappings []RuntimeClassMapping) error {
+	mediaTypes := make(map[string]string)
+	for _, mapping := range mappings {
+		if os, _, _ := strings.Cut(mapping.Platform, "/"); os == utils.OSLinux {
+			return fmt.Errorf(".spec.runtimeClassMappings platform %q runs as native containers and cannot be mapped",
+				mapping.Platform)
+		}
+		if errs := validation.IsDNS1123Subdomain(mapping.RuntimeClassName); len(errs) > 0 {
+			return fmt.Errorf(".spec.runtimeClassMappings runtimeClassName %q is not valid: %s",
+				mapping.RuntimeClassName, strings.Join(errs, ", "))
+		}
+		for _, mediaType := range mapping.MediaTypes {
+			if platform, ok := mediaTypes[mediaType]; ok {
+				return fmt.Errorf(".spec.runtimeClassMappings media type %q is mapped to both %s and %s", mediaType,
+					platform, mapping.Platform)
+			}
+			mediaTypes[mediaType] = mapping.Platform
+		}
+	}
+	return nil
+}
+
+// validateEmulation verifies that each emulating node set of the emulation plugin selects the nodes by at least one
+// requirement: an empty set would allow the emulated pods to run on any node.
+func validateEmulation(emulation *plugins.Emulation) error {
+	if emulation == nil {
+		return nil
+	}
+	for i, nodes := range emulation.Nodes {
+		if len(nodes.MatchExpressions) == 0 {
+			return fmt.Errorf(".spec.plugins.emulation.nodes[%d].matchExpressions must not be empty", i)
+		}
+	}
+	return nil
+}
+
+// validateArchitectureAgnosticImages verifies that each architecture-agnostic image rule sets exactly one matcher.
+func validateArchitectureAgnosticImages(rules []ArchitectureAgnosticImageRule) error {
+	for _, rule := range rules {
+		matchers := 0
+		for _, set := range []bool{rule.Label != nil, rule.Annotation != nil, rule.MediaType != ""} {
+			if set {
+				matchers++
+			}
+		}
+		if matchers != 1 {
+			return fmt.Errorf(".spec.architectureAgnosticImages rule %q must set exactly one of label, annotation "+
+				"and mediaType", rule.Name)
+		}
+	}
+	return nil
+}
+
+// validateSupportedArchitectures verifies that the architectures referenced in the spec are in the set of the
+// supported architectures.
+func validateSupportedArchitectures(cppc *ClusterPodPlacementConfig) error {
+	supportedArchitectures := cppc.SupportedArchitecturesOrDefault()
+	if cppc.Spec.FallbackArchitecture != "" && !supportedArchitectures.Has(cppc.Spec.FallbackArchitecture) {
+		return fmt.Errorf(".spec.fallbackArchitecture %q is not in the supported architectures %v",
+			cppc.Spec.FallbackArchitecture, sets.List(supportedArchitectures))
+	}
+	for _, platformVariant := range cppc.Spec.PlatformVariants {
+		if !supportedArchitectures.Has(platformVariant.Architecture) {
+			return fmt.Errorf(".spec.platformVariants architecture %q is not in the supported architectures %v",
+				platformVariant.Architecture, sets.List(supportedArchitectures))
+		}
+	}
+	for _, rule := range cppc.Spec.StaticImageArchitectures {
+		for _, architecture := range rule.Architectures {
+			if !supportedArchitectures.Has(architecture) {
+				return fmt.Errorf(".spec.staticImageArchitectures architecture %q is not in the supported architectures %v",
+					architecture, sets.List(supportedArchitectures))
+			}
+		}
+	}
+	if cppc.Spec.Plugins == nil {
+		return nil
+	}
+	if cppc.Spec.Plugins.NodeAffinityScoring != nil {
+		for _, term := range cppc.Spec.Plugins.NodeAffinityScoring.Platforms {
+			if !supportedArchitectures.Has(term.Architecture) {
+				return fmt.Errorf(".spec.plugins.nodeAffinityScoring.platforms architecture %q is not in the supported architectures %v",
+					term.Architecture, sets.List(supportedArchitectures))
+			}
+		}
+	}
+	if cppc.Spec.Plugins.Emulation != nil {
+		for _, nodes := range cppc.Spec.Plugins.Emulation.Nodes {
+			for _, architecture := range nodes.Architectures {
+				if !supportedArchitectures.Has(architecture) {
+					return fmt.Errorf(".spec.plugins.emulation.nodes architecture %q is not in the supported architectures %v",
+						architecture, sets.List(supportedArchitectures))
+				}
+			}
+		}
+	}
+	return nil
+}
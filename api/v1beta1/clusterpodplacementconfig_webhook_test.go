@@ -0,0 +1,378 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common/plugins"
+)
+
+func Test_validateSupportedArchitectures(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    ClusterPodPlacementConfigSpec
+		wantErr bool
+	}{
+		{
+			name: "default supported architectures",
+			spec: ClusterPodPlacementConfigSpec{FallbackArchitecture: "arm64"},
+		},
+		{
+			name:    "fallback architecture not in the default supported architectures",
+			spec:    ClusterPodPlacementConfigSpec{FallbackArchitecture: "riscv64"},
+			wantErr: true,
+		},
+		{
+			name: "fallback architecture in the configured supported architectures",
+			spec: ClusterPodPlacementConfigSpec{
+				FallbackArchitecture:   "riscv64",
+				SupportedArchitectures: []string{"amd64", "riscv64"},
+			},
+		},
+		{
+			name: "platform variant not in the configured supported architectures",
+			spec: ClusterPodPlacementConfigSpec{
+				SupportedArchitectures: []string{"riscv64"},
+				PlatformVariants:       DefaultPlatformVariants(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "node affinity scoring platform not in the configured supported architectures",
+			spec: ClusterPodPlacementConfigSpec{
+				SupportedArchitectures: []string{"amd64", "arm64"},
+				Plugins: &plugins.Plugins{
+					NodeAffinityScoring: &plugins.NodeAffinityScoring{
+						Platforms: []plugins.NodeAffinityScoringPlatformTerm{
+							{Architecture: "amd64", Weight: 10},
+							{Architecture: "s390x", Weight: 10},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "emulated architecture not in the configured supported architectures",
+			spec: ClusterPodPlacementConfigSpec{
+				SupportedArchitectures: []string{"amd64", "arm64"},
+				Plugins: &plugins.Plugins{
+					Emulation: &plugins.Emulation{
+						Nodes: []plugins.EmulatingNodes{
+							{Architectures: []string{"amd64"}},
+							{Architectures: []string{"riscv64"}},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "static image architecture not in the configured supported architectures",
+			spec: ClusterPodPlacementConfigSpec{
+				SupportedArchitectures: []string{"amd64", "arm64"},
+				StaticImageArchitectures: []StaticImageArchitecture{
+					{ImagePattern: "quay.io/myorg/*", Architectures: []string{"riscv64"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "emulated architectures in the default supported architectures",
+			spec: ClusterPodPlacementConfigSpec{
+				Plugins: &plugins.Plugins{
+					Emulation: &plugins.Emulation{
+						Nodes: []plugins.EmulatingNodes{{Architectures: []string{"amd64", "s390x"}}},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSupportedArchitectures(&ClusterPodPlacementConfig{Spec: tt.spec})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSupportedArchitectures() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateRegistryPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []RegistryPolicy
+		wantErr  bool
+	}{
+		{
+			name: "valid policies",
+			policies: []RegistryPolicy{
+				{Host: "*.docker.io", Timeout: &metav1.Duration{Duration: time.Minute}},
+				{Host: "registry-?.example.com:5000", MaxConcurrentInspections: 2},
+			},
+		},
+		{
+			name:     "invalid host glob",
+			policies: []RegistryPolicy{{Host: "registry-[.example.com"}},
+			wantErr:  true,
+		},
+		{
+			name:     "non-positive timeout",
+			policies: []RegistryPolicy{{Host: "quay.io", Timeout: &metav1.Duration{}}},
+			wantErr:  true,
+		},
+		{
+			name: "non-positive circuit breaker open duration",
+			policies: []RegistryPolicy{{Host: "quay.io", CircuitBreaker: &CircuitBreakerConfig{
+				OpenDuration: &metav1.Duration{Duration: -time.Second},
+			}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRegistryPolicies(tt.policies)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRegistryPolicies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateOfflineImageSources(t *testing.T) {
+	sources := []OfflineImageSource{{Prefix: "quay.io/openshift"}}
+	tests := []struct {
+		name    string
+		config  *OfflineImageSourcesConfig
+		wantErr bool
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name: "persistent volume claim with paths",
+			config: &OfflineImageSourcesConfig{PersistentVolumeClaimName: "images", Sources: []OfflineImageSource{
+				{Prefix: "quay.io/openshift", Path: "openshift"},
+				{Prefix: "registry.example.com:5000", Path: "example/images", Mode: OfflineImageSourceModeOfflineOnly},
+			}},
+		},
+		{
+			name:   "config map",
+			config: &OfflineImageSourcesConfig{ConfigMapName: "images", Sources: sources},
+		},
+		{
+			name:    "no volume",
+			config:  &OfflineImageSourcesConfig{Sources: sources},
+			wantErr: true,
+		},
+		{
+			name: "both volumes",
+			config: &OfflineImageSourcesConfig{PersistentVolumeClaimName: "images", ConfigMapName: "images",
+				Sources: sources},
+			wantErr: true,
+		},
+		{
+			name: "path out of the volume",
+			config: &OfflineImageSourcesConfig{PersistentVolumeClaimName: "images", Sources: []OfflineImageSource{
+				{Prefix: "quay.io/openshift", Path: "../openshift"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "absolute path",
+			config: &OfflineImageSourcesConfig{PersistentVolumeClaimName: "images", Sources: []OfflineImageSource{
+				{Prefix: "quay.io/openshift", Path: "/openshift"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "config map with a path",
+			config: &OfflineImageSourcesConfig{ConfigMapName: "images", Sources: []OfflineImageSource{
+				{Prefix: "quay.io/openshift", Path: "openshift"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOfflineImageSources(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateOfflineImageSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateBinaryVerification(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *BinaryVerificationConfig
+		wantErr bool
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:   "hosts and repository prefixes",
+			config: &BinaryVerificationConfig{ImagePatterns: []string{"quay.io/openshift", "*.example.com:5000", "registry-?.io/[ab]*"}},
+		},
+		{
+			name:    "invalid host glob",
+			config:  &BinaryVerificationConfig{ImagePatterns: []string{"quay.io/openshift", "[quay.io"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBinaryVerification(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateBinaryVerification() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateRuntimeClassMappings(t *testing.T) {
+	tests := []struct {
+		name     string
+		mappings []RuntimeClassMapping
+		wantErr  bool
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name: "wasm and custom platforms",
+			mappings: []RuntimeClassMapping{
+				{Platform: "wasi/wasm", RuntimeClassName: "wasmtime"},
+				{Platform: "unikernel/amd64", RuntimeClassName: "unikraft", MediaTypes: []string{"application/vnd.unikraft.config.v1+json"}},
+			},
+		},
+		{
+			name:     "native platform",
+			mappings: []RuntimeClassMapping{{Platform: "linux/amd64", RuntimeClassName: "kata"}},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid RuntimeClass name",
+			mappings: []RuntimeClassMapping{{Platform: "wasi/wasm", RuntimeClassName: "Wasm_Time"}},
+			wantErr:  true,
+		},
+		{
+			name: "media type mapped twice",
+			mappings: []RuntimeClassMapping{
+				{Platform: "wasi/wasm", RuntimeClassName: "wasmtime", MediaTypes: []string{"application/vnd.example+json"}},
+				{Platform: "unikernel/amd64", RuntimeClassName: "unikraft", MediaTypes: []string{"application/vnd.example+json"}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRuntimeClassMappings(tt.mappings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRuntimeClassMappings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateEmulation(t *testing.T) {
+	tests := []struct {
+		name      string
+		emulation *plugins.Emulation
+		wantErr   bool
+	}{
+		{
+			name: "valid emulating nodes",
+			emulation: &plugins.Emulation{Nodes: []plugins.EmulatingNodes{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "qemu.example.com/amd64", Operator: corev1.NodeSelectorOpExists},
+				},
+				Architectures: []string{"amd64"},
+			}}},
+		},
+		{
+			name: "emulating nodes with no match expressions",
+			emulation: &plugins.Emulation{Nodes: []plugins.EmulatingNodes{
+				{Architectures: []string{"amd64"}},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEmulation(tt.emulation)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateEmulation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateArchitectureAgnosticImages(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []ArchitectureAgnosticImageRule
+		wantErr bool
+	}{
+		{
+			name: "valid rules",
+			rules: []ArchitectureAgnosticImageRule{
+				{Name: "catalogs", Label: &ImageMetadataMatcher{Key: "operators.operatorframework.io.index.configs.v1"}},
+				{Name: "data", Annotation: &ImageMetadataMatcher{Key: "example.com/data-only", Value: "true"}},
+				{Name: "models", MediaType: "application/vnd.example.model.v1"},
+			},
+		},
+		{
+			name:    "no matcher",
+			rules:   []ArchitectureAgnosticImageRule{{Name: "empty"}},
+			wantErr: true,
+		},
+		{
+			name: "several matchers",
+			rules: []ArchitectureAgnosticImageRule{{
+				Name:      "both",
+				Label:     &ImageMetadataMatcher{Key: "example.com/data-only"},
+				MediaType: "application/vnd.example.model.v1",
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateArchitectureAgnosticImages(tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateArchitectureAgnosticImages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArchitectureAgnosticImageRule_Matches(t *testing.T) {
+	labels := map[string]string{"example.com/kind": "catalog"}
+	annotations := map[string]string{"example.com/data-only": "true"}
+	mediaTypes := []string{"application/vnd.oci.image.config.v1+json", "application/vnd.example.model.v1"}
+	tests := []struct {
+		name string
+		rule ArchitectureAgnosticImageRule
+		want bool
+	}{
+		{name: "label key", rule: ArchitectureAgnosticImageRule{Label: &ImageMetadataMatcher{Key: "example.com/kind"}}, want: true},
+		{name: "label value", rule: ArchitectureAgnosticImageRule{Label: &ImageMetadataMatcher{Key: "example.com/kind", Value: "data"}}},
+		{name: "label as annotation", rule: ArchitectureAgnosticImageRule{Annotation: &ImageMetadataMatcher{Key: "example.com/kind"}}},
+		{name: "annotation value", rule: ArchitectureAgnosticImageRule{Annotation: &ImageMetadataMatcher{Key: "example.com/data-only", Value: "true"}}, want: true},
+		{name: "media type", rule: ArchitectureAgnosticImageRule{MediaType: "application/vnd.example.model.v1"}, want: true},
+		{name: "no matcher"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(labels, annotations, mediaTypes); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,8 @@ const (
 	DegradedType                             = "Degraded"
 	ProgressingType                          = "Progressing"
 	DeprovisioningType                       = "Deprovisioning"
+	// RegistriesUnhealthyType is reported by the pod placement controller rather than by the operator.
+	RegistriesUnhealthyType = "RegistriesUnhealthy"
 
 	MutatingWebhookConfigurationReadyMsg = "The mutating webhook configuration is %sready."
 	PodPlacementControllerRolledOutMsg   = "The pod placement controller is %sfully rolled out."
@@ -21,4 +23,9 @@ const (
 	PendingDeprovisioningMsg             = "Some pods may still have the " + utils.SchedulingGateName +
 		"scheduling gate. The pod placement controller is updating them and will terminate."
 	AllComponentsReady = "AllComponentsReady"
+
+	RegistriesUnhealthyMsg     = "The circuit breaker of the registries %s is open: the inspections of their images fail fast."
+	RegistriesHealthyMsg       = "The circuit breakers of all the registries are closed."
+	CircuitBreakerOpenReason   = "CircuitBreakerOpen"
+	AllRegistriesHealthyReason = "AllRegistriesHealthy"
 )
@@ -0,0 +1,30 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+// DigestPinningMode defines whether the images of the containers of the gated pods are pinned to the digest they
+// were inspected at.
+// +kubebuilder:validation:Enum=Disabled;Enabled
+type DigestPinningMode string
+
+const (
+	// DigestPinningModeDisabled keeps the images of the containers as they are.
+	DigestPinningModeDisabled DigestPinningMode = "Disabled"
+	// DigestPinningModeEnabled rewrites the images of the containers to the digest they were inspected at before
+	// removing the scheduling gate, so that the kubelet pulls the image the node affinity was computed for.
+	DigestPinningModeEnabled DigestPinningMode = "Enabled"
+)
@@ -41,6 +41,7 @@ func addKnownTypes(s *runtime.Scheme) error {
 		&ClusterPodPlacementConfig{}, &ClusterPodPlacementConfigList{},
 		&PodPlacementConfig{}, &PodPlacementConfigList{},
 		&ENoExecEvent{}, &ENoExecEventList{},
+		&ImageArchitecture{}, &ImageArchitectureList{},
 	)
 	metav1.AddToGroupVersion(s, GroupVersion)
 	return nil
@@ -52,3 +53,5 @@ const PodPlacementConfigResource = "podplacementconfigs"
 const PodPlacementConfigKind = "PodPlacementConfig"
 const ENoExecEventKind = "ENoExecEvent"
 const ENoExecEventResource = "enoexecevents"
+const ImageArchitectureKind = "ImageArchitecture"
+const ImageArchitectureResource = "imagearchitectures"
@@ -0,0 +1,158 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ImageArchitectureSource identifies where the architectures recorded in an ImageArchitecture object come from.
+// +kubebuilder:validation:Enum=Registry;Offline
+type ImageArchitectureSource string
+
+const (
+	// ImageArchitectureSourceRegistry is used when the architectures were computed by inspecting the image
+	// manifest (and config) in its registry.
+	ImageArchitectureSourceRegistry ImageArchitectureSource = "Registry"
+	// ImageArchitectureSourceOffline is used when the architectures were computed by inspecting the image in an
+	// offline image source of the ClusterPodPlacementConfig, i.e., an OCI layout or an oci-archive.
+	ImageArchitectureSourceOffline ImageArchitectureSource = "Offline"
+)
+
+// ImagePlatform is a platform supported by an image.
+type ImagePlatform struct {
+	// OS is the operating system, e.g., linux or windows. Defaults to linux.
+	// +optional
+	OS string `json:"os,omitempty"`
+
+	// Architecture is the CPU architecture, e.g., amd64 or arm64.
+	// +kubebuilder:validation:Required
+	Architecture string `json:"architecture"`
+
+	// Variant is the CPU variant, e.g., v3 for amd64 or v7 for arm.
+	// +optional
+	Variant string `json:"variant,omitempty"`
+}
+
+// ManifestListDiscrepancy is an entry of the manifest list of an image dropped by the deep validation of the
+// manifest lists.
+type ManifestListDiscrepancy struct {
+	// Digest is the digest of the dropped entry.
+	// +kubebuilder:validation:Required
+	Digest string `json:"digest"`
+
+	// Platform is the platform of the entry, as declared in the manifest list.
+	// +kubebuilder:validation:Required
+	Platform ImagePlatform `json:"platform"`
+
+	// Reason is Unresolvable if the manifest or the config of the entry is missing from the registry, or
+	// PlatformMismatch if its config reports a different platform.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Unresolvable;PlatformMismatch
+	Reason string `json:"reason"`
+
+	// Message details the discrepancy.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ImageArchitectureSpec records the result of the inspection of an image, identified by its digest.
+// Digests are content-addressed, but the architectures recorded for a digest depend on the image inspection settings
+// of the ClusterPodPlacementConfig, e.g., the supported architectures or the architecture-agnostic rules. The objects
+// are stamped with the hash of these settings in the multiarch.openshift.io/inspection-config-hash annotation: the
+// objects with a different hash are ignored, and overwritten once the image is inspected again.
+type ImageArchitectureSpec struct {
+	// Digest is the digest of the inspected manifest or manifest list (index).
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`
+	Digest string `json:"digest"`
+
+	// ImageReference is the image reference that was inspected when the object was recorded.
+	// Other references (tags, mirrors) can resolve to the same digest.
+	// +optional
+	ImageReference string `json:"imageReference,omitempty"`
+
+	// Architectures is the set of architectures supported by the image.
+	// +optional
+	// +listType=set
+	Architectures []string `json:"architectures,omitempty"`
+
+	// Platforms is the list of platforms, including the CPU variants, supported by the image.
+	// +optional
+	// +listType=atomic
+	Platforms []ImagePlatform `json:"platforms,omitempty"`
+
+	// InspectionTime is the time at which the image was inspected.
+	// +optional
+	InspectionTime metav1.Time `json:"inspectionTime,omitempty"`
+
+	// Source is the origin of the architectures recorded in this object: Registry if the image was inspected in its
+	// registry, or Offline if it was inspected in an offline image source.
+	// +optional
+	Source ImageArchitectureSource `json:"source,omitempty"`
+
+	// Mirror is the location of the mirror the image was inspected from, e.g., mirror.example.com/openshift, as
+	// configured by the ImageDigestMirrorSets and the ImageTagMirrorSets. It is empty if the image was inspected from
+	// its source.
+	// +optional
+	Mirror string `json:"mirror,omitempty"`
+
+	// ArchitectureAgnosticRule is the name of the architecture-agnostic image rule of the ClusterPodPlacementConfig
+	// the image matched when it was inspected, if any.
+	// +optional
+	ArchitectureAgnosticRule string `json:"architectureAgnosticRule,omitempty"`
+
+	// ManifestListDiscrepancies is the list of the entries of the manifest list dropped by the deep validation of the
+	// manifest lists when the image was inspected. They are reported to the pods using the image.
+	// +optional
+	// +listType=atomic
+	ManifestListDiscrepancies []ManifestListDiscrepancy `json:"manifestListDiscrepancies,omitempty"`
+}
+
+// ImageArchitecture is a cluster-scoped record of the architectures supported by an image digest.
+// The pod placement controller replicas use these objects as a shared, persistent cache of the image inspection
+// results. The objects can be deleted at any time: the images will be inspected again when needed.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=imagearchitectures,scope=Cluster,shortName=imagearch
+// +kubebuilder:printcolumn:name=Architectures,JSONPath=.spec.architectures,type=string
+// +kubebuilder:printcolumn:name=Image,JSONPath=.spec.imageReference,type=string,priority=1
+// +kubebuilder:printcolumn:name=Source,JSONPath=.spec.source,type=string
+// +kubebuilder:printcolumn:name=Mirror,JSONPath=.spec.mirror,type=string,priority=1
+// +kubebuilder:printcolumn:name=Inspected,JSONPath=.spec.inspectionTime,type=date
+type ImageArchitecture struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ImageArchitectureSpec `json:"spec"`
+}
+
+// ImageArchitectureNameForDigest returns the name of the ImageArchitecture object for the given digest.
+// The algorithm separator (':') is not allowed in object names and is replaced by '-'.
+func ImageArchitectureNameForDigest(digest string) string {
+	return strings.ReplaceAll(digest, ":", "-")
+}
+
+//+kubebuilder:object:root=true
+
+// ImageArchitectureList contains a list of ImageArchitecture
+type ImageArchitectureList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ImageArchitecture `json:"items"`
+}
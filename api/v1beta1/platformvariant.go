@@ -0,0 +1,68 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+const (
+	// nfdCPUIDSSE42Label, nfdCPUIDPOPCNTLabel, nfdCPUIDAVX2Label and nfdCPUIDAVX512FLabel are the labels Node
+	// Feature Discovery sets on the nodes whose CPUs support the SSE4.2, POPCNT, AVX2 and AVX512F instruction sets,
+	// respectively. The SSE4.2 and POPCNT labels are not published by the default configuration of Node Feature
+	// Discovery: its attributeBlacklist has to let them through for the x86-64-v2 images to be scheduled.
+	nfdCPUIDSSE42Label   = "feature.node.kubernetes.io/cpu-cpuid.SSE42"
+	nfdCPUIDPOPCNTLabel  = "feature.node.kubernetes.io/cpu-cpuid.POPCNT"
+	nfdCPUIDAVX2Label    = "feature.node.kubernetes.io/cpu-cpuid.AVX2"
+	nfdCPUIDAVX512FLabel = "feature.node.kubernetes.io/cpu-cpuid.AVX512F"
+)
+
+// DefaultPlatformVariants returns the platform variants used when none is configured in the
+// ClusterPodPlacementConfig. They map the x86-64-v2, x86-64-v3 and x86-64-v4 microarchitecture levels to the
+// Node Feature Discovery labels of the instruction sets they introduce.
+func DefaultPlatformVariants() []PlatformVariant {
+	return []PlatformVariant{
+		{
+			Architecture: utils.ArchitectureAmd64,
+			Variant:      "v2",
+			MatchExpressions: []corev1.NodeSelectorRequirement{
+				nfdCPUIDRequirement(nfdCPUIDSSE42Label),
+				nfdCPUIDRequirement(nfdCPUIDPOPCNTLabel),
+			},
+		},
+		{
+			Architecture:     utils.ArchitectureAmd64,
+			Variant:          "v3",
+			MatchExpressions: []corev1.NodeSelectorRequirement{nfdCPUIDRequirement(nfdCPUIDAVX2Label)},
+		},
+		{
+			Architecture:     utils.ArchitectureAmd64,
+			Variant:          "v4",
+			MatchExpressions: []corev1.NodeSelectorRequirement{nfdCPUIDRequirement(nfdCPUIDAVX512FLabel)},
+		},
+	}
+}
+
+func nfdCPUIDRequirement(label string) corev1.NodeSelectorRequirement {
+	return corev1.NodeSelectorRequirement{
+		Key:      label,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{"true"},
+	}
+}
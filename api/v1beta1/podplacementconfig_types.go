@@ -44,6 +44,39 @@ type PodPlacementConfigSpec struct {
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=255
 	Priority uint8 `json:"priority"`
+
+	// InspectionSecrets references the secrets of the namespace of the PodPlacementConfig that hold the registry
+	// credentials used to inspect the images of the matching pods, in addition to their imagePullSecrets.
+	// The secrets are never injected in the pods: they are meant for the pods that do not set imagePullSecrets because
+	// the nodes are already authenticated to the registries.
+	// The secrets must be of type kubernetes.io/dockerconfigjson or kubernetes.io/dockercfg.
+	// +kubebuilder:validation:MaxItems=16
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	InspectionSecrets []InspectionSecretReference `json:"inspectionSecrets,omitempty"`
+
+	// StaticImageArchitectures is the ordered list of the rules mapping the images matching a glob to a static set
+	// of architectures, or excluding them from the inspection. The first matching rule applies. The rules of the
+	// PodPlacementConfigs matching a pod are evaluated by descending priority, before the ones of the
+	// ClusterPodPlacementConfig.
+	// +optional
+	// +listType=atomic
+	StaticImageArchitectures []StaticImageArchitecture `json:"staticImageArchitectures,omitempty"`
+
+	// DigestPinning overrides the digest pinning mode of the ClusterPodPlacementConfig for the matching pods. The mode
+	// of the PodPlacementConfig with the highest priority setting it applies. If unset, the mode of the
+	// ClusterPodPlacementConfig applies.
+	// +optional
+	DigestPinning DigestPinningMode `json:"digestPinning,omitempty"`
+}
+
+// InspectionSecretReference references a secret in the namespace of the PodPlacementConfig.
+type InspectionSecretReference struct {
+	// Name is the name of the secret.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
 }
 
 // PodPlacementConfig defines the configuration for the architecture aware pod placement operand in a given namespace for a subset of its pods based on the provided labelSelector.
@@ -117,7 +150,42 @@ type PodPlacementConfigList struct {
 	Items           []PodPlacementConfig `json:"items"`
 }
 
+const (
+	// InspectionSecretResolvedReason is the reason of the inspection secrets that hold valid registry credentials.
+	InspectionSecretResolvedReason = "Resolved"
+	// InspectionSecretNotFoundReason is the reason of the inspection secrets that do not exist.
+	InspectionSecretNotFoundReason = "NotFound"
+	// InspectionSecretInvalidReason is the reason of the inspection secrets whose type or data are not valid
+	// registry credentials.
+	InspectionSecretInvalidReason = "Invalid"
+	// InspectionSecretErrorReason is the reason of the inspection secrets that could not be retrieved.
+	InspectionSecretErrorReason = "Error"
+)
+
+// InspectionSecretStatus reports whether a secret referenced by the inspectionSecrets of a PodPlacementConfig can be
+// used to inspect the images of the matching pods.
+type InspectionSecretStatus struct {
+	// Name is the name of the secret.
+	Name string `json:"name"`
+
+	// Resolved is true if the secret exists and holds registry credentials that could be parsed.
+	Resolved bool `json:"resolved"`
+
+	// Reason is a machine-readable explanation of the resolution state: Resolved, NotFound, Invalid or Error.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human-readable explanation of the resolution state.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // PodPlacementConfigStatus defines the observed state of PodPlacementConfig
 type PodPlacementConfigStatus struct {
-	//TODO: Define the status fields for PodPlacementConfig
+	// InspectionSecrets reports the resolution state of each secret referenced by the inspectionSecrets of the
+	// PodPlacementConfig.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	InspectionSecrets []InspectionSecretStatus `json:"inspectionSecrets,omitempty"`
 }
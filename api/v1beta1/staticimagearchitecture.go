@@ -0,0 +1,67 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"path"
+)
+
+// StaticImageArchitecture maps the images matching a glob to a static set of architectures, or excludes them from
+// the inspection. The images matching a static rule are never inspected.
+type StaticImageArchitecture struct {
+	// ImagePattern is the glob matched against the fully qualified reference of the images, e.g.,
+	// docker.io/library/busybox:latest for busybox, with the syntax of the Go path.Match function: the * wildcard
+	// does not match the / separators. For example, quay.io/myorg/* matches all the tags and digests of the
+	// repositories of the quay.io/myorg organization.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
+	ImagePattern string `json:"imagePattern"`
+
+	// Architectures are the architectures the matching images support.
+	// Exactly one of architectures and skip must be set.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9_]+$`
+	Architectures []string `json:"architectures,omitempty"`
+
+	// Skip excludes the matching images from the inspection: like the operator bundle images, they are considered
+	// to support all the supported architectures and do not constrain the placement of the pods.
+	// Exactly one of architectures and skip must be set.
+	// +optional
+	Skip bool `json:"skip,omitempty"`
+}
+
+// Matches returns true if the given fully qualified image reference matches the pattern of the rule.
+func (s *StaticImageArchitecture) Matches(imageReference string) bool {
+	matched, err := path.Match(s.ImagePattern, imageReference)
+	return err == nil && matched
+}
+
+// ValidateStaticImageArchitectures verifies that the patterns of the given rules are valid globs and that each rule
+// sets exactly one of architectures and skip. The field is the path of the rules in the error messages.
+func ValidateStaticImageArchitectures(field string, rules []StaticImageArchitecture) error {
+	for _, rule := range rules {
+		if _, err := path.Match(rule.ImagePattern, ""); err != nil {
+			return fmt.Errorf("%s imagePattern %q is not a valid glob: %w", field, rule.ImagePattern, err)
+		}
+		if (len(rule.Architectures) > 0) == rule.Skip {
+			return fmt.Errorf("%s rule %q must set exactly one of architectures and skip", field, rule.ImagePattern)
+		}
+	}
+	return nil
+}
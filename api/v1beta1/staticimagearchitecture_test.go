@@ -0,0 +1,69 @@
+package v1beta1
+
+import (
+	"testing"
+)
+
+func TestValidateStaticImageArchitectures(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []StaticImageArchitecture
+		wantErr bool
+	}{
+		{
+			name: "valid rules",
+			rules: []StaticImageArchitecture{
+				{ImagePattern: "registry.example.com/appliances/*", Skip: true},
+				{ImagePattern: "docker.io/library/busybox:*", Architectures: []string{"amd64", "arm64"}},
+			},
+		},
+		{
+			name:    "invalid glob",
+			rules:   []StaticImageArchitecture{{ImagePattern: "quay.io/myorg/[", Skip: true}},
+			wantErr: true,
+		},
+		{
+			name:    "neither architectures nor skip",
+			rules:   []StaticImageArchitecture{{ImagePattern: "quay.io/myorg/*"}},
+			wantErr: true,
+		},
+		{
+			name: "both architectures and skip",
+			rules: []StaticImageArchitecture{
+				{ImagePattern: "quay.io/myorg/*", Architectures: []string{"amd64"}, Skip: true},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStaticImageArchitectures(".spec.staticImageArchitectures", tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStaticImageArchitectures() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStaticImageArchitecture_Matches(t *testing.T) {
+	tests := []struct {
+		pattern        string
+		imageReference string
+		want           bool
+	}{
+		{pattern: "quay.io/myorg/*", imageReference: "quay.io/myorg/app:v1", want: true},
+		{pattern: "quay.io/myorg/*", imageReference: "quay.io/myorg/app@sha256:0123", want: true},
+		{pattern: "quay.io/myorg/*", imageReference: "quay.io/myorg/team/app:v1"},
+		{pattern: "docker.io/library/busybox:1.*", imageReference: "docker.io/library/busybox:1.36", want: true},
+		{pattern: "docker.io/library/busybox:1.*", imageReference: "docker.io/library/busybox:latest"},
+		{pattern: "*.example.com/*/*", imageReference: "registry.example.com/appliances/probe:v2", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.imageReference, func(t *testing.T) {
+			rule := &StaticImageArchitecture{ImagePattern: tt.pattern}
+			if got := rule.Matches(tt.imageReference); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
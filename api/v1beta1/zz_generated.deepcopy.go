@@ -21,11 +21,98 @@ limitations under the License.
 package v1beta1
 
 import (
+	"github.com/openshift/multiarch-tuning-operator/api/common"
 	"github.com/openshift/multiarch-tuning-operator/api/common/plugins"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchitectureAgnosticImageRule) DeepCopyInto(out *ArchitectureAgnosticImageRule) {
+	*out = *in
+	if in.Label != nil {
+		in, out := &in.Label, &out.Label
+		*out = new(ImageMetadataMatcher)
+		**out = **in
+	}
+	if in.Annotation != nil {
+		in, out := &in.Annotation, &out.Annotation
+		*out = new(ImageMetadataMatcher)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchitectureAgnosticImageRule.
+func (in *ArchitectureAgnosticImageRule) DeepCopy() *ArchitectureAgnosticImageRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchitectureAgnosticImageRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchitectureAlias) DeepCopyInto(out *ArchitectureAlias) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchitectureAlias.
+func (in *ArchitectureAlias) DeepCopy() *ArchitectureAlias {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchitectureAlias)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BinaryVerificationConfig) DeepCopyInto(out *BinaryVerificationConfig) {
+	*out = *in
+	if in.ImagePatterns != nil {
+		in, out := &in.ImagePatterns, &out.ImagePatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BinaryVerificationConfig.
+func (in *BinaryVerificationConfig) DeepCopy() *BinaryVerificationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BinaryVerificationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CircuitBreakerConfig) DeepCopyInto(out *CircuitBreakerConfig) {
+	*out = *in
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.OpenDuration != nil {
+		in, out := &in.OpenDuration, &out.OpenDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CircuitBreakerConfig.
+func (in *CircuitBreakerConfig) DeepCopy() *CircuitBreakerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CircuitBreakerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterPodPlacementConfig) DeepCopyInto(out *ClusterPodPlacementConfig) {
 	*out = *in
@@ -98,6 +185,71 @@ func (in *ClusterPodPlacementConfigSpec) DeepCopyInto(out *ClusterPodPlacementCo
 		*out = new(plugins.Plugins)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FallbackArchitectureErrorClasses != nil {
+		in, out := &in.FallbackArchitectureErrorClasses, &out.FallbackArchitectureErrorClasses
+		*out = make([]common.ImageInspectionErrorClass, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImageInspectionCache != nil {
+		in, out := &in.ImageInspectionCache, &out.ImageInspectionCache
+		*out = new(ImageInspectionCacheConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PlatformVariants != nil {
+		in, out := &in.PlatformVariants, &out.PlatformVariants
+		*out = make([]PlatformVariant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SupportedArchitectures != nil {
+		in, out := &in.SupportedArchitectures, &out.SupportedArchitectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ArchitectureAliases != nil {
+		in, out := &in.ArchitectureAliases, &out.ArchitectureAliases
+		*out = make([]ArchitectureAlias, len(*in))
+		copy(*out, *in)
+	}
+	if in.RegistryPolicies != nil {
+		in, out := &in.RegistryPolicies, &out.RegistryPolicies
+		*out = make([]RegistryPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OfflineImageSources != nil {
+		in, out := &in.OfflineImageSources, &out.OfflineImageSources
+		*out = new(OfflineImageSourcesConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BinaryVerification != nil {
+		in, out := &in.BinaryVerification, &out.BinaryVerification
+		*out = new(BinaryVerificationConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RuntimeClassMappings != nil {
+		in, out := &in.RuntimeClassMappings, &out.RuntimeClassMappings
+		*out = make([]RuntimeClassMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ArchitectureAgnosticImages != nil {
+		in, out := &in.ArchitectureAgnosticImages, &out.ArchitectureAgnosticImages
+		*out = make([]ArchitectureAgnosticImageRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StaticImageArchitectures != nil {
+		in, out := &in.StaticImageArchitectures, &out.StaticImageArchitectures
+		*out = make([]StaticImageArchitecture, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPodPlacementConfigSpec.
@@ -221,13 +373,260 @@ func (in *ENoExecEventStatus) DeepCopy() *ENoExecEventStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageArchitecture) DeepCopyInto(out *ImageArchitecture) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageArchitecture.
+func (in *ImageArchitecture) DeepCopy() *ImageArchitecture {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageArchitecture)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ImageArchitecture) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageArchitectureList) DeepCopyInto(out *ImageArchitectureList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ImageArchitecture, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageArchitectureList.
+func (in *ImageArchitectureList) DeepCopy() *ImageArchitectureList {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageArchitectureList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ImageArchitectureList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageArchitectureSpec) DeepCopyInto(out *ImageArchitectureSpec) {
+	*out = *in
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Platforms != nil {
+		in, out := &in.Platforms, &out.Platforms
+		*out = make([]ImagePlatform, len(*in))
+		copy(*out, *in)
+	}
+	in.InspectionTime.DeepCopyInto(&out.InspectionTime)
+	if in.ManifestListDiscrepancies != nil {
+		in, out := &in.ManifestListDiscrepancies, &out.ManifestListDiscrepancies
+		*out = make([]ManifestListDiscrepancy, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageArchitectureSpec.
+func (in *ImageArchitectureSpec) DeepCopy() *ImageArchitectureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageArchitectureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageInspectionCacheConfig) DeepCopyInto(out *ImageInspectionCacheConfig) {
+	*out = *in
+	if in.TagTTL != nil {
+		in, out := &in.TagTTL, &out.TagTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DigestTTL != nil {
+		in, out := &in.DigestTTL, &out.DigestTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageInspectionCacheConfig.
+func (in *ImageInspectionCacheConfig) DeepCopy() *ImageInspectionCacheConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageInspectionCacheConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageMetadataMatcher) DeepCopyInto(out *ImageMetadataMatcher) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageMetadataMatcher.
+func (in *ImageMetadataMatcher) DeepCopy() *ImageMetadataMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageMetadataMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagePlatform) DeepCopyInto(out *ImagePlatform) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePlatform.
+func (in *ImagePlatform) DeepCopy() *ImagePlatform {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagePlatform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InspectionSecretReference) DeepCopyInto(out *InspectionSecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InspectionSecretReference.
+func (in *InspectionSecretReference) DeepCopy() *InspectionSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(InspectionSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InspectionSecretStatus) DeepCopyInto(out *InspectionSecretStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InspectionSecretStatus.
+func (in *InspectionSecretStatus) DeepCopy() *InspectionSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InspectionSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManifestListDiscrepancy) DeepCopyInto(out *ManifestListDiscrepancy) {
+	*out = *in
+	out.Platform = in.Platform
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManifestListDiscrepancy.
+func (in *ManifestListDiscrepancy) DeepCopy() *ManifestListDiscrepancy {
+	if in == nil {
+		return nil
+	}
+	out := new(ManifestListDiscrepancy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OfflineImageSource) DeepCopyInto(out *OfflineImageSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OfflineImageSource.
+func (in *OfflineImageSource) DeepCopy() *OfflineImageSource {
+	if in == nil {
+		return nil
+	}
+	out := new(OfflineImageSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OfflineImageSourcesConfig) DeepCopyInto(out *OfflineImageSourcesConfig) {
+	*out = *in
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]OfflineImageSource, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OfflineImageSourcesConfig.
+func (in *OfflineImageSourcesConfig) DeepCopy() *OfflineImageSourcesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OfflineImageSourcesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformVariant) DeepCopyInto(out *PlatformVariant) {
+	*out = *in
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]corev1.NodeSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformVariant.
+func (in *PlatformVariant) DeepCopy() *PlatformVariant {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformVariant)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodPlacementConfig) DeepCopyInto(out *PodPlacementConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodPlacementConfig.
@@ -293,6 +692,18 @@ func (in *PodPlacementConfigSpec) DeepCopyInto(out *PodPlacementConfigSpec) {
 		*out = new(plugins.LocalPlugins)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.InspectionSecrets != nil {
+		in, out := &in.InspectionSecrets, &out.InspectionSecrets
+		*out = make([]InspectionSecretReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.StaticImageArchitectures != nil {
+		in, out := &in.StaticImageArchitectures, &out.StaticImageArchitectures
+		*out = make([]StaticImageArchitecture, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodPlacementConfigSpec.
@@ -308,6 +719,11 @@ func (in *PodPlacementConfigSpec) DeepCopy() *PodPlacementConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodPlacementConfigStatus) DeepCopyInto(out *PodPlacementConfigStatus) {
 	*out = *in
+	if in.InspectionSecrets != nil {
+		in, out := &in.InspectionSecrets, &out.InspectionSecrets
+		*out = make([]InspectionSecretStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodPlacementConfigStatus.
@@ -319,3 +735,75 @@ func (in *PodPlacementConfigStatus) DeepCopy() *PodPlacementConfigStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryPolicy) DeepCopyInto(out *RegistryPolicy) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(CircuitBreakerConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryPolicy.
+func (in *RegistryPolicy) DeepCopy() *RegistryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuntimeClassMapping) DeepCopyInto(out *RuntimeClassMapping) {
+	*out = *in
+	if in.MediaTypes != nil {
+		in, out := &in.MediaTypes, &out.MediaTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]corev1.NodeSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeClassMapping.
+func (in *RuntimeClassMapping) DeepCopy() *RuntimeClassMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(RuntimeClassMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticImageArchitecture) DeepCopyInto(out *StaticImageArchitecture) {
+	*out = *in
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticImageArchitecture.
+func (in *StaticImageArchitecture) DeepCopy() *StaticImageArchitecture {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticImageArchitecture)
+	in.DeepCopyInto(out)
+	return out
+}
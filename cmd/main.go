@@ -32,6 +32,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -52,6 +53,10 @@ import (
 
 	//+kubebuilder:scaffold:imports
 
+	ocpappsv1 "github.com/openshift/api/apps/v1"
+	configv1 "github.com/openshift/api/config/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	"github.com/openshift/library-go/pkg/operator/events"
 
 	"github.com/panjf2000/ants/v2"
@@ -64,10 +69,12 @@ import (
 	multiarchv1beta1 "github.com/openshift/multiarch-tuning-operator/api/v1beta1"
 
 	"github.com/openshift/multiarch-tuning-operator/api/common"
+	"github.com/openshift/multiarch-tuning-operator/api/common/plugins"
 	enoexeceventhandler "github.com/openshift/multiarch-tuning-operator/internal/controller/enoexecevent/handler"
 	"github.com/openshift/multiarch-tuning-operator/internal/controller/operator"
 	"github.com/openshift/multiarch-tuning-operator/internal/controller/podplacement"
 	"github.com/openshift/multiarch-tuning-operator/internal/controller/podplacementconfig"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
 	"github.com/openshift/multiarch-tuning-operator/pkg/informers/clusterpodplacementconfig"
 	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
 )
@@ -96,6 +103,14 @@ var (
 	enableOperator     bool
 	initialLogLevel    int
 	postFuncs          []func()
+
+	enableImagePrefetcher bool
+	imagePrefetcherQueueSize,
+	imagePrefetcherInspectionsPerSecond,
+	imagePrefetcherBurst int
+
+	credentialProviderConfig,
+	credentialProviderBinDir string
 )
 
 func init() {
@@ -103,6 +118,10 @@ func init() {
 	utilruntime.Must(multiarchv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(multiarchv1beta1.AddToScheme(scheme))
 	utilruntime.Must(monitoringv1.AddToScheme(scheme))
+	utilruntime.Must(ocpappsv1.AddToScheme(scheme))
+	utilruntime.Must(configv1.AddToScheme(scheme))
+	utilruntime.Must(operatorv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(imagev1.AddToScheme(scheme))
 }
 
 func main() {
@@ -125,6 +144,10 @@ func main() {
 			&corev1.Pod{}: {
 				Field: fields.OneTermEqualSelector("status.phase", "Pending"),
 			},
+			// The nodes are only read for the images they hold: the other fields are not cached.
+			&corev1.Node{}: {
+				Transform: image.StripNodeForImageInventory,
+			},
 		}
 	}
 	if enableENoExecEventControllers {
@@ -253,8 +276,53 @@ func RunClusterPodPlacementConfigOperandControllers(mgr ctrl.Manager) {
 	}).SetupWithManager(mgr),
 		unableToCreateController, controllerKey, "PodReconciler")
 
+	must((&podplacement.PodPlacementConfigReconciler{
+		Client:    mgr.GetClient(),
+		ClientSet: clientset,
+	}).SetupWithManager(mgr),
+		unableToCreateController, controllerKey, "PodPlacementConfigReconciler")
+
 	must(mgr.Add(podplacement.NewGlobalPullSecretSyncer(clientset, globalPullSecretNamespace, globalPullSecretName)),
 		unableToAddRunnable, runnableKey, "GlobalPullSecretSyncer")
+
+	must(mgr.Add(podplacement.NewRegistriesConfigSyncer(mgr.GetCache(), mgr.GetRESTMapper(), mgr.GetScheme())),
+		unableToAddRunnable, runnableKey, "RegistriesConfigSyncer")
+
+	must(mgr.Add(podplacement.NewImageInspectionConfigSyncer(mgr.GetCache())),
+		unableToAddRunnable, runnableKey, "ImageInspectionConfigSyncer")
+
+	must(mgr.Add(podplacement.NewRegistryHealthReporter(mgr.GetClient())),
+		unableToAddRunnable, runnableKey, "RegistryHealthReporter")
+
+	// The ImageArchitecture objects are shared by all the replicas of the pod placement controller as a persistent,
+	// digest-keyed cache of the image inspection results.
+	image.FacadeSingleton().EnableImageArchitectureStore(mgr.GetClient())
+
+	// The platforms of the images whose inspection fails are inferred from the nodes already holding them, e.g., the
+	// images pre-loaded on the nodes.
+	must(image.IndexNodeImages(context.Background(), mgr.GetFieldIndexer()), "unable to index the images of the nodes")
+	image.FacadeSingleton().EnableNodeImageInventory(mgr.GetClient())
+
+	// The image API is only served by the OpenShift clusters. The ImageStreamTags cannot be watched: they are read
+	// without the cache.
+	if _, err := mgr.GetRESTMapper().RESTMapping(schema.GroupKind{Group: imagev1.GroupName, Kind: "ImageStreamTag"},
+		imagev1.GroupVersion.Version); err == nil {
+		image.FacadeSingleton().EnableImageStreamResolution(mgr.GetAPIReader())
+	} else {
+		setupLog.Info("The image API is not served by the cluster, the ImageStreams will not be resolved",
+			"error", err.Error())
+	}
+
+	if credentialProviderConfig != "" {
+		must(image.FacadeSingleton().ConfigureCredentialProviders(ctrllog.IntoContext(context.Background(), setupLog),
+			credentialProviderConfig, credentialProviderBinDir), "unable to configure the credential providers")
+	}
+
+	if enableImagePrefetcher {
+		must(mgr.Add(podplacement.NewImagePrefetcher(mgr.GetCache(), mgr.GetRESTMapper(), mgr.GetScheme(), clientset,
+			imagePrefetcherQueueSize, imagePrefetcherInspectionsPerSecond, imagePrefetcherBurst)),
+			unableToAddRunnable, runnableKey, "ImagePrefetcher")
+	}
 }
 
 func RunClusterPodPlacementConfigOperandWebHook(mgr ctrl.Manager) {
@@ -270,6 +338,8 @@ func RunClusterPodPlacementConfigOperandWebHook(mgr ctrl.Manager) {
 		}
 		ants.Release()
 	})
+	must(podplacement.IndexImageArchitectureReferences(context.Background(), mgr.GetFieldIndexer()),
+		"unable to index the ImageArchitecture objects")
 	handler := podplacement.NewPodSchedulingGateMutatingWebHook(mgr.GetClient(), clientset, mgr.GetScheme(),
 		mgr.GetEventRecorderFor(utils.OperatorName), pool) //nolint:staticcheck // MULTIARCH-6087: will be fixed with events API migration
 	mgr.GetWebhookServer().Register("/add-pod-scheduling-gate", &webhook.Admission{Handler: handler})
@@ -318,6 +388,12 @@ func bindFlags() {
 	flag.BoolVar(&enableOperator, "enable-operator", false, "Enable the operator")
 	flag.BoolVar(&enableCPPCInformer, "enable-cppc-informer", false, "Enable informer for ClusterPodPlacementConfig")
 	flag.BoolVar(&enableENoExecEventControllers, "enable-enoexec-event-controllers", false, "Enable the ENoExecEvent controllers")
+	flag.BoolVar(&enableImagePrefetcher, "enable-image-prefetcher", false, "Enable the warm-up of the image inspection cache from the workloads' pod templates")
+	flag.IntVar(&imagePrefetcherQueueSize, "image-prefetcher-queue-size", plugins.DefaultImagePrefetcherQueueSize, "The maximum number of images waiting to be prefetched")
+	flag.IntVar(&imagePrefetcherInspectionsPerSecond, "image-prefetcher-inspections-per-second", plugins.DefaultImagePrefetcherInspectionsPerSecond, "The maximum rate of the prefetch inspections")
+	flag.IntVar(&imagePrefetcherBurst, "image-prefetcher-burst", plugins.DefaultImagePrefetcherBurst, "The maximum burst of the prefetch inspections")
+	flag.StringVar(&credentialProviderConfig, "credential-provider-config", "", "The path to the kubelet CredentialProviderConfig file, or directory, to authenticate the image inspections with the credential providers")
+	flag.StringVar(&credentialProviderBinDir, "credential-provider-bin-dir", plugins.DefaultCredentialProvidersBinDir, "The directory of the kubelet credential provider binaries")
 	// This may be deprecated in the future. It is used to support the current way of setting the log level for operands
 	// If operands will start to support a controller that watches the ClusterPodPlacementConfig, this flag may be removed
 	// and the log level will be set in the ClusterPodPlacementConfig at runtime (with no need for reconciliation)
@@ -12,6 +12,7 @@ import (
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 
+	"github.com/openshift/multiarch-tuning-operator/api/common"
 	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
 	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
 )
@@ -93,8 +94,22 @@ func buildWebhookDeployment(clusterPodPlacementConfig *v1beta1.ClusterPodPlaceme
 
 // buildControllerDeployment creates the Deployment for the cluster pod placement config controller.
 func buildControllerDeployment(clusterPodPlacementConfig *v1beta1.ClusterPodPlacementConfig, requiredSCCHostmoundAnyUID string, seLinuxOptionsType *corev1.SELinuxOptions) *appsv1.Deployment {
+	args := []string{"--leader-elect", "--enable-ppc-controllers", "--enable-cppc-informer"}
+	if clusterPodPlacementConfig.PluginsEnabled(common.ImagePrefetcherPluginName) {
+		prefetcher := clusterPodPlacementConfig.Spec.Plugins.ImagePrefetcher
+		args = append(args, "--enable-image-prefetcher",
+			fmt.Sprintf("--image-prefetcher-queue-size=%d", prefetcher.QueueSizeOrDefault()),
+			fmt.Sprintf("--image-prefetcher-inspections-per-second=%d", prefetcher.InspectionsPerSecondOrDefault()),
+			fmt.Sprintf("--image-prefetcher-burst=%d", prefetcher.BurstOrDefault()))
+	}
+	if clusterPodPlacementConfig.PluginsEnabled(common.CredentialProvidersPluginName) {
+		credentialProviders := clusterPodPlacementConfig.Spec.Plugins.CredentialProviders
+		args = append(args,
+			fmt.Sprintf("--credential-provider-config=%s", credentialProviders.ConfigDirOrDefault()),
+			fmt.Sprintf("--credential-provider-bin-dir=%s", credentialProviders.BinDirOrDefault()))
+	}
 	d := buildDeployment(clusterPodPlacementConfig.Spec.LogVerbosity.ToZapLevelInt(), utils.PodPlacementControllerName, 2, utils.PodPlacementControllerName,
-		utils.PodPlacementFinalizerName, "--leader-elect", "--enable-ppc-controllers", "--enable-cppc-informer",
+		utils.PodPlacementFinalizerName, args...,
 	)
 	if d.Spec.Template.Annotations == nil {
 		d.Spec.Template.Annotations = map[string]string{}
@@ -167,6 +182,61 @@ func buildControllerDeployment(clusterPodPlacementConfig *v1beta1.ClusterPodPlac
 			Value: "/tmp/container/cache",
 		},
 	}
+	if clusterPodPlacementConfig.PluginsEnabled(common.CredentialProvidersPluginName) {
+		// The kubelet credential providers and their configuration are mounted at the same paths as on the nodes, as
+		// the configuration may refer to other files of the nodes.
+		credentialProviders := clusterPodPlacementConfig.Spec.Plugins.CredentialProviders
+		additionalVolumes = append(additionalVolumes,
+			corev1.Volume{
+				Name: "credential-providers-conf",
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: credentialProviders.ConfigDirOrDefault(),
+						Type: utils.NewPtr(corev1.HostPathDirectory),
+					},
+				},
+			},
+			corev1.Volume{
+				Name: "credential-providers-bin",
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: credentialProviders.BinDirOrDefault(),
+						Type: utils.NewPtr(corev1.HostPathDirectory),
+					},
+				},
+			})
+		additionalMounts = append(additionalMounts,
+			corev1.VolumeMount{
+				Name:      "credential-providers-conf",
+				MountPath: credentialProviders.ConfigDirOrDefault(),
+				ReadOnly:  true,
+			},
+			corev1.VolumeMount{
+				Name:      "credential-providers-bin",
+				MountPath: credentialProviders.BinDirOrDefault(),
+				ReadOnly:  true,
+			})
+	}
+
+	if offlineImageSources := clusterPodPlacementConfig.Spec.OfflineImageSources; offlineImageSources != nil {
+		volume := corev1.Volume{Name: "offline-image-sources"}
+		if offlineImageSources.ConfigMapName != "" {
+			volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: offlineImageSources.ConfigMapName},
+			}
+		} else {
+			volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: offlineImageSources.PersistentVolumeClaimName,
+				ReadOnly:  true,
+			}
+		}
+		additionalVolumes = append(additionalVolumes, volume)
+		additionalMounts = append(additionalMounts, corev1.VolumeMount{
+			Name:      "offline-image-sources",
+			MountPath: utils.OfflineImageSourcesMountPath,
+			ReadOnly:  true,
+		})
+	}
 
 	// 3. Append the additional volumes and mounts to the base ones from the generic builder.
 	d.Spec.Template.Spec.Volumes = append(d.Spec.Template.Spec.Volumes, additionalVolumes...)
@@ -201,6 +271,16 @@ func buildClusterRoleWebhook() *rbacv1.ClusterRole {
 			Resources: []string{v1beta1.PodPlacementConfigResource},
 			Verbs:     []string{LIST, WATCH, GET},
 		},
+		{
+			APIGroups: []string{v1beta1.GroupVersion.Group},
+			Resources: []string{v1beta1.ImageArchitectureResource},
+			Verbs:     []string{LIST, WATCH, GET},
+		},
+		{
+			APIGroups: []string{"node.k8s.io"},
+			Resources: []string{"runtimeclasses"},
+			Verbs:     []string{LIST, WATCH, GET},
+		},
 		{
 			APIGroups: []string{""},
 			Resources: []string{"pods"},
@@ -247,11 +327,31 @@ func buildClusterRoleController() *rbacv1.ClusterRole {
 			Resources: []string{v1beta1.ClusterPodPlacementConfigResource},
 			Verbs:     []string{LIST, WATCH, GET},
 		},
+		{
+			APIGroups: []string{v1beta1.GroupVersion.Group},
+			Resources: []string{v1beta1.ClusterPodPlacementConfigResource + "/status"},
+			Verbs:     []string{GET, PATCH},
+		},
 		{
 			APIGroups: []string{v1beta1.GroupVersion.Group},
 			Resources: []string{v1beta1.PodPlacementConfigResource},
 			Verbs:     []string{LIST, WATCH, GET},
 		},
+		{
+			APIGroups: []string{v1beta1.GroupVersion.Group},
+			Resources: []string{v1beta1.PodPlacementConfigResource + "/status"},
+			Verbs:     []string{GET, PATCH},
+		},
+		{
+			APIGroups: []string{v1beta1.GroupVersion.Group},
+			Resources: []string{v1beta1.ImageArchitectureResource},
+			Verbs:     []string{LIST, WATCH, GET, CREATE, UPDATE},
+		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"nodes"},
+			Verbs:     []string{LIST, WATCH, GET},
+		},
 		{
 			APIGroups: []string{""},
 			Resources: []string{"configmaps"},
@@ -262,6 +362,41 @@ func buildClusterRoleController() *rbacv1.ClusterRole {
 			Resources: []string{"secrets"},
 			Verbs:     []string{LIST, WATCH, GET},
 		},
+		{
+			APIGroups: []string{""},
+			Resources: []string{"serviceaccounts"},
+			Verbs:     []string{GET},
+		},
+		{
+			APIGroups: []string{"apps"},
+			Resources: []string{"deployments", "statefulsets"},
+			Verbs:     []string{LIST, WATCH, GET},
+		},
+		{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs", "cronjobs"},
+			Verbs:     []string{LIST, WATCH, GET},
+		},
+		{
+			APIGroups: []string{"apps.openshift.io"},
+			Resources: []string{"deploymentconfigs"},
+			Verbs:     []string{LIST, WATCH, GET},
+		},
+		{
+			APIGroups: []string{"config.openshift.io"},
+			Resources: []string{"imagedigestmirrorsets", "imagetagmirrorsets", "proxies", "images"},
+			Verbs:     []string{LIST, WATCH, GET},
+		},
+		{
+			APIGroups: []string{"image.openshift.io"},
+			Resources: []string{"imagestreams", "imagestreamtags", "imagestreamimages", "images"},
+			Verbs:     []string{GET},
+		},
+		{
+			APIGroups: []string{"operator.openshift.io"},
+			Resources: []string{"imagecontentsourcepolicies"},
+			Verbs:     []string{LIST, WATCH, GET},
+		},
 		{
 			APIGroups: []string{"authentication.k8s.io"},
 			Resources: []string{"tokenreviews"},
@@ -0,0 +1,82 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podplacement
+
+import (
+	"fmt"
+
+	"github.com/containers/image/v5/docker/reference"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// digestPinningEnabled returns true if the images of the pods matching the given PodPlacementConfigs are pinned to
+// their inspected digest: the mode of the PodPlacementConfig with the highest priority setting it applies, or the one
+// of the ClusterPodPlacementConfig if none sets it.
+func digestPinningEnabled(cppc *v1beta1.ClusterPodPlacementConfig, matchingPPCs []v1beta1.PodPlacementConfig) bool {
+	for _, ppc := range sortedByPriority(matchingPPCs) {
+		if ppc.Spec.DigestPinning != "" {
+			return ppc.Spec.DigestPinning == v1beta1.DigestPinningModeEnabled
+		}
+	}
+	return cppc.DigestPinning() == v1beta1.DigestPinningModeEnabled
+}
+
+// pinImageDigests rewrites the images of the containers and the init containers of the pod to the digest they were
+// inspected at, e.g., quay.io/myorg/foo@sha256:... for quay.io/myorg/foo:1.2. The original images are recorded in the
+// utils.PinnedImagesAnnotation annotation, as a comma-separated list of <container>=<image> entries. The images
+// already referenced by digest, the ones that were not inspected and the ones whose tags may be resolved by a mirror
+// other than the digest mirrors are left untouched. The image volumes cannot be pinned: the volumes of the pods are
+// immutable.
+func (pod *Pod) pinImageDigests() {
+	if len(pod.inspectedDigests) == 0 {
+		return
+	}
+	pinnedImages := pod.containerImagesAnnotation(utils.PinnedImagesAnnotation)
+	pinned := false
+	for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for i := range containers {
+			container := &containers[i]
+			d, ok := pod.inspectedDigests[fmt.Sprintf("//%s", container.Image)]
+			if !ok {
+				continue
+			}
+			named, err := reference.ParseNormalizedNamed(container.Image)
+			if err != nil {
+				continue
+			}
+			if _, ok := named.(reference.Digested); ok {
+				continue
+			}
+			canonical, err := reference.WithDigest(reference.TrimNamed(named), d)
+			if err != nil {
+				continue
+			}
+			pod.PublishEvent(corev1.EventTypeNormal, ImageDigestPinned,
+				fmt.Sprintf("%s container: %s, image: %s, digest: %s", ImageDigestPinnedMsg, container.Name,
+					container.Image, d))
+			pinnedImages[container.Name] = container.Image
+			container.Image = reference.FamiliarString(canonical)
+			pinned = true
+		}
+	}
+	if pinned {
+		pod.ensureContainerImagesAnnotation(utils.PinnedImagesAnnotation, pinnedImages)
+	}
+}
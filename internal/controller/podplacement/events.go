@@ -1,6 +1,9 @@
 package podplacement
 
-import "github.com/openshift/multiarch-tuning-operator/pkg/utils"
+import (
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
 
 const (
 	ArchitecturePredicatesConflict                = "ArchAwarePredicatesConflict"
@@ -13,12 +16,28 @@ const (
 	NoSupportedArchitecturesFound                 = "NoSupportedArchitecturesFound"
 	ArchitecturePreferredAffinityDuplicates       = "ArchAwarePreferredAffinityDuplicates"
 	ArchitectureAwareFallbackNodeAffinitySet      = "ArchAwareFallbackPredicateSet"
+	ArchitectureAwareVariantNodeAffinitySet       = "ArchAwareVariantPredicateSet"
+	ArchitectureAwareOSNodeAffinitySet            = "ArchAwareOSPredicateSet"
+	ManifestListDiscrepancyFound                  = "ArchAwareManifestListDiscrepancy"
+	BinaryArchitectureMismatchFound               = "ArchAwareBinaryArchitectureMismatch"
+	ArchitectureAwareRuntimeClassNodeAffinitySet  = "ArchAwareRuntimeClassPredicateSet"
+	RuntimeClassMismatch                          = "ArchAwareRuntimeClassMismatch"
+	ArchitectureAwareEmulationNodeAffinitySet     = "ArchAwareEmulationPredicateSet"
+	NodeImageInventoryUsed                        = "ArchAwareNodeImageInventoryUsed"
+	ArchitectureAgnosticImageMatched              = "ArchAwareArchitectureAgnosticImage"
+	StaticImageArchitectureApplied                = "ArchAwareStaticImageArchitecture"
+	ImagePlatformsBySource                        = "ArchAwareImagePlatforms"
+	ImageSubstituted                              = "ArchAwareImageSubstituted"
+	ImageSubstitutionSkipped                      = "ArchAwareImageSubstitutionSkipped"
+	ImageDigestPinned                             = "ArchAwareImageDigestPinned"
 
-	SchedulingGateAddedMsg            = "Successfully gated with the " + utils.SchedulingGateName + " scheduling gate"
-	SchedulingGateRemovalSuccessMsg   = "Successfully removed the " + utils.SchedulingGateName + " scheduling gate"
-	SchedulingGateRemovalFailureMsg   = "Failed to remove the scheduling gate \"" + utils.SchedulingGateName + "\""
-	ArchitecturePredicatesConflictMsg = "All the scheduling predicates already include architecture-specific constraints"
-	ArchitecturePredicateSetupMsg     = "Set the supported architectures to "
+	SchedulingGateAddedMsg               = "Successfully gated with the " + utils.SchedulingGateName + " scheduling gate"
+	SchedulingGateRemovalSuccessMsg      = "Successfully removed the " + utils.SchedulingGateName + " scheduling gate"
+	SchedulingGateRemovalFailureMsg      = "Failed to remove the scheduling gate \"" + utils.SchedulingGateName + "\""
+	ArchitecturePredicatesConflictMsg    = "All the scheduling predicates already include architecture-specific constraints"
+	ArchitecturePredicateSetupMsg        = "Set the supported architectures to "
+	ArchitectureOSPredicateSetupMsg      = "Set the supported operating systems and architectures to "
+	ArchitectureVariantPredicateSetupMsg = "Restricted the nodes of the following platforms to the ones supporting their CPU variant: "
 
 	ArchitecturePreferredPredicateSetupMsg         = "Applied all architecture preferences from configuration"
 	ArchitecturePreferredAffinityWithDuplicatesMsg = "Applied some architecture preferences from configuration; others were already set"
@@ -33,5 +52,25 @@ const (
 	ImageInspectionErrorMaxRetriesMsg   = "The operator was unable to determine the supported architectures after multiple retries. " +
 		"This is typically caused by the image registry being unreachable, returning an error, or a misconfiguration in the cluster's pull secrets or network. " +
 		"Registry error"
-	ArchitectureFallbackSetupMsg = "Image inspection failed; setting the nodeAffinity to the fallback architecture: "
+	ArchitectureFallbackSetupMsg  = "Image inspection failed; setting the nodeAffinity to the fallback architecture: "
+	ManifestListDiscrepancyMsg    = "Ignored an entry of the manifest list of an image as it does not match the index;"
+	BinaryArchitectureMismatchMsg = "Ignored a platform of an image as its binaries do not support it;"
+	RuntimeClassPredicateSetupMsg = "The images run on a runtime-specific platform; set the nodeAffinity of the RuntimeClass: "
+	RuntimeClassMismatchMsg       = "The images run on a runtime-specific platform, but the pod does not set the runtimeClassName of its mapping. " +
+		"The runtimeClassName cannot be changed after the pod creation; expected RuntimeClass: "
+	EmulationPredicateSetupMsg        = "Allowed the pod to run on the nodes emulating the following architectures: "
+	NodeImageInventoryUsedMsg         = "The image inspection failed; inferred the platforms of the image from the nodes holding it;"
+	ArchitectureAgnosticImageMatchMsg = "The image matches an architecture-agnostic image rule and supports all the architectures;"
+	StaticImageArchitectureMsg        = "The architectures of the image were set by a static rule, without inspecting it;"
+	ImagePlatformsBySourceMsg         = "The image supports the following platforms;"
+	ImageVolumeNotConstrainingMsg     = "The image volume is not a Linux image and does not constrain the placement of the pod;"
+	ImageSubstitutedMsg               = "The image was substituted with an equivalent supporting more architectures;"
+	ImageSubstitutionSkippedMsg       = "The image substitution rule was not applied as the image or its substitute could not be inspected;"
+	ImageDigestPinnedMsg              = "The image was pinned to the digest it was inspected at;"
 )
+
+// imageInspectionErrorReason returns the event reason for an image inspection error of the given class,
+// e.g., ArchAwareInspectionErrorNotFound.
+func imageInspectionErrorReason(class common.ImageInspectionErrorClass) string {
+	return ImageArchitectureInspectionError + string(class)
+}
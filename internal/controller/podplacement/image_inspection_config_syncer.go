@@ -0,0 +1,118 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podplacement
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	multiarchv1beta1 "github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
+)
+
+// imageInspectionConfigKey is the only key of the queue of the ImageInspectionConfigSyncer.
+const imageInspectionConfigKey = "image-inspection-config"
+
+// ImageInspectionConfigSyncer watches the ClusterPodPlacementConfig and applies its image inspection settings, e.g.,
+// the cache, the supported architectures and the registry policies, when it changes. The settings are kept when the
+// ClusterPodPlacementConfig is deleted, as the operand is being removed.
+type ImageInspectionConfigSyncer struct {
+	cache ctrlcache.Cache
+	queue workqueue.TypedRateLimitingInterface[string]
+	log   logr.Logger
+}
+
+func NewImageInspectionConfigSyncer(cache ctrlcache.Cache) *ImageInspectionConfigSyncer {
+	return &ImageInspectionConfigSyncer{
+		cache: cache,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{
+				Name: "image-inspection-config-syncer",
+			}),
+		log: ctrllog.Log.WithName("ImageInspectionConfigSyncer"),
+	}
+}
+
+func (s *ImageInspectionConfigSyncer) Start(ctx context.Context) error {
+	s.log = ctrllog.FromContext(ctx, "handler", "ImageInspectionConfigSyncer")
+	s.log.Info("Starting the Image Inspection Config Syncer")
+	defer s.queue.ShutDown()
+	informer, err := s.cache.GetInformer(ctx, &multiarchv1beta1.ClusterPodPlacementConfig{})
+	if err != nil {
+		s.log.Error(err, "Unable to get the informer of the ClusterPodPlacementConfig")
+		return err
+	}
+	if _, err = informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { s.queue.Add(imageInspectionConfigKey) },
+		UpdateFunc: func(interface{}, interface{}) { s.queue.Add(imageInspectionConfigKey) },
+	}); err != nil {
+		s.log.Error(err, "Error registering the handler of the ClusterPodPlacementConfig")
+		return err
+	}
+	go func() {
+		for s.processNextItem(ctx) {
+		}
+	}()
+	<-ctx.Done()
+	s.log.Info("Stopping the Image Inspection Config Syncer")
+	return nil
+}
+
+func (s *ImageInspectionConfigSyncer) processNextItem(ctx context.Context) bool {
+	key, shutdown := s.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer s.queue.Done(key)
+	if err := s.sync(ctx); err != nil {
+		s.log.Error(err, "Unable to sync the image inspection configuration")
+		s.queue.AddRateLimited(key)
+		return true
+	}
+	s.queue.Forget(key)
+	return true
+}
+
+// sync applies the image inspection settings of the ClusterPodPlacementConfig in the cache, if any.
+func (s *ImageInspectionConfigSyncer) sync(ctx context.Context) error {
+	ctx = ctrllog.IntoContext(ctx, s.log)
+	cppc := &multiarchv1beta1.ClusterPodPlacementConfig{}
+	if err := s.cache.Get(ctx, client.ObjectKey{Name: common.SingletonResourceObjectName}, cppc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	configureImageInspection(ctx, cppc)
+	return nil
+}
+
+// configureImageInspection applies the image inspection settings of the given ClusterPodPlacementConfig.
+func configureImageInspection(ctx context.Context, cppc *multiarchv1beta1.ClusterPodPlacementConfig) {
+	image.FacadeSingleton().ConfigureCache(ctx, cppc.Spec.ImageInspectionCache)
+	image.FacadeSingleton().ConfigureArchitectures(ctx, cppc.SupportedArchitecturesOrDefault(), cppc.Spec.ArchitectureAliases)
+	image.FacadeSingleton().ConfigureRegistryPolicies(ctx, cppc.Spec.RegistryPolicies)
+	image.FacadeSingleton().ConfigureOfflineSources(ctx, cppc.Spec.OfflineImageSources)
+	image.FacadeSingleton().ConfigureManifestListValidation(ctx, cppc.Spec.ManifestListValidation)
+	image.FacadeSingleton().ConfigureBinaryVerification(ctx, cppc.Spec.BinaryVerification)
+	image.FacadeSingleton().ConfigureRuntimeClassMappings(ctx, cppc.Spec.RuntimeClassMappings)
+	image.FacadeSingleton().ConfigureArchitectureAgnosticImages(ctx, cppc.Spec.ArchitectureAgnosticImages)
+}
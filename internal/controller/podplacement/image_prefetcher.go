@@ -0,0 +1,337 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podplacement
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
+
+	ocpappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/internal/controller/podplacement/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/informers/clusterpodplacementconfig"
+)
+
+const (
+	// prefetchWorkers is the number of workers inspecting the queued images. The throughput is bounded by the rate
+	// limiter: the workers only allow slow inspections to overlap.
+	prefetchWorkers = 2
+	// defaultServiceAccountName is the service account the pods run as when their template does not set one.
+	defaultServiceAccountName = "default"
+)
+
+// prefetchedWorkloads are the kinds of the workloads whose pod templates are watched by the ImagePrefetcher. The
+// DaemonSets are not watched: their pods are ignored by the operator.
+var prefetchedWorkloads = []client.Object{
+	&appsv1.Deployment{},
+	&appsv1.StatefulSet{},
+	&batchv1.Job{},
+	&batchv1.CronJob{},
+	&ocpappsv1.DeploymentConfig{},
+}
+
+// prefetchRequest is an image of a workload's pod template to inspect. It is comparable so that the queue coalesces
+// the identical requests, e.g., from the Jobs created by the same CronJob.
+type prefetchRequest struct {
+	namespace string
+	image     string
+	// pullSecrets is the comma-separated list of the imagePullSecrets of the pod template.
+	pullSecrets string
+	// serviceAccount is the service account whose imagePullSecrets are used when the pod template sets none.
+	serviceAccount string
+	// inspectionSecrets is the comma-separated list of the inspectionSecrets of the PodPlacementConfigs matching
+	// the pod template.
+	inspectionSecrets string
+}
+
+// ImagePrefetcher warms up the image inspection cache: it watches the workloads and inspects the images of their pod
+// templates when they change, so that the pods are ungated with a cache hit when they are created. Like the pods, the
+// pod templates ignored by the operator are skipped, and the images are inspected with the secrets of the
+// PodPlacementConfigs matching them. The images are queued in a bounded queue, whose entries are dropped when full,
+// and inspected at a limited rate.
+type ImagePrefetcher struct {
+	cache      ctrlcache.Cache
+	restMapper meta.RESTMapper
+	scheme     *runtime.Scheme
+	clientSet  kubernetes.Interface
+	queue      workqueue.TypedInterface[prefetchRequest]
+	// queueMutex serializes the length check and the insertion of the requests, as the handlers of the informers
+	// of the different kinds run concurrently.
+	queueMutex sync.Mutex
+	queueSize  int
+	limiter    *rate.Limiter
+	log        logr.Logger
+	// clusterPodPlacementConfig returns the ClusterPodPlacementConfig, or nil if it does not exist.
+	clusterPodPlacementConfig func() *v1beta1.ClusterPodPlacementConfig
+	// podPlacementConfigs lists the PodPlacementConfigs of the given namespace.
+	podPlacementConfigs func(ctx context.Context, namespace string) ([]v1beta1.PodPlacementConfig, error)
+}
+
+func NewImagePrefetcher(cache ctrlcache.Cache, restMapper meta.RESTMapper, scheme *runtime.Scheme,
+	clientSet kubernetes.Interface, queueSize, inspectionsPerSecond, burst int) *ImagePrefetcher {
+	p := &ImagePrefetcher{
+		cache:      cache,
+		restMapper: restMapper,
+		scheme:     scheme,
+		clientSet:  clientSet,
+		queue: workqueue.NewTypedWithConfig(workqueue.TypedQueueConfig[prefetchRequest]{
+			Name: "image-prefetcher",
+		}),
+		queueSize: queueSize,
+		limiter:   rate.NewLimiter(rate.Limit(inspectionsPerSecond), burst),
+		log:       ctrllog.Log.WithName("ImagePrefetcher"),
+	}
+	p.clusterPodPlacementConfig = clusterpodplacementconfig.GetClusterPodPlacementConfig
+	p.podPlacementConfigs = p.listPodPlacementConfigs
+	return p
+}
+
+func (p *ImagePrefetcher) listPodPlacementConfigs(ctx context.Context, namespace string) ([]v1beta1.PodPlacementConfig, error) {
+	ppcList := &v1beta1.PodPlacementConfigList{}
+	if err := p.cache.List(ctx, ppcList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	return ppcList.Items, nil
+}
+
+func (p *ImagePrefetcher) Start(ctx context.Context) error {
+	metrics.InitImagePrefetcherMetrics()
+	p.log = ctrllog.FromContext(ctx, "handler", "ImagePrefetcher")
+	p.log.Info("Starting the Image Prefetcher", "queueSize", p.queueSize, "inspectionsPerSecond", p.limiter.Limit(),
+		"burst", p.limiter.Burst())
+	defer p.queue.ShutDown()
+	for _, obj := range prefetchedWorkloads {
+		gvk, err := apiutil.GVKForObject(obj, p.scheme)
+		if err != nil {
+			return err
+		}
+		if _, err = p.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version); meta.IsNoMatchError(err) {
+			// e.g., DeploymentConfigs are not served by clusters without the OpenShift apps API.
+			p.log.Info("The workload kind is not served by the cluster, skipping", "kind", gvk.String())
+			continue
+		} else if err != nil {
+			return err
+		}
+		informer, err := p.cache.GetInformer(ctx, obj)
+		if err != nil {
+			p.log.Error(err, "Unable to get the informer", "kind", gvk.String())
+			return err
+		}
+		if _, err = informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+			AddFunc:    p.onAdd,
+			UpdateFunc: p.onUpdate,
+		}); err != nil {
+			p.log.Error(err, "Error registering the handler", "kind", gvk.String())
+			return err
+		}
+	}
+	for range prefetchWorkers {
+		go func() {
+			for p.processNextRequest(ctx) {
+			}
+		}()
+	}
+	<-ctx.Done()
+	p.log.Info("Stopping the Image Prefetcher")
+	return nil
+}
+
+func (p *ImagePrefetcher) onAdd(obj interface{}) {
+	p.enqueue(p.prefetchRequestsOf(obj))
+}
+
+// onUpdate queues only the requests that were not issued for the previous version of the pod template, e.g., the
+// images updated by a rollout.
+func (p *ImagePrefetcher) onUpdate(oldObj, newObj interface{}) {
+	p.enqueue(p.prefetchRequestsOf(newObj).Difference(p.prefetchRequestsOf(oldObj)))
+}
+
+// enqueue adds the given requests to the queue. The requests exceeding the size of the queue are dropped: their
+// images are inspected when the pods are created.
+func (p *ImagePrefetcher) enqueue(requests sets.Set[prefetchRequest]) {
+	p.queueMutex.Lock()
+	defer p.queueMutex.Unlock()
+	for request := range requests {
+		if p.queue.Len() >= p.queueSize {
+			metrics.PrefetchDroppedImages.Inc()
+			p.log.V(4).Info("The queue is full, dropping the request", "namespace", request.namespace,
+				"image", request.image)
+			continue
+		}
+		p.queue.Add(request)
+		metrics.PrefetchEnqueuedImages.Inc()
+	}
+	metrics.PrefetchQueueLength.Set(float64(p.queue.Len()))
+}
+
+func (p *ImagePrefetcher) processNextRequest(ctx context.Context) bool {
+	request, shutdown := p.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer p.queue.Done(request)
+	metrics.PrefetchQueueLength.Set(float64(p.queue.Len()))
+	if err := p.limiter.Wait(ctx); err != nil {
+		return false
+	}
+	p.prefetch(ctx, request)
+	return true
+}
+
+// prefetch inspects the image of the given request with the pull secrets the pods will use.
+func (p *ImagePrefetcher) prefetch(ctx context.Context, request prefetchRequest) {
+	log := p.log.WithValues("namespace", request.namespace, "image", request.image)
+	ctx = ctrllog.IntoContext(ctx, log)
+	secrets := inspectionPullSecretDataList(ctx, p.clientSet, request.namespace,
+		secretNamesOf(request.inspectionSecrets), p.pullSecretNames(ctx, request))
+	if _, err := imageInspectionCache.GetCompatiblePlatformsSet(ctx, fmt.Sprintf("//%s", request.image),
+		false, secrets); err != nil {
+		metrics.PrefetchedImages.WithLabelValues("failure").Inc()
+		log.V(3).Info("Unable to prefetch the image", "error", err.Error())
+		return
+	}
+	metrics.PrefetchedImages.WithLabelValues("success").Inc()
+	log.V(4).Info("Prefetched the image")
+}
+
+// pullSecretNames returns the names of the imagePullSecrets the pods of the request will have. Like the
+// ServiceAccount admission plugin, they are the ones of the pod template or, if none, the ones of its service account.
+func (p *ImagePrefetcher) pullSecretNames(ctx context.Context, request prefetchRequest) []string {
+	if request.pullSecrets != "" {
+		return secretNamesOf(request.pullSecrets)
+	}
+	sa, err := p.clientSet.CoreV1().ServiceAccounts(request.namespace).Get(ctx, request.serviceAccount, metav1.GetOptions{})
+	if err != nil {
+		p.log.V(3).Info("Unable to get the service account", "namespace", request.namespace,
+			"serviceAccount", request.serviceAccount, "error", err.Error())
+		return nil
+	}
+	names := make([]string, 0, len(sa.ImagePullSecrets))
+	for _, secret := range sa.ImagePullSecrets {
+		names = append(names, secret.Name)
+	}
+	return names
+}
+
+// podTemplateOf returns the pod template of the given workload, or nil if obj is not a supported workload.
+func podTemplateOf(obj interface{}) *corev1.PodTemplateSpec {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return &o.Spec.Template
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template
+	case *batchv1.Job:
+		return &o.Spec.Template
+	case *batchv1.CronJob:
+		return &o.Spec.JobTemplate.Spec.Template
+	case *ocpappsv1.DeploymentConfig:
+		return o.Spec.Template
+	}
+	return nil
+}
+
+// secretNamesOf returns the names of the given comma-separated list of secrets.
+func secretNamesOf(names string) []string {
+	if names == "" {
+		return nil
+	}
+	return strings.Split(names, ",")
+}
+
+// prefetchRequestsOf returns the requests to inspect the images of the given workload's pod template. Like in
+// processPod, the pod templates whose pods are ignored, or whose architecture is already set by a node selector, are
+// skipped, and so are the images matching a static rule.
+func (p *ImagePrefetcher) prefetchRequestsOf(obj interface{}) sets.Set[prefetchRequest] {
+	requests := sets.New[prefetchRequest]()
+	template := podTemplateOf(obj)
+	workload, ok := obj.(metav1.Object)
+	if template == nil || !ok {
+		return requests
+	}
+	// The pods are not handled by the operator while the ClusterPodPlacementConfig does not exist.
+	cppc := p.clusterPodPlacementConfig()
+	if cppc == nil {
+		return requests
+	}
+	ctx := ctrllog.IntoContext(context.Background(), p.log)
+	pod := newPod(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   workload.GetNamespace(),
+			Labels:      template.Labels,
+			Annotations: template.Annotations,
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}, ctx, nil)
+	ppcs, err := p.podPlacementConfigs(ctx, pod.Namespace)
+	if err != nil {
+		p.log.V(3).Info("Unable to list the PodPlacementConfigs", "namespace", pod.Namespace, "error", err.Error())
+		return requests
+	}
+	matchingPPCs := pod.filterMatchingPPCs(&v1beta1.PodPlacementConfigList{Items: ppcs})
+	if pod.shouldIgnorePod(cppc, matchingPPCs) || pod.isNodeSelectorConfiguredForArchitecture() {
+		return requests
+	}
+	staticRules := staticImageRules(cppc, matchingPPCs)
+	inspectionSecrets := strings.Join(inspectionSecretNames(matchingPPCs), ",")
+	pullSecrets := make([]string, 0, len(template.Spec.ImagePullSecrets))
+	for _, secret := range template.Spec.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, secret.Name)
+	}
+	serviceAccount := template.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccountName
+	}
+	for _, container := range append(template.Spec.Containers, template.Spec.InitContainers...) {
+		if container.Image == "" {
+			continue
+		}
+		// The images matching a static rule are never inspected.
+		if rule := matchStaticImageRule(staticRules, fmt.Sprintf("//%s", container.Image)); rule != nil {
+			p.log.V(4).Info("Skipping the image matching a static rule", "image", container.Image,
+				"rule", rule.String())
+			continue
+		}
+		requests.Insert(prefetchRequest{
+			namespace:         workload.GetNamespace(),
+			image:             container.Image,
+			pullSecrets:       strings.Join(pullSecrets, ","),
+			serviceAccount:    serviceAccount,
+			inspectionSecrets: inspectionSecrets,
+		})
+	}
+	return requests
+}
@@ -0,0 +1,248 @@
+package podplacement
+
+import (
+	"context"
+	"testing"
+
+	ocpappsv1 "github.com/openshift/api/apps/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/internal/controller/podplacement/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// newTestImagePrefetcher returns an ImagePrefetcher with a default ClusterPodPlacementConfig, listing the given
+// PodPlacementConfigs.
+func newTestImagePrefetcher(clientSet *fakeclientset.Clientset, queueSize int,
+	ppcs ...v1beta1.PodPlacementConfig) *ImagePrefetcher {
+	p := NewImagePrefetcher(nil, nil, nil, clientSet, queueSize, 1, 1)
+	p.clusterPodPlacementConfig = func() *v1beta1.ClusterPodPlacementConfig {
+		return &v1beta1.ClusterPodPlacementConfig{}
+	}
+	p.podPlacementConfigs = func(_ context.Context, namespace string) ([]v1beta1.PodPlacementConfig, error) {
+		var items []v1beta1.PodPlacementConfig
+		for _, ppc := range ppcs {
+			if ppc.Namespace == namespace {
+				items = append(items, ppc)
+			}
+		}
+		return items, nil
+	}
+	return p
+}
+
+func podTemplate(serviceAccount string, pullSecrets []string, images ...string) v1.PodTemplateSpec {
+	template := v1.PodTemplateSpec{
+		Spec: v1.PodSpec{
+			ServiceAccountName: serviceAccount,
+		},
+	}
+	for _, secret := range pullSecrets {
+		template.Spec.ImagePullSecrets = append(template.Spec.ImagePullSecrets, v1.LocalObjectReference{Name: secret})
+	}
+	for i, image := range images {
+		container := v1.Container{Image: image}
+		if i%2 == 0 {
+			template.Spec.Containers = append(template.Spec.Containers, container)
+		} else {
+			template.Spec.InitContainers = append(template.Spec.InitContainers, container)
+		}
+	}
+	return template
+}
+
+func Test_prefetchRequestsOf(t *testing.T) {
+	objectMeta := metav1.ObjectMeta{Namespace: "test", Name: "test"}
+	ppcs := []v1beta1.PodPlacementConfig{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "secrets"},
+			Spec: v1beta1.PodPlacementConfigSpec{
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "secrets"}},
+				InspectionSecrets: []v1beta1.InspectionSecretReference{{Name: "registry-credentials"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "static"},
+			Spec: v1beta1.PodPlacementConfigSpec{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "static"}},
+				StaticImageArchitectures: []v1beta1.StaticImageArchitecture{
+					{ImagePattern: "quay.io/foo/static:*", Architectures: []string{utils.ArchitectureAmd64}},
+				},
+			},
+		},
+	}
+	labeledTemplate := func(labels map[string]string, images ...string) v1.PodTemplateSpec {
+		template := podTemplate("", nil, images...)
+		template.Labels = labels
+		return template
+	}
+	tests := []struct {
+		name     string
+		obj      interface{}
+		expected []prefetchRequest
+	}{
+		{
+			name: "deployment with the images in containers and initContainers",
+			obj: &appsv1.Deployment{ObjectMeta: objectMeta, Spec: appsv1.DeploymentSpec{
+				Template: podTemplate("", nil, "quay.io/foo/bar:latest", "quay.io/foo/init:latest"),
+			}},
+			expected: []prefetchRequest{
+				{namespace: "test", image: "quay.io/foo/bar:latest", serviceAccount: defaultServiceAccountName},
+				{namespace: "test", image: "quay.io/foo/init:latest", serviceAccount: defaultServiceAccountName},
+			},
+		},
+		{
+			name: "statefulset with pull secrets and service account",
+			obj: &appsv1.StatefulSet{ObjectMeta: objectMeta, Spec: appsv1.StatefulSetSpec{
+				Template: podTemplate("sa", []string{"s1", "s2"}, "quay.io/foo/bar:latest"),
+			}},
+			expected: []prefetchRequest{
+				{namespace: "test", image: "quay.io/foo/bar:latest", pullSecrets: "s1,s2", serviceAccount: "sa"},
+			},
+		},
+		{
+			name: "cronjob",
+			obj: &batchv1.CronJob{ObjectMeta: objectMeta, Spec: batchv1.CronJobSpec{
+				JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{
+					Template: podTemplate("", nil, "quay.io/foo/bar:latest"),
+				}},
+			}},
+			expected: []prefetchRequest{
+				{namespace: "test", image: "quay.io/foo/bar:latest", serviceAccount: defaultServiceAccountName},
+			},
+		},
+		{
+			name: "deploymentconfig with images set by triggers",
+			obj: &ocpappsv1.DeploymentConfig{ObjectMeta: objectMeta, Spec: ocpappsv1.DeploymentConfigSpec{
+				Template: &v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{
+					{Image: ""}, {Image: "quay.io/foo/bar:latest"},
+				}}},
+			}},
+			expected: []prefetchRequest{
+				{namespace: "test", image: "quay.io/foo/bar:latest", serviceAccount: defaultServiceAccountName},
+			},
+		},
+		{
+			name: "deployment matching a PodPlacementConfig with inspection secrets",
+			obj: &appsv1.Deployment{ObjectMeta: objectMeta, Spec: appsv1.DeploymentSpec{
+				Template: labeledTemplate(map[string]string{"app": "secrets"}, "quay.io/foo/bar:latest"),
+			}},
+			expected: []prefetchRequest{
+				{namespace: "test", image: "quay.io/foo/bar:latest", serviceAccount: defaultServiceAccountName,
+					inspectionSecrets: "registry-credentials"},
+			},
+		},
+		{
+			name: "deployment whose images match a static rule of a PodPlacementConfig",
+			obj: &appsv1.Deployment{ObjectMeta: objectMeta, Spec: appsv1.DeploymentSpec{
+				Template: labeledTemplate(map[string]string{"app": "static"}, "quay.io/foo/static:latest",
+					"quay.io/foo/bar:latest"),
+			}},
+			expected: []prefetchRequest{
+				{namespace: "test", image: "quay.io/foo/bar:latest", serviceAccount: defaultServiceAccountName},
+			},
+		},
+		{
+			name: "deployment in an ignored namespace",
+			obj: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "test"},
+				Spec: appsv1.DeploymentSpec{Template: podTemplate("", nil, "quay.io/foo/bar:latest")}},
+			expected: []prefetchRequest{},
+		},
+		{
+			name: "deployment with the architecture set by a node selector",
+			obj: &appsv1.Deployment{ObjectMeta: objectMeta, Spec: appsv1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{Spec: v1.PodSpec{
+					NodeSelector: map[string]string{utils.ArchLabel: utils.ArchitectureArm64},
+					Containers:   []v1.Container{{Image: "quay.io/foo/bar:latest"}},
+				}},
+			}},
+			expected: []prefetchRequest{},
+		},
+		{
+			name:     "deploymentconfig with no template",
+			obj:      &ocpappsv1.DeploymentConfig{ObjectMeta: objectMeta},
+			expected: []prefetchRequest{},
+		},
+		{
+			name:     "unsupported kind",
+			obj:      &v1.Pod{ObjectMeta: objectMeta},
+			expected: []prefetchRequest{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			p := newTestImagePrefetcher(fakeclientset.NewSimpleClientset(), 1, ppcs...)
+			g.Expect(p.prefetchRequestsOf(tt.obj).UnsortedList()).To(ConsistOf(tt.expected))
+		})
+	}
+}
+
+func TestImagePrefetcher_onUpdate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	metrics.InitImagePrefetcherMetrics()
+	p := newTestImagePrefetcher(fakeclientset.NewSimpleClientset(), 10)
+	oldDeployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+		Spec: appsv1.DeploymentSpec{Template: podTemplate("", nil, "quay.io/foo/bar:v1", "quay.io/foo/init:v1")}}
+	newDeployment := oldDeployment.DeepCopy()
+	newDeployment.Spec.Template.Spec.Containers[0].Image = "quay.io/foo/bar:v2"
+	p.onUpdate(oldDeployment, newDeployment)
+	g.Expect(p.queue.Len()).To(Equal(1), "only the updated image should be queued")
+	request, _ := p.queue.Get()
+	g.Expect(request.image).To(Equal("quay.io/foo/bar:v2"))
+}
+
+func TestImagePrefetcher_enqueue(t *testing.T) {
+	g := NewGomegaWithT(t)
+	metrics.InitImagePrefetcherMetrics()
+	p := newTestImagePrefetcher(fakeclientset.NewSimpleClientset(), 2)
+	p.enqueue(sets.New[prefetchRequest](
+		prefetchRequest{namespace: "test", image: "quay.io/foo/a:latest"},
+		prefetchRequest{namespace: "test", image: "quay.io/foo/b:latest"},
+		prefetchRequest{namespace: "test", image: "quay.io/foo/c:latest"},
+	))
+	g.Expect(p.queue.Len()).To(Equal(2), "the requests exceeding the queue size should be dropped")
+}
+
+func TestImagePrefetcher_pullSecretNames(t *testing.T) {
+	clientSet := fakeclientset.NewSimpleClientset(&v1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Namespace: "test", Name: "sa"},
+		ImagePullSecrets: []v1.LocalObjectReference{{Name: "sa-secret"}},
+	})
+	p := newTestImagePrefetcher(clientSet, 1)
+	tests := []struct {
+		name     string
+		request  prefetchRequest
+		expected []string
+	}{
+		{
+			name:     "the pod template pull secrets take precedence over the service account ones",
+			request:  prefetchRequest{namespace: "test", pullSecrets: "s1,s2", serviceAccount: "sa"},
+			expected: []string{"s1", "s2"},
+		},
+		{
+			name:     "the service account pull secrets are used when the pod template has none",
+			request:  prefetchRequest{namespace: "test", serviceAccount: "sa"},
+			expected: []string{"sa-secret"},
+		},
+		{
+			name:     "missing service account",
+			request:  prefetchRequest{namespace: "test", serviceAccount: "missing"},
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(p.pullSecretNames(ctx, tt.request)).To(Equal(tt.expected))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podplacement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/containers/image/v5/docker/reference"
+	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	"github.com/openshift/multiarch-tuning-operator/api/common/plugins"
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// imageSubstitutionRule is an image substitution rule along with the configuration it comes from, e.g.,
+// PodPlacementConfig-<name> or ClusterPodPlacementConfig.
+type imageSubstitutionRule struct {
+	plugins.ImageSubstitutionRule
+	source string
+}
+
+// imageSubstitutionRules returns the image substitution rules applying to the pods matching the given
+// PodPlacementConfigs: the ones of the PodPlacementConfigs enabling the plugin, by descending priority, followed by the
+// ones of the ClusterPodPlacementConfig.
+func imageSubstitutionRules(cppc *v1beta1.ClusterPodPlacementConfig,
+	matchingPPCs []v1beta1.PodPlacementConfig) []imageSubstitutionRule {
+	var rules []imageSubstitutionRule
+	for _, ppc := range sortedByPriority(matchingPPCs) {
+		if !ppc.PluginsEnabled(common.ImageSubstitutionPluginName) {
+			continue
+		}
+		source := fmt.Sprintf("%s-%s", v1beta1.PodPlacementConfigKind, ppc.Name)
+		for _, rule := range ppc.Spec.Plugins.ImageSubstitution.Rules {
+			rules = append(rules, imageSubstitutionRule{ImageSubstitutionRule: rule, source: source})
+		}
+	}
+	if substitution := cppc.ImageSubstitution(); substitution != nil {
+		for _, rule := range substitution.Rules {
+			rules = append(rules, imageSubstitutionRule{ImageSubstitutionRule: rule,
+				source: v1beta1.ClusterPodPlacementConfigKind})
+		}
+	}
+	return rules
+}
+
+// normalizedImageReference returns the fully qualified reference of the given image, e.g.,
+// docker.io/library/busybox:latest for busybox. It returns an empty string if the reference is invalid.
+func normalizedImageReference(imageReference string) string {
+	named, err := reference.ParseNormalizedNamed(imageReference)
+	if err != nil {
+		return ""
+	}
+	return reference.TagNameOnly(named).String()
+}
+
+// matchImageSubstitutionRule returns the first of the given rules whose image is the given image, once both are fully
+// qualified, or nil if none matches.
+func matchImageSubstitutionRule(rules []imageSubstitutionRule, imageReference string) *imageSubstitutionRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	fullyQualifiedReference := normalizedImageReference(imageReference)
+	if fullyQualifiedReference == "" {
+		return nil
+	}
+	for i := range rules {
+		if normalizedImageReference(rules[i].Image) == fullyQualifiedReference {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// String returns a description of the rule for the events of the pods.
+func (r *imageSubstitutionRule) String() string {
+	return fmt.Sprintf("image: %s, substitute: %s, source: %s", r.Image, r.Substitute, r.source)
+}
+
+// substitutionCandidate is a container of the pod whose image matches an image substitution rule.
+type substitutionCandidate struct {
+	container *corev1.Container
+	rule      *imageSubstitutionRule
+	skipCache bool
+}
+
+// substitutionInspection is an image inspected to evaluate the image substitution rules, along with its
+// architectures or the error that prevented its inspection.
+type substitutionInspection struct {
+	architectures sets.Set[string]
+	err           error
+}
+
+// substitutionInspectionKey identifies an image inspected to evaluate the image substitution rules: the images pulled
+// with the Always pull policy are inspected bypassing the cache.
+type substitutionInspectionKey struct {
+	imageReference string
+	skipCache      bool
+}
+
+// substituteImages swaps the images of the containers and the init containers of the pod matching the given rules for
+// their substitutes, when the architectures of the substitutes are a strict superset of the ones of the images.
+// The original images are recorded in the utils.OriginalImagesAnnotation annotation, as a comma-separated list of
+// <container>=<image> entries, and the containers already recorded are left untouched. The images and the substitutes
+// are inspected once each, in parallel, by at most maxParallelImageInspections workers. The rules whose image or
+// substitute cannot be inspected are not applied, and a warning event is published for them.
+func (pod *Pod) substituteImages(pullSecretDataList [][]byte, rules []imageSubstitutionRule) {
+	if len(rules) == 0 {
+		return
+	}
+	log := ctrllog.FromContext(pod.Ctx())
+	originalImages := pod.containerImagesAnnotation(utils.OriginalImagesAnnotation)
+	var candidates []substitutionCandidate
+	inspections := map[substitutionInspectionKey]*substitutionInspection{}
+	for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for i := range containers {
+			container := &containers[i]
+			if _, ok := originalImages[container.Name]; ok {
+				continue
+			}
+			rule := matchImageSubstitutionRule(rules, container.Image)
+			if rule == nil {
+				continue
+			}
+			skipCache := container.ImagePullPolicy == corev1.PullAlways
+			candidates = append(candidates, substitutionCandidate{container: container, rule: rule, skipCache: skipCache})
+			for _, imageReference := range []string{container.Image, rule.Substitute} {
+				inspections[substitutionInspectionKey{imageReference: imageReference, skipCache: skipCache}] =
+					&substitutionInspection{}
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	// The errors are recorded per image rather than returned: a rule that cannot be evaluated does not prevent the
+	// evaluation of the other ones.
+	var g errgroup.Group
+	g.SetLimit(maxParallelImageInspections)
+	for key, inspection := range inspections {
+		g.Go(func() error {
+			inspection.architectures, inspection.err = pod.imageArchitectures(key.imageReference, key.skipCache,
+				pullSecretDataList)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	substituted := false
+	for _, candidate := range candidates {
+		container, rule := candidate.container, candidate.rule
+		inspection := inspections[substitutionInspectionKey{imageReference: container.Image, skipCache: candidate.skipCache}]
+		substituteInspection := inspections[substitutionInspectionKey{imageReference: rule.Substitute,
+			skipCache: candidate.skipCache}]
+		if err := errors.Join(inspection.err, substituteInspection.err); err != nil {
+			log.V(1).Error(err, "Unable to evaluate the image substitution rule", "imageName", container.Image,
+				"rule", rule.String())
+			pod.PublishEvent(corev1.EventTypeWarning, ImageSubstitutionSkipped,
+				fmt.Sprintf("%s container: %s, %s, error: %s", ImageSubstitutionSkippedMsg, container.Name, rule,
+					err.Error()))
+			continue
+		}
+		architectures, substituteArchitectures := inspection.architectures, substituteInspection.architectures
+		if !substituteArchitectures.IsSuperset(architectures) || substituteArchitectures.Equal(architectures) {
+			log.V(2).Info("The substitute image does not widen the placement of the pod", "imageName",
+				container.Image, "rule", rule.String())
+			continue
+		}
+		pod.PublishEvent(corev1.EventTypeNormal, ImageSubstituted,
+			fmt.Sprintf("%s container: %s, %s, architectures: {%s} -> {%s}", ImageSubstitutedMsg, container.Name,
+				rule, strings.Join(sets.List(architectures), ", "),
+				strings.Join(sets.List(substituteArchitectures), ", ")))
+		originalImages[container.Name] = container.Image
+		container.Image = rule.Substitute
+		substituted = true
+	}
+	if substituted {
+		pod.ensureContainerImagesAnnotation(utils.OriginalImagesAnnotation, originalImages)
+	}
+}
+
+// imageArchitectures returns the architectures of the given image, as inspected.
+func (pod *Pod) imageArchitectures(imageReference string, skipCache bool,
+	pullSecretDataList [][]byte) (sets.Set[string], error) {
+	imageName := localImageStreamReference(pod.Ctx(), pod.Namespace, fmt.Sprintf("//%s", imageReference))
+	platforms, err := imageInspectionCache.GetCompatiblePlatformsSet(pod.Ctx(), imageName, skipCache,
+		pullSecretDataList)
+	if err != nil {
+		return nil, err
+	}
+	return image.ArchitecturesOf(platforms), nil
+}
@@ -16,7 +16,7 @@ var (
 	TimeToInspectImage      prometheus.Histogram
 	TimeToInspectPodImages  prometheus.Histogram
 	ProcessedPodsCtrl       prometheus.Counter
-	FailedInspectionCounter prometheus.Counter
+	FailedInspectionCounter *prometheus.CounterVec
 )
 
 var onceController sync.Once
@@ -61,11 +61,12 @@ func initPodPlacementControllerMetrics() {
 			Help: "The total number of pods processed by the pod placement controller that had a scheduling gate",
 		},
 	)
-	FailedInspectionCounter = prometheus.NewCounter(
+	FailedInspectionCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "mto_ppo_ctrl_failed_image_inspection_total",
-			Help: "The total number of image inspections that failed",
+			Help: "The total number of image inspections that failed, by error class",
 		},
+		[]string{"class"},
 	)
 	metrics2.Registry.MustRegister(TimeToProcessPod, TimeToProcessGatedPod, TimeToInspectImage,
 		TimeToInspectPodImages, ProcessedPodsCtrl, FailedInspectionCounter)
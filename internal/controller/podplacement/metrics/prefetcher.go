@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"sync"
+
+	metrics2 "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	PrefetchQueueLength    prometheus.Gauge
+	PrefetchEnqueuedImages prometheus.Counter
+	PrefetchDroppedImages  prometheus.Counter
+	PrefetchedImages       *prometheus.CounterVec
+)
+
+var oncePrefetcher sync.Once
+
+func InitImagePrefetcherMetrics() {
+	oncePrefetcher.Do(initImagePrefetcherMetrics)
+}
+
+func initImagePrefetcherMetrics() {
+	PrefetchQueueLength = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "mto_ppo_ctrl_prefetch_queue_length",
+			Help: "The current number of images waiting to be inspected by the image prefetcher",
+		},
+	)
+	PrefetchEnqueuedImages = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mto_ppo_ctrl_prefetch_enqueued_images_total",
+			Help: "The total number of images extracted from the workloads' pod templates and queued for inspection",
+		},
+	)
+	PrefetchDroppedImages = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mto_ppo_ctrl_prefetch_dropped_images_total",
+			Help: "The total number of images not queued for inspection because the prefetcher queue was full",
+		},
+	)
+	PrefetchedImages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mto_ppo_ctrl_prefetched_images_total",
+			Help: "The total number of images inspected by the image prefetcher, by result",
+		},
+		[]string{"result"},
+	)
+	metrics2.Registry.MustRegister(PrefetchQueueLength, PrefetchEnqueuedImages, PrefetchDroppedImages, PrefetchedImages)
+}
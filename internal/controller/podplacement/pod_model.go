@@ -19,11 +19,18 @@ package podplacement
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -42,17 +49,69 @@ import (
 var (
 	// imageInspectionCache is the facade singleton used to inspect images. It is defined here to facilitate testing.
 	imageInspectionCache image.ICache = image.FacadeSingleton()
+	// localImageStreamReference resolves the bare names of the ImageStreams with local lookup. It is defined here to
+	// facilitate testing.
+	localImageStreamReference = image.FacadeSingleton().LocalImageStreamReference
+	// nodeImagePlatforms infers the platforms of the images from the nodes holding them. It is defined here to
+	// facilitate testing.
+	nodeImagePlatforms = image.FacadeSingleton().NodeImagePlatforms
+	// supportedArchitectures returns the architectures supported by the cluster. It is defined here to facilitate
+	// testing.
+	supportedArchitectures = image.FacadeSingleton().SupportedArchitectures
 )
 
-const MaxRetryCount = 5
+const (
+	MaxRetryCount = 5
+
+	// maxParallelImageInspections is the maximum number of images of a pod inspected in parallel.
+	maxParallelImageInspections = 4
+
+	rateLimitBaseBackoff = 10 * time.Second
+	rateLimitMaxBackoff  = 5 * time.Minute
+)
 
 type containerImage struct {
 	imageName string
 	skipCache bool
+	// volume is true if the image is only mounted as an image volume.
+	volume bool
+}
+
+// constrainsPlacement returns false for the images only mounted as image volumes whose platforms include no Linux
+// platform, e.g., the OCI artifacts holding models or plugins: they are mounted as data and do not constrain the
+// placement of the pod. The images of the containers and the Linux images of the volumes always constrain it.
+func (c containerImage) constrainsPlacement(platforms sets.Set[image.Platform]) bool {
+	return !c.volume || image.OperatingSystemsOf(platforms).Has(utils.OSLinux)
+}
+
+// imageSource is an image of the pod along with where the pod uses it, e.g., container app or volume models.
+type imageSource struct {
+	containerImage
+	source string
+}
+
+// placementOptions are the inputs of the computation of the node affinity of a pod, resolved from the
+// ClusterPodPlacementConfig and the PodPlacementConfigs matching the pod.
+type placementOptions struct {
+	// pullSecretDataList is the auth data used to inspect the images of the pod.
+	pullSecretDataList [][]byte
+	// substitutionRules are the image substitution rules applied before the images are inspected.
+	substitutionRules []imageSubstitutionRule
+	// staticRules are the static image rules whose architectures are used instead of inspecting the images.
+	staticRules []staticImageRule
+	// platformVariants are the node requirements of the platform variants.
+	platformVariants []v1beta1.PlatformVariant
+	// runtimeClassMappings are the platforms run by a RuntimeClass, e.g., wasi/wasm.
+	runtimeClassMappings []v1beta1.RuntimeClassMapping
+	// emulation is the configuration of the emulation plugin, or nil if it is disabled.
+	emulation *plugins.Emulation
 }
 
 type Pod struct {
 	models.Pod
+	// inspectedDigests maps the images of the pod, e.g., //quay.io/myorg/foo:1.2, to the digest they were inspected at
+	// when their platforms were last intersected.
+	inspectedDigests map[string]digest.Digest
 }
 
 func newPod(pod *corev1.Pod, ctx context.Context, recorder record.EventRecorder) *Pod {
@@ -95,18 +154,35 @@ func (pod *Pod) getPodImagePullSecrets() []string {
 
 // SetNodeAffinityArchRequirement wraps the logic to set the nodeAffinity for the pod.
 // It verifies first that no nodeSelector field is set for the kubernetes.io/arch label.
-// Then, it computes the intersection of the architectures supported by the images used by the pod via pod.getArchitecturePredicate.
-// Finally, it initializes the nodeAffinity for the pod and set it to the computed requirement via the pod.setRequiredArchNodeAffinity method.
-func (pod *Pod) SetNodeAffinityArchRequirement(pullSecretDataList [][]byte) (bool, error) {
+// Then, it substitutes the images matching the substitutionRules of the given options via pod.substituteImages, so that
+// the node affinity covers the architectures of the substitutes.
+// Then, it computes the intersection of the platforms supported by the images used by the pod via pod.intersectImagesPlatforms.
+// Finally, it initializes the nodeAffinity for the pod and set it to the computed requirement via the pod.setRequiredArchNodeAffinity method,
+// refining it with the operating systems of the images and the nodes matching the platformVariants of the given options
+// via the pod.setRequiredPlatformNodeAffinity method.
+// When the images only support a platform of the runtimeClassMappings of the given options, e.g., wasi/wasm, the node
+// affinity is set to the requirements of the mapping instead, via the pod.setRuntimeClassNodeAffinity method.
+// When the emulation plugin of the given options is not nil, the nodes emulating the architectures of the images are
+// also allowed via the pod.setEmulationNodeAffinity method.
+func (pod *Pod) SetNodeAffinityArchRequirement(opts placementOptions) (bool, error) {
 	if pod.isNodeSelectorConfiguredForArchitecture() {
 		pod.publishIgnorePod()
 		return false, nil
 	}
-	requirement, err := pod.getArchitecturePredicate(pullSecretDataList)
+	pod.substituteImages(opts.pullSecretDataList, opts.substitutionRules)
+	platforms, err := pod.intersectImagesPlatforms(opts.pullSecretDataList, opts.staticRules)
 	if err != nil {
 		return false, err
 	}
+	if mapping := runtimeClassMappingOf(platforms, opts.runtimeClassMappings); mapping != nil {
+		pod.EnsureNoLabel(utils.ImageInspectionErrorLabel)
+		pod.EnsureNoAnnotation(utils.ImageInspectionRetryAfterAnnotation)
+		pod.setRuntimeClassNodeAffinity(mapping)
+		return true, nil
+	}
+	requirement := architecturePredicate(sets.List(image.ArchitecturesOf(platforms)))
 	pod.EnsureNoLabel(utils.ImageInspectionErrorLabel)
+	pod.EnsureNoAnnotation(utils.ImageInspectionRetryAfterAnnotation)
 	if len(requirement.Values) == 0 {
 		pod.PublishEvent(corev1.EventTypeNormal, NoSupportedArchitecturesFound, NoSupportedArchitecturesFoundMsg)
 	}
@@ -124,12 +200,174 @@ func (pod *Pod) SetNodeAffinityArchRequirement(pullSecretDataList [][]byte) (boo
 		pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
 	}
 
+	userTerms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.DeepCopy().NodeSelectorTerms
 	pod.setRequiredArchNodeAffinity(requirement)
 	pod.PublishEvent(corev1.EventTypeNormal, ArchitectureAwareNodeAffinitySet,
 		ArchitecturePredicateSetupMsg+fmt.Sprintf("{%s}", strings.Join(requirement.Values, ", ")))
+	pod.setRequiredPlatformNodeAffinity(requirement, platforms, opts.platformVariants)
+	pod.setEmulationNodeAffinity(userTerms, platforms, opts.platformVariants, opts.emulation)
 	return true, nil
 }
 
+// setEmulationNodeAffinity allows the pod to run on the nodes of the given emulation plugin that emulate any of the
+// architectures of the given platforms. For each emulating node set, each of the given userTerms, i.e., the
+// nodeSelectorTerms of the pod before the operator patched them, that does not constrain the architecture is added
+// to the required node affinity along with the requirements selecting the emulating nodes. As the nodeSelectorTerms
+// are ORed, the pod can then be scheduled on either the nodes of the architectures of the images or the emulating
+// nodes. The emulating terms also require the Linux nodes and, when none of the emulated platforms is
+// unconstrained, the requirements of the given platformVariants for each of them, as the native terms do.
+// A preferred node affinity term, weighted with the native weight of the plugin, keeps the nodes running the
+// images natively first, and the pod is labelled as allowed to run emulated.
+// The emulating nodes are always allowed: the native nodes are only preferred, and the scheduler falls back to the
+// emulating ones when the native ones cannot fit the pod.
+// Emulation only applies to Linux images, as binfmt_misc is Linux-specific.
+func (pod *Pod) setEmulationNodeAffinity(userTerms []corev1.NodeSelectorTerm, platforms sets.Set[image.Platform],
+	platformVariants []v1beta1.PlatformVariant, emulation *plugins.Emulation) {
+	if emulation == nil || platforms.Len() == 0 ||
+		!image.OperatingSystemsOf(platforms).Equal(sets.New[string](utils.OSLinux)) {
+		return
+	}
+	architectures := image.ArchitecturesOf(platforms)
+	constraints := variantConstraints(platforms, platformVariants)
+	if len(userTerms) == 0 {
+		userTerms = make([]corev1.NodeSelectorTerm, 1)
+	}
+	osRequirement := corev1.NodeSelectorRequirement{
+		Key:      utils.OSLabel,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{utils.OSLinux},
+	}
+	emulatedArchitectures := sets.New[string]()
+	nodeSelector := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	for _, nodes := range emulation.Nodes {
+		emulated := architectures.Intersection(sets.New[string](nodes.Architectures...))
+		if emulated.Len() == 0 {
+			continue
+		}
+		// The variant requirements of the emulated platforms: a single empty set if any of them is unconstrained.
+		var variantRequirements [][]corev1.NodeSelectorRequirement
+		for _, platform := range image.SortedPlatforms(platforms) {
+			if !emulated.Has(platform.Architecture) {
+				continue
+			}
+			requirements, ok := constraints[platform]
+			if !ok {
+				variantRequirements = [][]corev1.NodeSelectorRequirement{nil}
+				break
+			}
+			variantRequirements = append(variantRequirements, requirements)
+		}
+		for _, term := range userTerms {
+			if slices.ContainsFunc(term.MatchExpressions, func(expression corev1.NodeSelectorRequirement) bool {
+				return expression.Key == utils.ArchLabel
+			}) {
+				continue
+			}
+			for _, requirements := range variantRequirements {
+				emulatingTerm := *term.DeepCopy()
+				emulatingTerm.MatchExpressions = append(emulatingTerm.MatchExpressions, nodes.MatchExpressions...)
+				emulatingTerm.MatchExpressions = append(emulatingTerm.MatchExpressions, osRequirement)
+				emulatingTerm.MatchExpressions = append(emulatingTerm.MatchExpressions, requirements...)
+				nodeSelector.NodeSelectorTerms = append(nodeSelector.NodeSelectorTerms, emulatingTerm)
+			}
+			emulatedArchitectures = emulatedArchitectures.Union(emulated)
+		}
+	}
+	if emulatedArchitectures.Len() == 0 {
+		return
+	}
+	pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+		pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, corev1.PreferredSchedulingTerm{
+			Weight: emulation.NativeWeightOrDefault(),
+			Preference: corev1.NodeSelectorTerm{
+				MatchExpressions: []corev1.NodeSelectorRequirement{architecturePredicate(sets.List(architectures))},
+			},
+		})
+	pod.EnsureLabel(utils.EmulationLabel, utils.EmulationLabelValueAllowed)
+	pod.PublishEvent(corev1.EventTypeNormal, ArchitectureAwareEmulationNodeAffinitySet,
+		EmulationPredicateSetupMsg+fmt.Sprintf("{%s}", strings.Join(sets.List(emulatedArchitectures), ", ")))
+}
+
+// runtimeClassMappingOf returns the mapping of the given runtimeClassMappings whose platform is the only one in the
+// given platforms, or nil if there is none.
+func runtimeClassMappingOf(platforms sets.Set[image.Platform], runtimeClassMappings []v1beta1.RuntimeClassMapping) *v1beta1.RuntimeClassMapping {
+	if platforms.Len() != 1 {
+		return nil
+	}
+	platform := platforms.UnsortedList()[0]
+	platform.Variant = ""
+	for i := range runtimeClassMappings {
+		if runtimeClassMappings[i].Platform == platform.String() {
+			return &runtimeClassMappings[i]
+		}
+	}
+	return nil
+}
+
+// setRuntimeClassNodeAffinity adds the node selector requirements of the given RuntimeClass mapping to the required
+// node affinity of the pod. As the runtimeClassName of a pod cannot change after its creation, a warning event is
+// published if the pod does not already use the RuntimeClass of the mapping.
+func (pod *Pod) setRuntimeClassNodeAffinity(mapping *v1beta1.RuntimeClassMapping) {
+	if pod.Spec.RuntimeClassName == nil || *pod.Spec.RuntimeClassName != mapping.RuntimeClassName {
+		pod.PublishEvent(corev1.EventTypeWarning, RuntimeClassMismatch, RuntimeClassMismatchMsg+mapping.RuntimeClassName)
+	}
+	if len(mapping.MatchExpressions) == 0 {
+		return
+	}
+	if pod.Spec.Affinity == nil {
+		pod.Spec.Affinity = &corev1.Affinity{}
+	}
+	if pod.Spec.Affinity.NodeAffinity == nil {
+		pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	if pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	for _, requirement := range mapping.MatchExpressions {
+		pod.setRequiredArchNodeAffinity(requirement)
+	}
+	pod.PublishEvent(corev1.EventTypeNormal, ArchitectureAwareRuntimeClassNodeAffinitySet,
+		RuntimeClassPredicateSetupMsg+mapping.RuntimeClassName)
+}
+
+// applyRuntimeClass sets the runtimeClassName of the pod to the given RuntimeClass, along with its overhead, node
+// selector and tolerations, as the RuntimeClass admission plugin does for the pods created with a runtimeClassName.
+// It returns false, leaving the pod untouched, if the pod sets an overhead or a node selector conflicting with the
+// RuntimeClass.
+func (pod *Pod) applyRuntimeClass(runtimeClass *nodev1.RuntimeClass) bool {
+	if pod.Spec.Overhead != nil {
+		return false
+	}
+	if runtimeClass.Scheduling != nil {
+		for key, value := range runtimeClass.Scheduling.NodeSelector {
+			if podValue, ok := pod.Spec.NodeSelector[key]; ok && podValue != value {
+				return false
+			}
+		}
+	}
+	pod.Spec.RuntimeClassName = &runtimeClass.Name
+	if runtimeClass.Overhead != nil {
+		pod.Spec.Overhead = runtimeClass.Overhead.PodFixed.DeepCopy()
+	}
+	if runtimeClass.Scheduling == nil {
+		return true
+	}
+	for key, value := range runtimeClass.Scheduling.NodeSelector {
+		if pod.Spec.NodeSelector == nil {
+			pod.Spec.NodeSelector = map[string]string{}
+		}
+		pod.Spec.NodeSelector[key] = value
+	}
+	for _, toleration := range runtimeClass.Scheduling.Tolerations {
+		if !slices.ContainsFunc(pod.Spec.Tolerations, func(t corev1.Toleration) bool {
+			return equality.Semantic.DeepEqual(t, toleration)
+		}) {
+			pod.Spec.Tolerations = append(pod.Spec.Tolerations, toleration)
+		}
+	}
+	return true
+}
+
 // setRequiredArchNodeAffinity sets the node affinity for the pod to the given requirement based on the rules in
 // the sig-scheduling's KEP-3838: https://github.com/kubernetes/enhancements/tree/master/keps/sig-scheduling/3838-pod-mutable-scheduling-directives.
 func (pod *Pod) setRequiredArchNodeAffinity(requirement corev1.NodeSelectorRequirement) {
@@ -296,6 +534,115 @@ func (pod *Pod) trackAffinitySource(arch string, weight int32, source string, ap
 	}
 }
 
+// setRequiredPlatformNodeAffinity refines the given arch requirement, added by the operator to the nodeSelectorTerms,
+// with the operating systems and the CPU variants of the given platforms, i.e., the platforms supported by all the
+// images of the pod, along with the minimum CPU variant they require.
+// Each nodeSelectorTerm including the arch requirement is split into one term per operating system, requiring the
+// kubernetes.io/os label to match it, unless all the platforms are Linux ones. Each of them is further split into one
+// term for the architectures that do not require any additional constraint and one term per architecture whose
+// CPU variant requires the node selector requirements in the given platformVariants, so that the constraints of a
+// platform do not apply to the nodes of the others.
+func (pod *Pod) setRequiredPlatformNodeAffinity(requirement corev1.NodeSelectorRequirement,
+	platforms sets.Set[image.Platform], platformVariants []v1beta1.PlatformVariant) {
+	constraints := variantConstraints(platforms, platformVariants)
+	operatingSystems := image.OperatingSystemsOf(platforms)
+	requireOS := !operatingSystems.Equal(sets.New[string](utils.OSLinux))
+	if platforms.Len() == 0 || (!requireOS && len(constraints) == 0) {
+		return
+	}
+	sortedPlatforms := image.SortedPlatforms(platforms)
+	nodeSelectorTerms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	patchedTerms := make([]corev1.NodeSelectorTerm, 0, len(nodeSelectorTerms))
+	for _, term := range nodeSelectorTerms {
+		archExpressionIndex := slices.IndexFunc(term.MatchExpressions, func(expression corev1.NodeSelectorRequirement) bool {
+			return equality.Semantic.DeepEqual(expression, requirement)
+		})
+		if archExpressionIndex < 0 {
+			patchedTerms = append(patchedTerms, term)
+			continue
+		}
+		for _, operatingSystem := range sets.List(operatingSystems) {
+			osTerm := *term.DeepCopy()
+			if requireOS {
+				osTerm.MatchExpressions = append(osTerm.MatchExpressions, corev1.NodeSelectorRequirement{
+					Key:      utils.OSLabel,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{operatingSystem},
+				})
+			}
+			var unconstrainedArchitectures []string
+			var constrainedPlatforms []image.Platform
+			for _, platform := range sortedPlatforms {
+				if platform.OS != operatingSystem {
+					continue
+				}
+				if _, ok := constraints[platform]; ok {
+					constrainedPlatforms = append(constrainedPlatforms, platform)
+				} else {
+					unconstrainedArchitectures = append(unconstrainedArchitectures, platform.Architecture)
+				}
+			}
+			if len(unconstrainedArchitectures) > 0 {
+				patchedTerms = append(patchedTerms, withArchitectures(osTerm, archExpressionIndex, unconstrainedArchitectures))
+			}
+			for _, platform := range constrainedPlatforms {
+				constrainedTerm := withArchitectures(osTerm, archExpressionIndex, []string{platform.Architecture})
+				constrainedTerm.MatchExpressions = append(constrainedTerm.MatchExpressions, constraints[platform]...)
+				patchedTerms = append(patchedTerms, constrainedTerm)
+			}
+		}
+	}
+	pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = patchedTerms
+
+	if requireOS {
+		platformNames := make([]string, 0, len(sortedPlatforms))
+		for _, platform := range sortedPlatforms {
+			platform.Variant = ""
+			platformNames = append(platformNames, platform.String())
+		}
+		pod.PublishEvent(corev1.EventTypeNormal, ArchitectureAwareOSNodeAffinitySet,
+			ArchitectureOSPredicateSetupMsg+fmt.Sprintf("{%s}", strings.Join(platformNames, ", ")))
+	}
+	if len(constraints) > 0 {
+		platformNames := make([]string, 0, len(constraints))
+		for _, platform := range sortedPlatforms {
+			if _, ok := constraints[platform]; ok {
+				platformNames = append(platformNames, platform.String())
+			}
+		}
+		pod.PublishEvent(corev1.EventTypeNormal, ArchitectureAwareVariantNodeAffinitySet,
+			ArchitectureVariantPredicateSetupMsg+fmt.Sprintf("{%s}", strings.Join(platformNames, ", ")))
+	}
+}
+
+// variantConstraints returns, for each of the given platforms requiring a CPU variant, the node selector requirements
+// of all the platformVariants of its architecture up to the required variant. Platforms with no requirements are omitted.
+func variantConstraints(platforms sets.Set[image.Platform],
+	platformVariants []v1beta1.PlatformVariant) map[image.Platform][]corev1.NodeSelectorRequirement {
+	constraints := map[image.Platform][]corev1.NodeSelectorRequirement{}
+	for platform := range platforms {
+		if platform.Variant == "" {
+			continue
+		}
+		for _, platformVariant := range platformVariants {
+			if platformVariant.Architecture != platform.Architecture ||
+				image.CompareVariants(platformVariant.Variant, platform.Variant) > 0 {
+				continue
+			}
+			constraints[platform] = append(constraints[platform], platformVariant.MatchExpressions...)
+		}
+	}
+	return constraints
+}
+
+// withArchitectures returns a deep copy of the given term in which the expression at archExpressionIndex
+// requires the given architectures.
+func withArchitectures(term corev1.NodeSelectorTerm, archExpressionIndex int, architectures []string) corev1.NodeSelectorTerm {
+	patchedTerm := *term.DeepCopy()
+	patchedTerm.MatchExpressions[archExpressionIndex].Values = architectures
+	return patchedTerm
+}
+
 // setRequiredNodeAffinityToFallbackArchitecture sets the node affinity for the pod to the fallback architecture.
 func (pod *Pod) setRequiredNodeAffinityToFallbackArchitecture(architecture string) {
 	requirement := corev1.NodeSelectorRequirement{
@@ -328,62 +675,305 @@ func (pod *Pod) getArchitecturePredicate(pullSecretDataList [][]byte) (corev1.No
 	if err != nil {
 		return corev1.NodeSelectorRequirement{}, err
 	}
+	return architecturePredicate(architectures), nil
+}
 
+// architecturePredicate returns the node selector requirement for the given architectures.
+func architecturePredicate(architectures []string) corev1.NodeSelectorRequirement {
 	if len(architectures) == 0 {
 		return corev1.NodeSelectorRequirement{
 			Key:      utils.NoSupportedArchLabel,
 			Operator: corev1.NodeSelectorOpExists,
-		}, nil
+		}
 	}
 	return corev1.NodeSelectorRequirement{
 		Key:      utils.ArchLabel,
 		Operator: corev1.NodeSelectorOpIn,
 		Values:   architectures,
-	}, nil
+	}
 }
 
+// imagesNamesSet returns the images of the containers, the init containers and the image volumes of the pod. An image
+// is marked as a volume image only if no container runs it.
 func (pod *Pod) imagesNamesSet() sets.Set[containerImage] {
+	volumeOnly := map[containerImage]bool{}
+	for _, source := range pod.imageSources() {
+		key := containerImage{imageName: source.imageName, skipCache: source.skipCache}
+		previous, seen := volumeOnly[key]
+		volumeOnly[key] = source.volume && (!seen || previous)
+	}
 	imageNamesSet := sets.New[containerImage]()
-	for _, container := range append(pod.Spec.Containers, pod.Spec.InitContainers...) {
-		imageNamesSet.Insert(containerImage{
+	for key, volume := range volumeOnly {
+		key.volume = volume
+		imageNamesSet.Insert(key)
+	}
+	return imageNamesSet
+}
+
+// imageSources returns the images of the containers, the init containers and the image volumes of the pod, along with
+// their source. The image volumes listed in the utils.IgnoreImageVolumesAnnotation annotation of the pod are skipped.
+func (pod *Pod) imageSources() []imageSource {
+	var sources []imageSource
+	for _, container := range pod.Spec.Containers {
+		sources = append(sources, imageSource{containerImage: containerImage{
 			imageName: fmt.Sprintf("//%s", container.Image),
 			skipCache: container.ImagePullPolicy == corev1.PullAlways,
-		})
+		}, source: "container " + container.Name})
 	}
-	return imageNamesSet
+	for _, container := range pod.Spec.InitContainers {
+		sources = append(sources, imageSource{containerImage: containerImage{
+			imageName: fmt.Sprintf("//%s", container.Image),
+			skipCache: container.ImagePullPolicy == corev1.PullAlways,
+		}, source: "initContainer " + container.Name})
+	}
+	ignoredVolumes := sets.New[string]()
+	for _, name := range strings.Split(pod.Annotations[utils.IgnoreImageVolumesAnnotation], ",") {
+		ignoredVolumes.Insert(strings.TrimSpace(name))
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Image == nil || volume.Image.Reference == "" || ignoredVolumes.Has(volume.Name) {
+			continue
+		}
+		sources = append(sources, imageSource{containerImage: containerImage{
+			imageName: fmt.Sprintf("//%s", volume.Image.Reference),
+			skipCache: volume.Image.PullPolicy == corev1.PullAlways,
+			volume:    true,
+		}, source: "volume " + volume.Name})
+	}
+	return sources
+}
+
+// containerImagesAnnotation returns the images recorded in the given annotation of the pod, by container name. The
+// annotation holds a comma-separated list of <container>=<image> entries.
+func (pod *Pod) containerImagesAnnotation(annotation string) map[string]string {
+	images := map[string]string{}
+	for _, entry := range strings.Split(pod.Annotations[annotation], ",") {
+		if containerName, imageName, ok := strings.Cut(entry, "="); ok {
+			images[containerName] = imageName
+		}
+	}
+	return images
+}
+
+// ensureContainerImagesAnnotation records the given images, by container name, in the given annotation of the pod.
+func (pod *Pod) ensureContainerImagesAnnotation(annotation string, images map[string]string) {
+	entries := make([]string, 0, len(images))
+	for containerName, imageName := range images {
+		entries = append(entries, fmt.Sprintf("%s=%s", containerName, imageName))
+	}
+	sort.Strings(entries)
+	pod.EnsureAnnotation(annotation, strings.Join(entries, ","))
 }
 
 // inspect returns the list of supported architectures for the images used by the pod.
 // if an error occurs, it returns the error and a nil slice of strings.
 func (pod *Pod) intersectImagesArchitecture(pullSecretDataList [][]byte) (supportedArchitectures []string, err error) {
+	platforms, err := pod.intersectImagesPlatforms(pullSecretDataList, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sets.List(image.ArchitecturesOf(platforms)), nil
+}
+
+// intersectImagesPlatforms returns the (os, architecture) pairs supported by all the images used by the pod, along
+// with the minimum CPU variant the pod requires for them. When the pod sets .spec.os, only the platforms of that
+// operating system are considered.
+// An image built for several variants of a platform requires the lowest of them; the pod requires the highest
+// variant required by its images. The empty variant is the baseline of the architecture.
+// The platforms of the images matching one of the given static rules are set by the first of them, and these images
+// are not inspected.
+// When the inspection of an image not pulled with the Always pull policy fails, its platforms are inferred from the
+// nodes already holding it, if any. The pod is labelled with the inference source: the node images take precedence
+// over the static rules.
+// if an error occurs, it returns the error and a nil set.
+func (pod *Pod) intersectImagesPlatforms(pullSecretDataList [][]byte,
+	staticRules []staticImageRule) (sets.Set[image.Platform], error) {
 	log := ctrllog.FromContext(pod.Ctx())
 	imageNamesSet := pod.imagesNamesSet()
 	log.V(1).Info("Images list for pod", "imageNamesSet", fmt.Sprintf("%+v", imageNamesSet))
 	// https://github.com/containers/skopeo/blob/v1.11.1/cmd/skopeo/inspect.go#L72
-	// Iterate over the images, get their architectures and intersect (as in set intersection) them each other
-	var supportedArchitecturesSet sets.Set[string]
+	// Iterate over the images, get their platforms and intersect (as in set intersection) their (os, architecture) pairs
 	nowExternal := time.Now()
 	defer utils.HistogramObserve(nowExternal, metrics.TimeToInspectPodImages)
-	for imageContainer := range imageNamesSet {
-		log.V(3).Info("Checking image", "imageName", imageContainer.imageName,
-			"skipCache (imagePullPolicy==Always)", imageContainer.skipCache)
-		// We are collecting the time to inspect the image here to avoid implementing a metric in each of the
-		// cache implementations.
-		now := time.Now()
-		currentImageSupportedArchitectures, err := imageInspectionCache.GetCompatibleArchitecturesSet(pod.Ctx(),
-			imageContainer.imageName, imageContainer.skipCache, pullSecretDataList)
-		utils.HistogramObserve(now, metrics.TimeToInspectImage)
-		if err != nil {
-			log.V(1).Error(err, "Error inspecting the image", "imageName", imageContainer.imageName)
-			return nil, err
+	// The images are inspected in parallel, by at most maxParallelImageInspections workers.
+	imageContainers := imageNamesSet.UnsortedList()
+	imagesSupportedPlatforms := make([]sets.Set[image.Platform], len(imageContainers))
+	inferredFromNodes := make([]bool, len(imageContainers))
+	setByStaticRules := make([]bool, len(imageContainers))
+	inspectedDigests := make([]digest.Digest, len(imageContainers))
+	var architectureAgnosticRulesMutex sync.Mutex
+	architectureAgnosticRules := sets.New[string]()
+	// The entries of the manifest lists dropped by the deep validation, and the platforms dropped by the binary
+	// verification, are reported as events of the pod. So are the images matching an architecture-agnostic rule.
+	inspectionCtx := image.WithManifestListDiscrepancyHandler(pod.Ctx(), pod.publishManifestListDiscrepancy)
+	inspectionCtx = image.WithBinaryArchitectureMismatchHandler(inspectionCtx, pod.publishBinaryArchitectureMismatch)
+	inspectionCtx = image.WithArchitectureAgnosticRuleHandler(inspectionCtx, func(imageReference, rule string) {
+		pod.PublishEvent(corev1.EventTypeNormal, ArchitectureAgnosticImageMatched,
+			fmt.Sprintf("%s image: %s, rule: %s", ArchitectureAgnosticImageMatchMsg, imageReference, rule))
+		architectureAgnosticRulesMutex.Lock()
+		defer architectureAgnosticRulesMutex.Unlock()
+		architectureAgnosticRules.Insert(rule)
+	})
+	g, ctx := errgroup.WithContext(inspectionCtx)
+	g.SetLimit(maxParallelImageInspections)
+	for i, imageContainer := range imageContainers {
+		g.Go(func() error {
+			log.V(3).Info("Checking image", "imageName", imageContainer.imageName,
+				"skipCache (imagePullPolicy==Always)", imageContainer.skipCache)
+			if rule := matchStaticImageRule(staticRules, imageContainer.imageName); rule != nil {
+				log.V(3).Info("The image matches a static rule", "imageName", imageContainer.imageName,
+					"rule", rule.String())
+				pod.PublishEvent(corev1.EventTypeNormal, StaticImageArchitectureApplied,
+					fmt.Sprintf("%s image: %s, %s", StaticImageArchitectureMsg, imageContainer.imageName, rule))
+				imagesSupportedPlatforms[i], setByStaticRules[i] = rule.platforms(), true
+				return nil
+			}
+			// We are collecting the time to inspect the image here to avoid implementing a metric in each of the
+			// cache implementations.
+			now := time.Now()
+			// The bare names of the ImageStreams with local lookup are pulled from the internal registry.
+			imageName := localImageStreamReference(ctx, pod.Namespace, imageContainer.imageName)
+			// The digests of the images resolved by the ImageStreams are not recorded: they belong to the pull spec
+			// of the ImageStream rather than to the image of the pod.
+			digestCtx := ctx
+			if imageName == imageContainer.imageName {
+				digestCtx = image.WithInspectedDigestHandler(ctx, func(_ string, d digest.Digest) {
+					inspectedDigests[i] = d
+				})
+			}
+			currentImageSupportedPlatforms, err := imageInspectionCache.GetCompatiblePlatformsSet(digestCtx,
+				imageName, imageContainer.skipCache, pullSecretDataList)
+			utils.HistogramObserve(now, metrics.TimeToInspectImage)
+			if err != nil {
+				log.V(1).Error(err, "Error inspecting the image", "imageName", imageName)
+				if imageContainer.skipCache {
+					return err
+				}
+				// The images pulled with the IfNotPresent or Never pull policies can run on the nodes already holding
+				// them, even if no registry reachable from the controller serves them.
+				nodePlatforms, nodeErr := nodeImagePlatforms(ctx, imageName)
+				if nodeErr != nil || nodePlatforms.Len() == 0 {
+					return err
+				}
+				log.V(1).Info("Inferred the platforms of the image from the nodes holding it", "imageName", imageName,
+					"platforms", nodePlatforms)
+				pod.PublishEvent(corev1.EventTypeNormal, NodeImageInventoryUsed,
+					fmt.Sprintf("%s image: %s, platforms: %v", NodeImageInventoryUsedMsg, imageName,
+						image.SortedPlatforms(nodePlatforms)))
+				currentImageSupportedPlatforms, inferredFromNodes[i] = nodePlatforms, true
+			}
+			imagesSupportedPlatforms[i] = currentImageSupportedPlatforms
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	// The digests inspected bypassing the cache (imagePullPolicy: Always) take precedence.
+	pod.inspectedDigests = map[string]digest.Digest{}
+	for _, skipCache := range []bool{false, true} {
+		for i, d := range inspectedDigests {
+			if d != "" && imageContainers[i].skipCache == skipCache {
+				pod.inspectedDigests[imageContainers[i].imageName] = d
+			}
 		}
-		if supportedArchitecturesSet == nil {
-			supportedArchitecturesSet = currentImageSupportedArchitectures
-		} else {
-			supportedArchitecturesSet = supportedArchitecturesSet.Intersection(currentImageSupportedArchitectures)
+	}
+	if slices.Contains(inferredFromNodes, true) {
+		pod.EnsureLabel(utils.ArchitectureInferenceSourceLabel, utils.ArchitectureInferenceSourceNodeImages)
+	} else if slices.Contains(setByStaticRules, true) {
+		pod.EnsureLabel(utils.ArchitectureInferenceSourceLabel, utils.ArchitectureInferenceSourceStaticRule)
+	}
+	// The label records a single rule: the first one, in alphabetical order, if the images matched several rules.
+	if architectureAgnosticRules.Len() > 0 {
+		pod.EnsureLabel(utils.ArchitectureAgnosticRuleLabel, sets.List(architectureAgnosticRules)[0])
+	}
+	pod.publishImagePlatformsBySource(imageContainers, imagesSupportedPlatforms)
+	var requiredVariants map[image.Platform]string
+	for i, currentImageSupportedPlatforms := range imagesSupportedPlatforms {
+		if !imageContainers[i].constrainsPlacement(currentImageSupportedPlatforms) {
+			continue
+		}
+		currentImageVariants := pod.minimumVariants(currentImageSupportedPlatforms)
+		if requiredVariants == nil {
+			requiredVariants = currentImageVariants
+			continue
+		}
+		for platform, variant := range requiredVariants {
+			currentImageVariant, ok := currentImageVariants[platform]
+			if !ok {
+				delete(requiredVariants, platform)
+				continue
+			}
+			if image.CompareVariants(currentImageVariant, variant) > 0 {
+				requiredVariants[platform] = currentImageVariant
+			}
 		}
 	}
-	return sets.List(supportedArchitecturesSet), nil
+	platforms := sets.New[image.Platform]()
+	for platform, variant := range requiredVariants {
+		platform.Variant = variant
+		platforms.Insert(platform)
+	}
+	return platforms, nil
+}
+
+// publishImagePlatformsBySource publishes an event with the platforms of each of the given images and the containers
+// and volumes using it, so that users can see which image narrowed the set of platforms of the pod. The pods running
+// a single image get no such event: the platforms of the image are the ones of the pod.
+func (pod *Pod) publishImagePlatformsBySource(imageContainers []containerImage,
+	imagesSupportedPlatforms []sets.Set[image.Platform]) {
+	if len(imageContainers) < 2 {
+		return
+	}
+	sourcesOf := map[string][]string{}
+	for _, source := range pod.imageSources() {
+		sourcesOf[source.imageName] = append(sourcesOf[source.imageName], source.source)
+	}
+	for i, imageContainer := range imageContainers {
+		message := ImagePlatformsBySourceMsg
+		if !imageContainer.constrainsPlacement(imagesSupportedPlatforms[i]) {
+			message = ImageVolumeNotConstrainingMsg
+		}
+		pod.PublishEvent(corev1.EventTypeNormal, ImagePlatformsBySource,
+			fmt.Sprintf("%s image: %s, sources: [%s], platforms: %v", message, imageContainer.imageName,
+				strings.Join(sourcesOf[imageContainer.imageName], ", "),
+				image.SortedPlatforms(imagesSupportedPlatforms[i])))
+	}
+}
+
+// publishManifestListDiscrepancy publishes a warning event for an entry of a manifest list dropped by the deep
+// validation of the image inspection.
+func (pod *Pod) publishManifestListDiscrepancy(discrepancy image.ManifestListDiscrepancy) {
+	pod.PublishEvent(corev1.EventTypeWarning, ManifestListDiscrepancyFound,
+		fmt.Sprintf("%s image: %s, digest: %s, platform: %s, reason: %s, message: %s", ManifestListDiscrepancyMsg,
+			discrepancy.ImageReference, discrepancy.Digest, discrepancy.Platform, discrepancy.Reason,
+			discrepancy.Message))
+}
+
+// publishBinaryArchitectureMismatch publishes a warning event for a platform of an image dropped by the binary
+// verification of the image inspection.
+func (pod *Pod) publishBinaryArchitectureMismatch(mismatch image.BinaryArchitectureMismatch) {
+	pod.PublishEvent(corev1.EventTypeWarning, BinaryArchitectureMismatchFound,
+		fmt.Sprintf("%s image: %s, digest: %s, platform: %s, detected architectures: %s", BinaryArchitectureMismatchMsg,
+			mismatch.ImageReference, mismatch.Digest, mismatch.Platform,
+			strings.Join(mismatch.DetectedArchitectures, ", ")))
+}
+
+// minimumVariants maps the (os, architecture) pairs of the given platforms to the lowest CPU variant available for
+// them. The platforms of operating systems other than the one set in the pod's .spec.os are ignored.
+func (pod *Pod) minimumVariants(platforms sets.Set[image.Platform]) map[image.Platform]string {
+	variants := make(map[image.Platform]string, platforms.Len())
+	for platform := range platforms {
+		if pod.Spec.OS != nil && platform.OS != string(pod.Spec.OS.Name) {
+			continue
+		}
+		key := image.Platform{OS: platform.OS, Architecture: platform.Architecture}
+		variant, ok := variants[key]
+		if !ok || image.CompareVariants(platform.Variant, variant) < 0 {
+			variants[key] = platform.Variant
+		}
+	}
+	return variants
 }
 
 func (pod *Pod) maxRetries() bool {
@@ -416,7 +1006,7 @@ func (pod *Pod) ensureArchitectureLabels(requirement corev1.NodeSelectorRequirem
 	default:
 		pod.EnsureLabel(utils.MultiArchLabel, "")
 	}
-	validArchitectures := utils.AllSupportedArchitecturesSet()
+	validArchitectures := supportedArchitectures()
 	for _, value := range requirement.Values {
 		if validArchitectures.Has(value) {
 			pod.EnsureLabel(utils.ArchLabelValue(value), "")
@@ -499,17 +1089,50 @@ func (pod *Pod) handleError(err error, s string) {
 		return
 	}
 	log := ctrllog.FromContext(pod.Ctx())
-	metrics.FailedInspectionCounter.Inc()
+	class := image.ErrorClass(err)
+	metrics.FailedInspectionCounter.WithLabelValues(string(class)).Inc()
 	errMsg := err.Error()
 	runes := []rune(errMsg)
 	if len(runes) > 256 {
 		errMsg = string(runes[:256])
 	}
-	pod.EnsureLabel(utils.ImageInspectionErrorLabel, "")
+	pod.EnsureLabel(utils.ImageInspectionErrorLabel, string(class))
 	pod.EnsureAnnotation(utils.ImageInspectionErrorLabel, errMsg)
 	pod.EnsureAndIncrementLabel(utils.ImageInspectionErrorCountLabel)
-	pod.PublishEvent(corev1.EventTypeWarning, ImageArchitectureInspectionError, ImageArchitectureInspectionErrorMsg+errMsg)
-	log.Error(err, s)
+	switch {
+	case class.IsFailFast():
+		// Retrying cannot succeed until the image or the cluster configuration changes: give up immediately.
+		pod.EnsureLabel(utils.ImageInspectionErrorCountLabel, strconv.Itoa(MaxRetryCount))
+	case class.IsRateLimited():
+		pod.EnsureAnnotation(utils.ImageInspectionRetryAfterAnnotation,
+			time.Now().Add(pod.rateLimitBackoff()).UTC().Format(time.RFC3339))
+	}
+	pod.PublishEvent(corev1.EventTypeWarning, imageInspectionErrorReason(class), ImageArchitectureInspectionErrorMsg+errMsg)
+	log.Error(err, s, "class", class)
+}
+
+// rateLimitBackoff returns the time to wait before retrying the inspection of a pod whose images' registry
+// rate-limited the previous attempts. It doubles at each retry, starting from rateLimitBaseBackoff.
+func (pod *Pod) rateLimitBackoff() time.Duration {
+	retries, err := strconv.Atoi(pod.Labels[utils.ImageInspectionErrorCountLabel])
+	if err != nil || retries < 1 {
+		retries = 1
+	}
+	backoff := rateLimitBaseBackoff << min(retries-1, 5)
+	return min(backoff, rateLimitMaxBackoff)
+}
+
+// retryAfter returns the time to wait before the next inspection attempt, if the registry rate-limited the previous one.
+func (pod *Pod) retryAfter() time.Duration {
+	value, ok := pod.Annotations[utils.ImageInspectionRetryAfterAnnotation]
+	if !ok {
+		return 0
+	}
+	retryAfter, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0
+	}
+	return time.Until(retryAfter)
 }
 
 // isPreferredAffinityConfiguredForArchitecture returns true if the pod has a MatchExpression in the PreferredDuringSchedulingIgnoredDuringExecution
@@ -2,17 +2,22 @@ package podplacement
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 
 	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
 
 	"github.com/openshift/multiarch-tuning-operator/api/common"
 	"github.com/openshift/multiarch-tuning-operator/api/common/plugins"
@@ -213,6 +218,20 @@ func TestPod_imagesNamesSet(t *testing.T) {
 				containerImage{imageName: "//foo/pull:always", skipCache: true},
 			),
 		},
+		{
+			name: "pod with image volumes, one of them ignored and one of them sharing the image of a container",
+			pod: NewPod().WithContainersImages("bar/foo:latest").
+				WithImageVolume("models", "foo/models:v1", v1.PullIfNotPresent).
+				WithImageVolume("plugins", "foo/plugins:latest", v1.PullAlways).
+				WithImageVolume("tools", "bar/foo:latest", v1.PullIfNotPresent).
+				WithImageVolume("cache", "foo/cache:v1", v1.PullIfNotPresent).
+				WithAnnotations(map[string]string{utils.IgnoreImageVolumesAnnotation: "cache, other"}).Build(),
+			want: sets.New[containerImage](
+				containerImage{imageName: "//bar/foo:latest"},
+				containerImage{imageName: "//foo/models:v1", volume: true},
+				containerImage{imageName: "//foo/plugins:latest", skipCache: true, volume: true},
+			),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -286,6 +305,486 @@ func TestPod_intersectImagesArchitecture(t *testing.T) {
 	}
 }
 
+func TestPod_intersectImagesPlatformsWithNodeImageInventory(t *testing.T) {
+	const preloadedImage = "preloaded.example.com/tools/debug:v1"
+	tests := []struct {
+		name          string
+		pod           *v1.Pod
+		wantPlatforms sets.Set[mmoimage.Platform]
+		wantLabel     bool
+		wantErr       bool
+	}{
+		{
+			name:          "pre-loaded image pulled if not present",
+			pod:           NewPod().WithContainer(preloadedImage, v1.PullIfNotPresent).WithContainersImages(fake.MultiArchImage).Build(),
+			wantPlatforms: mmoimage.PlatformsOf(sets.New[string](utils.ArchitectureArm64)),
+			wantLabel:     true,
+		},
+		{
+			name:    "pre-loaded image always pulled",
+			pod:     NewPod().WithContainer(preloadedImage, v1.PullAlways).Build(),
+			wantErr: true,
+		},
+		{
+			name:    "image held by no node",
+			pod:     NewPod().WithContainer("non-existing-image", v1.PullNever).Build(),
+			wantErr: true,
+		},
+		{
+			name:          "inspected image",
+			pod:           NewPod().WithContainer(fake.SingleArchAmd64Image, v1.PullIfNotPresent).Build(),
+			wantPlatforms: mmoimage.PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+		},
+	}
+	metrics.InitPodPlacementControllerMetrics()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageInspectionCache = fake.FacadeSingleton()
+			nodeImagePlatforms = func(_ context.Context, imageReference string) (sets.Set[mmoimage.Platform], error) {
+				if imageReference == "//"+preloadedImage {
+					return mmoimage.PlatformsOf(sets.New[string](utils.ArchitectureArm64)), nil
+				}
+				return sets.New[mmoimage.Platform](), nil
+			}
+			t.Cleanup(func() {
+				imageInspectionCache = mmoimage.FacadeSingleton()
+				nodeImagePlatforms = mmoimage.FacadeSingleton().NodeImagePlatforms
+			})
+			pod := newPod(tt.pod, ctx, nil)
+			platforms, err := pod.intersectImagesPlatforms(nil, nil)
+			g := NewGomegaWithT(t)
+			if tt.wantErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(platforms).Should(Equal(tt.wantPlatforms))
+			if tt.wantLabel {
+				g.Expect(pod.Labels).Should(HaveKeyWithValue(utils.ArchitectureInferenceSourceLabel,
+					utils.ArchitectureInferenceSourceNodeImages))
+			} else {
+				g.Expect(pod.Labels).ShouldNot(HaveKey(utils.ArchitectureInferenceSourceLabel))
+			}
+		})
+	}
+}
+
+func TestPod_intersectImagesPlatformsWithStaticImageRules(t *testing.T) {
+	const applianceImage = "appliances.example.com/vendor/probe:v2"
+	staticRules := staticImageRules(&v1beta1.ClusterPodPlacementConfig{
+		Spec: v1beta1.ClusterPodPlacementConfigSpec{
+			StaticImageArchitectures: []v1beta1.StaticImageArchitecture{
+				{ImagePattern: "appliances.example.com/*/*", Skip: true},
+			},
+		},
+	}, []v1beta1.PodPlacementConfig{{
+		ObjectMeta: metav1.ObjectMeta{Name: "probes"},
+		Spec: v1beta1.PodPlacementConfigSpec{
+			StaticImageArchitectures: []v1beta1.StaticImageArchitecture{
+				{ImagePattern: "appliances.example.com/vendor/probe:*", Architectures: []string{utils.ArchitectureArm64}},
+			},
+		},
+	}})
+	tests := []struct {
+		name          string
+		pod           *v1.Pod
+		staticRules   []staticImageRule
+		wantPlatforms sets.Set[mmoimage.Platform]
+		wantLabel     bool
+		wantErr       bool
+	}{
+		{
+			name:          "image matching the rule of the PodPlacementConfig first",
+			pod:           NewPod().WithContainersImages(applianceImage, fake.MultiArchImage).Build(),
+			staticRules:   staticRules,
+			wantPlatforms: mmoimage.PlatformsOf(sets.New[string](utils.ArchitectureArm64)),
+			wantLabel:     true,
+		},
+		{
+			name: "image skipped by the rule of the ClusterPodPlacementConfig",
+			pod: NewPod().WithContainersImages("appliances.example.com/vendor/agent:v1",
+				fake.SingleArchAmd64Image).Build(),
+			staticRules:   staticRules,
+			wantPlatforms: mmoimage.PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+			wantLabel:     true,
+		},
+		{
+			name:    "image not matching any rule",
+			pod:     NewPod().WithContainersImages(applianceImage).Build(),
+			wantErr: true,
+		},
+		{
+			name:          "inspected images",
+			pod:           NewPod().WithContainersImages(fake.MultiArchImage).Build(),
+			staticRules:   staticRules,
+			wantPlatforms: mmoimage.PlatformsOf(sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64)),
+		},
+	}
+	metrics.InitPodPlacementControllerMetrics()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageInspectionCache = fake.FacadeSingleton()
+			t.Cleanup(func() { imageInspectionCache = mmoimage.FacadeSingleton() })
+			pod := newPod(tt.pod, ctx, nil)
+			platforms, err := pod.intersectImagesPlatforms(nil, tt.staticRules)
+			g := NewGomegaWithT(t)
+			if tt.wantErr {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(platforms).Should(Equal(tt.wantPlatforms))
+			if tt.wantLabel {
+				g.Expect(pod.Labels).Should(HaveKeyWithValue(utils.ArchitectureInferenceSourceLabel,
+					utils.ArchitectureInferenceSourceStaticRule))
+			} else {
+				g.Expect(pod.Labels).ShouldNot(HaveKey(utils.ArchitectureInferenceSourceLabel))
+			}
+		})
+	}
+}
+
+func TestPod_substituteImages(t *testing.T) {
+	substitutionRules := imageSubstitutionRules(&v1beta1.ClusterPodPlacementConfig{
+		Spec: v1beta1.ClusterPodPlacementConfigSpec{
+			Plugins: &plugins.Plugins{
+				ImageSubstitution: &plugins.ImageSubstitution{
+					BasePlugin: plugins.BasePlugin{Enabled: true},
+					Rules: []plugins.ImageSubstitutionRule{
+						{Image: fake.SingleArchAmd64Image, Substitute: fake.MultiArchImage},
+						{Image: fake.MultiArchImage, Substitute: fake.SingleArchArm64Image},
+						{Image: fake.MultiArchImage2, Substitute: "my-registry.io/library/missing-image:latest"},
+					},
+				},
+			},
+		},
+	}, []v1beta1.PodPlacementConfig{{
+		ObjectMeta: metav1.ObjectMeta{Name: "disabled"},
+		Spec: v1beta1.PodPlacementConfigSpec{
+			Plugins: &plugins.LocalPlugins{
+				ImageSubstitution: &plugins.ImageSubstitution{
+					Rules: []plugins.ImageSubstitutionRule{
+						{Image: fake.SingleArchAmd64Image, Substitute: fake.SingleArchArm64Image},
+					},
+				},
+			},
+		},
+	}})
+	tests := []struct {
+		name               string
+		pod                *v1.Pod
+		wantImages         []string
+		wantInitImages     []string
+		wantOriginalImages map[string]string
+		wantNoAnnotation   bool
+		wantEvent          string
+	}{
+		{
+			name: "substitute widening the placement",
+			pod: NewPod().WithContainersImages(fake.SingleArchAmd64Image).
+				WithInitContainersImages(fake.SingleArchAmd64Image).Build(),
+			wantImages:     []string{fake.MultiArchImage},
+			wantInitImages: []string{fake.MultiArchImage},
+			wantEvent:      ImageSubstituted,
+		},
+		{
+			name:             "substitute that cannot be inspected",
+			pod:              NewPod().WithContainersImages(fake.MultiArchImage2).Build(),
+			wantImages:       []string{fake.MultiArchImage2},
+			wantNoAnnotation: true,
+			wantEvent:        ImageSubstitutionSkipped,
+		},
+		{
+			name:             "substitute narrowing the placement",
+			pod:              NewPod().WithContainersImages(fake.MultiArchImage).Build(),
+			wantImages:       []string{fake.MultiArchImage},
+			wantNoAnnotation: true,
+		},
+		{
+			name:             "image not matching any rule",
+			pod:              NewPod().WithContainersImages(fake.SingleArchArm64Image).Build(),
+			wantImages:       []string{fake.SingleArchArm64Image},
+			wantNoAnnotation: true,
+		},
+	}
+	metrics.InitPodPlacementControllerMetrics()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageInspectionCache = fake.FacadeSingleton()
+			t.Cleanup(func() { imageInspectionCache = mmoimage.FacadeSingleton() })
+			recorder := record.NewFakeRecorder(10)
+			pod := newPod(tt.pod, ctx, recorder)
+			originalContainers := pod.DeepCopy().Spec.Containers
+			pod.substituteImages(nil, substitutionRules)
+			g := NewGomegaWithT(t)
+			if tt.wantEvent != "" {
+				g.Expect(recorder.Events).To(Receive(ContainSubstring(tt.wantEvent)))
+			}
+			for i, container := range pod.Spec.Containers {
+				g.Expect(container.Image).Should(Equal(tt.wantImages[i]))
+			}
+			for i, container := range pod.Spec.InitContainers {
+				g.Expect(container.Image).Should(Equal(tt.wantInitImages[i]))
+			}
+			if tt.wantNoAnnotation {
+				g.Expect(pod.Annotations).ShouldNot(HaveKey(utils.OriginalImagesAnnotation))
+				return
+			}
+			g.Expect(pod.containerImagesAnnotation(utils.OriginalImagesAnnotation)).Should(HaveKeyWithValue(originalContainers[0].Name,
+				originalContainers[0].Image))
+			// The containers already substituted are left untouched.
+			pod.Spec.Containers[0].Image = fake.SingleArchAmd64Image
+			pod.substituteImages(nil, substitutionRules)
+			g.Expect(pod.Spec.Containers[0].Image).Should(Equal(fake.SingleArchAmd64Image))
+		})
+	}
+}
+
+func TestPod_SetNodeAffinityArchRequirement_substitution(t *testing.T) {
+	substitutionRules := []imageSubstitutionRule{{ImageSubstitutionRule: plugins.ImageSubstitutionRule{
+		Image: fake.SingleArchAmd64Image, Substitute: fake.MultiArchImage}, source: v1beta1.ClusterPodPlacementConfigKind}}
+	tests := []struct {
+		name       string
+		pod        *v1.Pod
+		wantImage  string
+		wantEvents map[string]int
+	}{
+		{
+			name:      "pod with no architecture constraint",
+			pod:       NewPod().WithContainersImages(fake.SingleArchAmd64Image).WithAffinity(nil).Build(),
+			wantImage: fake.MultiArchImage,
+			wantEvents: map[string]int{
+				ImageSubstituted:               1,
+				ArchitecturePredicatesConflict: 0,
+			},
+		},
+		{
+			name: "pod whose node affinity already constrains the architecture",
+			pod: NewPod().WithContainersImages(fake.SingleArchAmd64Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64}},
+				},
+			).Build(),
+			wantImage: fake.SingleArchAmd64Image,
+			wantEvents: map[string]int{
+				ImageSubstituted:               0,
+				ArchitecturePredicatesConflict: 1,
+			},
+		},
+	}
+	metrics.InitPodPlacementControllerMetrics()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageInspectionCache = fake.FacadeSingleton()
+			t.Cleanup(func() { imageInspectionCache = mmoimage.FacadeSingleton() })
+			recorder := record.NewFakeRecorder(20)
+			pod := newPod(tt.pod, ctx, recorder)
+			_, err := pod.SetNodeAffinityArchRequirement(placementOptions{substitutionRules: substitutionRules})
+			g := NewGomegaWithT(t)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(pod.Spec.Containers[0].Image).Should(Equal(tt.wantImage))
+			events := map[string]int{}
+			for len(recorder.Events) > 0 {
+				fields := strings.Fields(<-recorder.Events)
+				events[fields[1]]++
+			}
+			for reason, count := range tt.wantEvents {
+				g.Expect(events[reason]).Should(Equal(count), "unexpected number of %s events", reason)
+			}
+		})
+	}
+}
+
+func Test_matchImageSubstitutionRule(t *testing.T) {
+	rules := []imageSubstitutionRule{
+		{ImageSubstitutionRule: plugins.ImageSubstitutionRule{Image: "foo:1.2", Substitute: "foo:1.2-arm64"}},
+		{ImageSubstitutionRule: plugins.ImageSubstitutionRule{Image: "quay.io/myorg/bar",
+			Substitute: "registry.example.com/myorg/bar"}},
+	}
+	tests := []struct {
+		imageReference string
+		want           string
+	}{
+		{imageReference: "docker.io/library/foo:1.2", want: "foo:1.2-arm64"},
+		{imageReference: "foo:1.3"},
+		{imageReference: "quay.io/myorg/bar:latest", want: "registry.example.com/myorg/bar"},
+		{imageReference: "Invalid:Name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.imageReference, func(t *testing.T) {
+			got := ""
+			if rule := matchImageSubstitutionRule(rules, tt.imageReference); rule != nil {
+				got = rule.Substitute
+			}
+			if got != tt.want {
+				t.Errorf("matchImageSubstitutionRule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPod_pinImageDigests(t *testing.T) {
+	const inspectedDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	const pinnedImage = "quay.io/myorg/foo@" + inspectedDigest
+	tests := []struct {
+		name             string
+		pod              *v1.Pod
+		inspectedDigests map[string]digest.Digest
+		wantImages       []string
+		wantInitImages   []string
+	}{
+		{
+			name: "inspected images",
+			pod: NewPod().WithContainersImages("quay.io/myorg/foo:1.2").
+				WithInitContainersImages("quay.io/myorg/foo:1.2").Build(),
+			inspectedDigests: map[string]digest.Digest{"//quay.io/myorg/foo:1.2": inspectedDigest},
+			wantImages:       []string{pinnedImage},
+			wantInitImages:   []string{pinnedImage},
+		},
+		{
+			name:             "familiar names",
+			pod:              NewPod().WithContainersImages("busybox").Build(),
+			inspectedDigests: map[string]digest.Digest{"//busybox": inspectedDigest},
+			wantImages:       []string{"busybox@" + inspectedDigest},
+		},
+		{
+			name:             "images not inspected",
+			pod:              NewPod().WithContainersImages("quay.io/myorg/bar:1.0", "quay.io/myorg/foo:1.2").Build(),
+			inspectedDigests: map[string]digest.Digest{"//quay.io/myorg/foo:1.2": inspectedDigest},
+			wantImages:       []string{"quay.io/myorg/bar:1.0", pinnedImage},
+		},
+		{
+			name: "images referenced by digest",
+			pod:  NewPod().WithContainersImages("quay.io/myorg/foo:1.2@" + inspectedDigest).Build(),
+			inspectedDigests: map[string]digest.Digest{
+				"//quay.io/myorg/foo:1.2@" + inspectedDigest: inspectedDigest,
+			},
+			wantImages: []string{"quay.io/myorg/foo:1.2@" + inspectedDigest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := newPod(tt.pod, ctx, nil)
+			originalPod := pod.DeepCopy()
+			pod.inspectedDigests = tt.inspectedDigests
+			pod.pinImageDigests()
+			g := NewGomegaWithT(t)
+			pinnedImages := pod.containerImagesAnnotation(utils.PinnedImagesAnnotation)
+			for i, container := range pod.Spec.Containers {
+				g.Expect(container.Image).Should(Equal(tt.wantImages[i]))
+				if container.Image != originalPod.Spec.Containers[i].Image {
+					g.Expect(pinnedImages).Should(HaveKeyWithValue(container.Name, originalPod.Spec.Containers[i].Image))
+				} else {
+					g.Expect(pinnedImages).ShouldNot(HaveKey(container.Name))
+				}
+			}
+			for i, container := range pod.Spec.InitContainers {
+				g.Expect(container.Image).Should(Equal(tt.wantInitImages[i]))
+			}
+		})
+	}
+}
+
+func Test_digestPinningEnabled(t *testing.T) {
+	ppc := func(name string, priority uint8, mode v1beta1.DigestPinningMode) v1beta1.PodPlacementConfig {
+		return v1beta1.PodPlacementConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1beta1.PodPlacementConfigSpec{Priority: priority, DigestPinning: mode},
+		}
+	}
+	enabledCPPC := &v1beta1.ClusterPodPlacementConfig{
+		Spec: v1beta1.ClusterPodPlacementConfigSpec{DigestPinning: v1beta1.DigestPinningModeEnabled},
+	}
+	tests := []struct {
+		name         string
+		cppc         *v1beta1.ClusterPodPlacementConfig
+		matchingPPCs []v1beta1.PodPlacementConfig
+		want         bool
+	}{
+		{
+			name: "no ClusterPodPlacementConfig",
+		},
+		{
+			name: "enabled by the ClusterPodPlacementConfig",
+			cppc: enabledCPPC,
+			want: true,
+		},
+		{
+			name:         "inherited by the PodPlacementConfigs not setting the mode",
+			cppc:         enabledCPPC,
+			matchingPPCs: []v1beta1.PodPlacementConfig{ppc("default", 10, "")},
+			want:         true,
+		},
+		{
+			name: "overridden by the PodPlacementConfig with the highest priority",
+			cppc: enabledCPPC,
+			matchingPPCs: []v1beta1.PodPlacementConfig{
+				ppc("low", 1, v1beta1.DigestPinningModeEnabled),
+				ppc("high", 200, v1beta1.DigestPinningModeDisabled),
+				ppc("unset", 255, ""),
+			},
+		},
+		{
+			name:         "enabled by a PodPlacementConfig",
+			matchingPPCs: []v1beta1.PodPlacementConfig{ppc("pinned", 0, v1beta1.DigestPinningModeEnabled)},
+			want:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := digestPinningEnabled(tt.cppc, tt.matchingPPCs); got != tt.want {
+				t.Errorf("digestPinningEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPod_intersectImagesPlatformsWithImageVolumes(t *testing.T) {
+	tests := []struct {
+		name          string
+		pod           *v1.Pod
+		wantPlatforms sets.Set[mmoimage.Platform]
+	}{
+		{
+			name: "image volume narrowing the platforms",
+			pod: NewPod().WithContainersImages(fake.MultiArchImage2).
+				WithImageVolume("plugins", fake.MultiArchImage, v1.PullIfNotPresent).Build(),
+			wantPlatforms: mmoimage.PlatformsOf(sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64)),
+		},
+		{
+			name: "ignored image volume",
+			pod: NewPod().WithContainersImages(fake.MultiArchImage2).
+				WithImageVolume("plugins", fake.SingleArchArm64Image, v1.PullIfNotPresent).
+				WithAnnotations(map[string]string{utils.IgnoreImageVolumesAnnotation: "plugins"}).Build(),
+			wantPlatforms: mmoimage.PlatformsOf(sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64,
+				utils.ArchitecturePpc64le, utils.ArchitectureS390x)),
+		},
+	}
+	metrics.InitPodPlacementControllerMetrics()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imageInspectionCache = fake.FacadeSingleton()
+			t.Cleanup(func() { imageInspectionCache = mmoimage.FacadeSingleton() })
+			pod := newPod(tt.pod, ctx, nil)
+			platforms, err := pod.intersectImagesPlatforms(nil, nil)
+			g := NewGomegaWithT(t)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(platforms).Should(Equal(tt.wantPlatforms))
+		})
+	}
+}
+
+func Test_containerImage_constrainsPlacement(t *testing.T) {
+	wasm := sets.New[mmoimage.Platform](mmoimage.WasmPlatform)
+	linux := mmoimage.PlatformsOf(sets.New[string](utils.ArchitectureAmd64))
+	g := NewGomegaWithT(t)
+	g.Expect(containerImage{}.constrainsPlacement(sets.New[mmoimage.Platform]())).To(BeTrue())
+	g.Expect(containerImage{}.constrainsPlacement(wasm)).To(BeTrue())
+	g.Expect(containerImage{volume: true}.constrainsPlacement(linux)).To(BeTrue())
+	g.Expect(containerImage{volume: true}.constrainsPlacement(wasm)).To(BeFalse())
+	g.Expect(containerImage{volume: true}.constrainsPlacement(sets.New[mmoimage.Platform]())).To(BeFalse())
+}
+
 func TestPod_getArchitecturePredicate(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -686,11 +1185,14 @@ func TestPod_setRequiredNodeAffinityToFallbackArchitecture(t *testing.T) {
 
 func TestPod_SetNodeAffinityArchRequirement(t *testing.T) {
 	tests := []struct {
-		name               string
-		pullSecretDataList [][]byte
-		pod                *v1.Pod
-		want               *v1.Pod
-		expectErr          bool
+		name                 string
+		pullSecretDataList   [][]byte
+		platformVariants     []v1beta1.PlatformVariant
+		runtimeClassMappings []v1beta1.RuntimeClassMapping
+		emulation            *plugins.Emulation
+		pod                  *v1.Pod
+		want                 *v1.Pod
+		expectErr            bool
 	}{
 		{
 			name: "pod with no node selector terms",
@@ -862,13 +1364,380 @@ func TestPod_SetNodeAffinityArchRequirement(t *testing.T) {
 					},
 				}).Build(),
 		},
+		{
+			name:             "pod with an image requiring the x86-64-v3 variant on amd64",
+			platformVariants: v1beta1.DefaultPlatformVariants(),
+			pod:              NewPod().WithContainersImages(fake.MultiArchAmd64V3Image).Build(),
+			want: NewPod().WithContainersImages(fake.MultiArchAmd64V3Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{
+						Key:      utils.ArchLabel,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{utils.ArchitectureArm64},
+					},
+				},
+				[]v1.NodeSelectorRequirement{
+					{
+						Key:      utils.ArchLabel,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{utils.ArchitectureAmd64},
+					},
+					{
+						Key:      "feature.node.kubernetes.io/cpu-cpuid.SSE42",
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{"true"},
+					},
+					{
+						Key:      "feature.node.kubernetes.io/cpu-cpuid.POPCNT",
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{"true"},
+					},
+					{
+						Key:      "feature.node.kubernetes.io/cpu-cpuid.AVX2",
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{"true"},
+					},
+				},
+			).Build(),
+		},
+		{
+			name:             "pod with images requiring the baseline and the x86-64-v3 variants on amd64",
+			platformVariants: v1beta1.DefaultPlatformVariants(),
+			pod:              NewPod().WithContainersImages(fake.MultiArchAmd64V3Image, fake.SingleArchAmd64Image).Build(),
+			want: NewPod().WithContainersImages(fake.MultiArchAmd64V3Image, fake.SingleArchAmd64Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{
+						Key:      utils.ArchLabel,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{utils.ArchitectureAmd64},
+					},
+					{
+						Key:      "feature.node.kubernetes.io/cpu-cpuid.SSE42",
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{"true"},
+					},
+					{
+						Key:      "feature.node.kubernetes.io/cpu-cpuid.POPCNT",
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{"true"},
+					},
+					{
+						Key:      "feature.node.kubernetes.io/cpu-cpuid.AVX2",
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{"true"},
+					},
+				},
+			).Build(),
+		},
+		{
+			name: "pod with an image requiring the x86-64-v3 variant and a custom label map",
+			platformVariants: []v1beta1.PlatformVariant{
+				{
+					Architecture: utils.ArchitectureAmd64,
+					Variant:      "v2",
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: "example.com/x86-64-v2", Operator: v1.NodeSelectorOpExists},
+					},
+				},
+				{
+					Architecture: utils.ArchitectureAmd64,
+					Variant:      "v3",
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: "example.com/x86-64-v3", Operator: v1.NodeSelectorOpExists},
+					},
+				},
+				{
+					Architecture: utils.ArchitectureAmd64,
+					Variant:      "v4",
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: "example.com/x86-64-v4", Operator: v1.NodeSelectorOpExists},
+					},
+				},
+			},
+			pod: NewPod().WithContainersImages(fake.MultiArchAmd64V3Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: "foo", Operator: v1.NodeSelectorOpExists},
+				}).Build(),
+			want: NewPod().WithContainersImages(fake.MultiArchAmd64V3Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: "foo", Operator: v1.NodeSelectorOpExists},
+					{
+						Key:      utils.ArchLabel,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{utils.ArchitectureArm64},
+					},
+				},
+				[]v1.NodeSelectorRequirement{
+					{Key: "foo", Operator: v1.NodeSelectorOpExists},
+					{
+						Key:      utils.ArchLabel,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{utils.ArchitectureAmd64},
+					},
+					{Key: "example.com/x86-64-v2", Operator: v1.NodeSelectorOpExists},
+					{Key: "example.com/x86-64-v3", Operator: v1.NodeSelectorOpExists},
+				},
+			).Build(),
+		},
+		{
+			name: "pod with an image requiring the x86-64-v3 variant and no matching label map entries",
+			platformVariants: []v1beta1.PlatformVariant{
+				{
+					Architecture: utils.ArchitectureAmd64,
+					Variant:      "v4",
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: "example.com/x86-64-v4", Operator: v1.NodeSelectorOpExists},
+					},
+				},
+			},
+			pod: NewPod().WithContainersImages(fake.MultiArchAmd64V3Image).Build(),
+			want: NewPod().WithContainersImages(fake.MultiArchAmd64V3Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{
+						Key:      utils.ArchLabel,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{utils.ArchitectureAmd64, utils.ArchitectureArm64},
+					},
+				},
+			).Build(),
+		},
+		{
+			name: "pod with a mixed-OS image and no OS set",
+			pod:  NewPod().WithContainersImages(fake.MixedOSImage).Build(),
+			want: NewPod().WithContainersImages(fake.MixedOSImage).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureArm64}},
+					{Key: utils.OSLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.OSLinux}},
+				},
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64}},
+					{Key: utils.OSLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.OSWindows}},
+				},
+			).Build(),
+		},
+		{
+			name: "linux pod with a mixed-OS image",
+			pod:  NewPod().WithContainersImages(fake.MixedOSImage).WithOS(v1.Linux).Build(),
+			want: NewPod().WithContainersImages(fake.MixedOSImage).WithOS(v1.Linux).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureArm64}},
+				},
+			).Build(),
+		},
+		{
+			name: "windows pod with a multi-OS image",
+			pod:  NewPod().WithContainersImages(fake.MultiOSImage).WithOS(v1.Windows).Build(),
+			want: NewPod().WithContainersImages(fake.MultiOSImage).WithOS(v1.Windows).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64}},
+					{Key: utils.OSLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.OSWindows}},
+				},
+			).Build(),
+		},
+		{
+			name: "pod with a multi-OS image and a linux-only image",
+			pod:  NewPod().WithContainersImages(fake.MultiOSImage, fake.MultiArchImage).Build(),
+			want: NewPod().WithContainersImages(fake.MultiOSImage, fake.MultiArchImage).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64, utils.ArchitectureArm64}},
+				},
+			).Build(),
+		},
+		{
+			name: "windows pod with a linux-only image",
+			pod:  NewPod().WithContainersImages(fake.MultiArchImage).WithOS(v1.Windows).Build(),
+			want: NewPod().WithContainersImages(fake.MultiArchImage).WithOS(v1.Windows).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.NoSupportedArchLabel, Operator: v1.NodeSelectorOpExists},
+				},
+			).Build(),
+		},
+		{
+			name: "pod with a wasm image mapped to a RuntimeClass",
+			runtimeClassMappings: []v1beta1.RuntimeClassMapping{{
+				Platform:         "wasi/wasm",
+				RuntimeClassName: "wasmtime",
+				MatchExpressions: []v1.NodeSelectorRequirement{
+					{Key: "runtime.example.com/wasmtime", Operator: v1.NodeSelectorOpExists},
+				},
+			}},
+			pod: NewPod().WithContainersImages(fake.WasmImage).Build(),
+			want: NewPod().WithContainersImages(fake.WasmImage).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: "runtime.example.com/wasmtime", Operator: v1.NodeSelectorOpExists},
+				},
+			).Build(),
+		},
+		{
+			name: "pod with a wasm image and a mapping without requirements",
+			runtimeClassMappings: []v1beta1.RuntimeClassMapping{
+				{Platform: "wasi/wasm", RuntimeClassName: "wasmtime"},
+			},
+			pod:  NewPod().WithContainersImages(fake.WasmImage).WithAffinity(nil).Build(),
+			want: NewPod().WithContainersImages(fake.WasmImage).WithAffinity(nil).Build(),
+		},
+		{
+			name: "pod with a wasm image and a linux image mapped to a RuntimeClass",
+			runtimeClassMappings: []v1beta1.RuntimeClassMapping{
+				{Platform: "wasi/wasm", RuntimeClassName: "wasmtime"},
+			},
+			pod: NewPod().WithContainersImages(fake.WasmImage, fake.MultiArchImage).Build(),
+			want: NewPod().WithContainersImages(fake.WasmImage, fake.MultiArchImage).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.NoSupportedArchLabel, Operator: v1.NodeSelectorOpExists},
+				},
+			).Build(),
+		},
+		{
+			name: "pod with a single-arch image and emulating nodes",
+			emulation: &plugins.Emulation{
+				BasePlugin: plugins.BasePlugin{Enabled: true},
+				Nodes: []plugins.EmulatingNodes{
+					{
+						MatchExpressions: []v1.NodeSelectorRequirement{
+							{Key: "qemu.example.com/amd64", Operator: v1.NodeSelectorOpExists},
+						},
+						Architectures: []string{utils.ArchitectureAmd64},
+					},
+					{
+						MatchExpressions: []v1.NodeSelectorRequirement{
+							{Key: "qemu.example.com/riscv64", Operator: v1.NodeSelectorOpExists},
+						},
+						Architectures: []string{"riscv64"},
+					},
+				},
+			},
+			pod: NewPod().WithContainersImages(fake.SingleArchAmd64Image).WithNodeSelectors("foo", "bar").Build(),
+			want: NewPod().WithContainersImages(fake.SingleArchAmd64Image).WithNodeSelectors(
+				"foo", "bar").WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64}},
+				},
+				[]v1.NodeSelectorRequirement{
+					{Key: "qemu.example.com/amd64", Operator: v1.NodeSelectorOpExists},
+					{Key: utils.OSLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.OSLinux}},
+				},
+			).WithPreferredDuringSchedulingIgnoredDuringExecution(&v1.PreferredSchedulingTerm{
+				Weight: plugins.DefaultEmulationNativeWeight,
+				Preference: v1.NodeSelectorTerm{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64}},
+					},
+				},
+			}).Build(),
+		},
+		{
+			name: "pod with a single-arch image and no node emulating its architecture",
+			emulation: &plugins.Emulation{
+				BasePlugin: plugins.BasePlugin{Enabled: true},
+				Nodes: []plugins.EmulatingNodes{{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: "qemu.example.com/riscv64", Operator: v1.NodeSelectorOpExists},
+					},
+					Architectures: []string{"riscv64"},
+				}},
+			},
+			pod: NewPod().WithContainersImages(fake.SingleArchAmd64Image).WithAffinity(nil).Build(),
+			want: NewPod().WithContainersImages(fake.SingleArchAmd64Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64}},
+				},
+			).Build(),
+		},
+		{
+			name: "pod with user terms, one of which constrains the architecture, and emulating nodes",
+			emulation: &plugins.Emulation{
+				BasePlugin:   plugins.BasePlugin{Enabled: true},
+				NativeWeight: 5,
+				Nodes: []plugins.EmulatingNodes{{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: "qemu.example.com/amd64", Operator: v1.NodeSelectorOpExists},
+					},
+					Architectures: []string{utils.ArchitectureAmd64},
+				}},
+			},
+			pod: NewPod().WithContainersImages(fake.SingleArchAmd64Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{{Key: "foo", Operator: v1.NodeSelectorOpExists}},
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureArm64}},
+				},
+			).Build(),
+			want: NewPod().WithContainersImages(fake.SingleArchAmd64Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: "foo", Operator: v1.NodeSelectorOpExists},
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64}},
+				},
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureArm64}},
+				},
+				[]v1.NodeSelectorRequirement{
+					{Key: "foo", Operator: v1.NodeSelectorOpExists},
+					{Key: "qemu.example.com/amd64", Operator: v1.NodeSelectorOpExists},
+					{Key: utils.OSLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.OSLinux}},
+				},
+			).WithPreferredDuringSchedulingIgnoredDuringExecution(&v1.PreferredSchedulingTerm{
+				Weight: 5,
+				Preference: v1.NodeSelectorTerm{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64}},
+					},
+				},
+			}).Build(),
+		},
+		{
+			name: "pod with an image requiring the x86-64-v3 variant on amd64 and emulating nodes",
+			platformVariants: []v1beta1.PlatformVariant{{
+				Architecture: utils.ArchitectureAmd64,
+				Variant:      "v3",
+				MatchExpressions: []v1.NodeSelectorRequirement{
+					{Key: "example.com/x86-64-v3", Operator: v1.NodeSelectorOpExists},
+				},
+			}},
+			emulation: &plugins.Emulation{
+				BasePlugin: plugins.BasePlugin{Enabled: true},
+				Nodes: []plugins.EmulatingNodes{{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: "qemu.example.com/amd64", Operator: v1.NodeSelectorOpExists},
+					},
+					Architectures: []string{utils.ArchitectureAmd64},
+				}},
+			},
+			pod: NewPod().WithContainersImages(fake.MultiArchAmd64V3Image).Build(),
+			want: NewPod().WithContainersImages(fake.MultiArchAmd64V3Image).WithNodeSelectorTermsMatchExpressions(
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureArm64}},
+				},
+				[]v1.NodeSelectorRequirement{
+					{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.ArchitectureAmd64}},
+					{Key: "example.com/x86-64-v3", Operator: v1.NodeSelectorOpExists},
+				},
+				[]v1.NodeSelectorRequirement{
+					{Key: "qemu.example.com/amd64", Operator: v1.NodeSelectorOpExists},
+					{Key: utils.OSLabel, Operator: v1.NodeSelectorOpIn, Values: []string{utils.OSLinux}},
+					{Key: "example.com/x86-64-v3", Operator: v1.NodeSelectorOpExists},
+				},
+			).WithPreferredDuringSchedulingIgnoredDuringExecution(&v1.PreferredSchedulingTerm{
+				Weight: plugins.DefaultEmulationNativeWeight,
+				Preference: v1.NodeSelectorTerm{
+					MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: utils.ArchLabel, Operator: v1.NodeSelectorOpIn,
+							Values: []string{utils.ArchitectureAmd64, utils.ArchitectureArm64}},
+					},
+				},
+			}).Build(),
+		},
 	}
 	metrics.InitPodPlacementControllerMetrics()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			imageInspectionCache = fake.FacadeSingleton()
 			pod := newPod(tt.pod, ctx, nil)
-			_, err := pod.SetNodeAffinityArchRequirement(tt.pullSecretDataList)
+			_, err := pod.SetNodeAffinityArchRequirement(placementOptions{
+				pullSecretDataList:   tt.pullSecretDataList,
+				platformVariants:     tt.platformVariants,
+				runtimeClassMappings: tt.runtimeClassMappings,
+				emulation:            tt.emulation,
+			})
 			g := NewGomegaWithT(t)
 			if tt.expectErr {
 				g.Expect(err).Should(HaveOccurred())
@@ -881,6 +1750,54 @@ func TestPod_SetNodeAffinityArchRequirement(t *testing.T) {
 	}
 }
 
+func TestPod_applyRuntimeClass(t *testing.T) {
+	runtimeClass := &nodev1.RuntimeClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "wasmtime"},
+		Handler:    "wasmtime",
+		Overhead:   &nodev1.Overhead{PodFixed: v1.ResourceList{v1.ResourceMemory: resource.MustParse("32Mi")}},
+		Scheduling: &nodev1.Scheduling{
+			NodeSelector: map[string]string{"runtime.example.com/wasmtime": "true"},
+			Tolerations:  []v1.Toleration{{Key: "runtime.example.com/wasm", Operator: v1.TolerationOpExists}},
+		},
+	}
+	tests := []struct {
+		name  string
+		pod   *v1.Pod
+		apply bool
+	}{
+		{
+			name:  "pod without scheduling constraints",
+			pod:   NewPod().WithContainersImages(fake.WasmImage).Build(),
+			apply: true,
+		},
+		{
+			name:  "pod with a compatible node selector",
+			pod:   NewPod().WithContainersImages(fake.WasmImage).WithNodeSelectors("runtime.example.com/wasmtime", "true").Build(),
+			apply: true,
+		},
+		{
+			name: "pod with a conflicting node selector",
+			pod:  NewPod().WithContainersImages(fake.WasmImage).WithNodeSelectors("runtime.example.com/wasmtime", "false").Build(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			pod := newPod(tt.pod, ctx, nil)
+			g.Expect(pod.applyRuntimeClass(runtimeClass)).To(Equal(tt.apply))
+			if !tt.apply {
+				g.Expect(pod.Spec.RuntimeClassName).To(BeNil())
+				g.Expect(pod.Spec.Overhead).To(BeNil())
+				return
+			}
+			g.Expect(pod.Spec.RuntimeClassName).To(HaveValue(Equal("wasmtime")))
+			g.Expect(pod.Spec.Overhead).To(Equal(runtimeClass.Overhead.PodFixed))
+			g.Expect(pod.Spec.NodeSelector).To(HaveKeyWithValue("runtime.example.com/wasmtime", "true"))
+			g.Expect(pod.Spec.Tolerations).To(Equal(runtimeClass.Scheduling.Tolerations))
+		})
+	}
+}
+
 // TestEnsureArchitectureLabels checks the ensureArchitectureLabels method to ensure it sets the correct labels based on NodeSelectorRequirement.
 func TestEnsureArchitectureLabels(t *testing.T) {
 	tests := []struct {
@@ -2158,3 +3075,86 @@ func TestPod_trackAffinitySource_AnnotationGrowth(t *testing.T) {
 		})
 	}
 }
+
+func TestPod_handleError(t *testing.T) {
+	metrics.InitPodPlacementControllerMetrics()
+	tests := []struct {
+		name               string
+		err                error
+		expectedClass      string
+		expectedMaxRetries bool
+		expectedRetryAfter bool
+	}{
+		{
+			name:               "not found errors fail fast",
+			err:                &mmoimage.InspectionError{Class: common.ImageInspectionErrorNotFound, Err: errors.New("manifest unknown")},
+			expectedClass:      string(common.ImageInspectionErrorNotFound),
+			expectedMaxRetries: true,
+		},
+		{
+			name:               "policy rejections fail fast",
+			err:                &mmoimage.InspectionError{Class: common.ImageInspectionErrorPolicyRejected, Err: errors.New("rejected")},
+			expectedClass:      string(common.ImageInspectionErrorPolicyRejected),
+			expectedMaxRetries: true,
+		},
+		{
+			name:               "rate limited errors back off",
+			err:                &mmoimage.InspectionError{Class: common.ImageInspectionErrorRateLimited, Err: errors.New("too many requests")},
+			expectedClass:      string(common.ImageInspectionErrorRateLimited),
+			expectedRetryAfter: true,
+		},
+		{
+			name:          "unreachable registries are retried",
+			err:           &mmoimage.InspectionError{Class: common.ImageInspectionErrorUnreachable, Err: errors.New("connection refused")},
+			expectedClass: string(common.ImageInspectionErrorUnreachable),
+		},
+		{
+			name:          "unclassified errors are unknown",
+			err:           errors.New("generic error"),
+			expectedClass: string(common.ImageInspectionErrorUnknown),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			recorder := record.NewFakeRecorder(1)
+			pod := newPod(NewPod().Build(), ctx, recorder)
+			pod.handleError(tt.err, "inspection failed")
+			g.Expect(pod.Labels).To(HaveKeyWithValue(utils.ImageInspectionErrorLabel, tt.expectedClass))
+			g.Expect(pod.maxRetries()).To(Equal(tt.expectedMaxRetries))
+			g.Expect(pod.retryAfter() > 0).To(Equal(tt.expectedRetryAfter))
+			g.Expect(<-recorder.Events).To(ContainSubstring(ImageArchitectureInspectionError + tt.expectedClass))
+		})
+	}
+}
+
+func TestPod_rateLimitBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		retries  string
+		expected time.Duration
+	}{
+		{
+			name:     "first retry",
+			retries:  "1",
+			expected: rateLimitBaseBackoff,
+		},
+		{
+			name:     "third retry",
+			retries:  "3",
+			expected: 4 * rateLimitBaseBackoff,
+		},
+		{
+			name:     "backoff is capped",
+			retries:  "10",
+			expected: rateLimitMaxBackoff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			pod := newPod(NewPod().WithLabels(utils.ImageInspectionErrorCountLabel, tt.retries).Build(), ctx, nil)
+			g.Expect(pod.rateLimitBackoff()).To(Equal(tt.expected))
+		})
+	}
+}
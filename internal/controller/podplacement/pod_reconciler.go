@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	runtime2 "runtime"
+	"slices"
 	"sort"
 	"time"
 
@@ -39,6 +40,7 @@ import (
 	"github.com/openshift/multiarch-tuning-operator/api/common"
 	multiarchv1beta1 "github.com/openshift/multiarch-tuning-operator/api/v1beta1"
 	"github.com/openshift/multiarch-tuning-operator/internal/controller/podplacement/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
 	"github.com/openshift/multiarch-tuning-operator/pkg/informers/clusterpodplacementconfig"
 	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
 )
@@ -85,6 +87,11 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		log.V(2).Info("Pod does not have the scheduling gate. Ignoring...")
 		return ctrl.Result{}, nil
 	}
+	if retryAfter := pod.retryAfter(); retryAfter > 0 {
+		// The registry rate-limited the previous inspection: back off before retrying.
+		log.V(2).Info("Backing off the image inspection of the pod", "retryAfter", retryAfter)
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
 	metrics.ProcessedPodsCtrl.Inc()
 	defer utils.HistogramObserve(now, metrics.TimeToProcessGatedPod)
 	r.processPod(ctx, pod)
@@ -154,12 +161,24 @@ func (r *PodReconciler) processPod(ctx context.Context, pod *Pod) {
 	}
 
 	// Prepare the requirement for the node affinity.
-	psdl, err := r.pullSecretDataList(ctx, pod)
+	psdl, err := r.pullSecretDataList(ctx, pod, matchingPPCs)
 	pod.handleError(err, "Unable to retrieve the image pull secret data for the pod.")
 	// If no error occurred when retrieving the image pull secret data, set the node affinity.
 	if err == nil {
-		_, err = pod.SetNodeAffinityArchRequirement(psdl)
+		_, err = pod.SetNodeAffinityArchRequirement(placementOptions{
+			pullSecretDataList:   psdl,
+			substitutionRules:    imageSubstitutionRules(cppc, matchingPPCs),
+			staticRules:          staticImageRules(cppc, matchingPPCs),
+			platformVariants:     cppc.PlatformVariantsOrDefault(),
+			runtimeClassMappings: cppc.RuntimeClassMappings(),
+			emulation:            cppc.Emulation(),
+		})
 		pod.handleError(err, "Unable to set the node affinity for the pod.")
+		// The images are pinned to the digests their platforms were computed for, so that the node affinity and the
+		// images pulled by the kubelet agree.
+		if err == nil && digestPinningEnabled(cppc, matchingPPCs) {
+			pod.pinImageDigests()
+		}
 	}
 	if pod.maxRetries() && err != nil {
 		// the number of retries is incremented in the handleError function when the error is not nil.
@@ -168,11 +187,14 @@ func (r *PodReconciler) processPod(ctx context.Context, pod *Pod) {
 		// the counter is equal to the maxRetries value and the pod should not be processed again.
 		// Publish this event and remove the scheduling gate.
 		log.Info("Max retries Reached. The pod will not have the nodeAffinity set.")
-		pod.PublishEvent(corev1.EventTypeWarning, ImageArchitectureInspectionError, fmt.Sprintf("%s: %s", ImageInspectionErrorMaxRetriesMsg, err.Error()))
-
-		if cppc != nil && cppc.Spec.FallbackArchitecture != "" {
-			log.Info("Setting the nodeAffinity to the fallback architecture", "fallbackArchitecture", cppc.Spec.FallbackArchitecture)
-			pod.setRequiredNodeAffinityToFallbackArchitecture(cppc.Spec.FallbackArchitecture)
+		class := image.ErrorClass(err)
+		pod.PublishEvent(corev1.EventTypeWarning, imageInspectionErrorReason(class), fmt.Sprintf("%s: %s", ImageInspectionErrorMaxRetriesMsg, err.Error()))
+
+		// The fallback architecture only applies to the error classes selected by the administrator.
+		if fallbackArchitecture := cppc.FallbackArchitectureFor(class); fallbackArchitecture != "" {
+			log.Info("Setting the nodeAffinity to the fallback architecture", "fallbackArchitecture", fallbackArchitecture,
+				"class", class)
+			pod.setRequiredNodeAffinityToFallbackArchitecture(fallbackArchitecture)
 		}
 	}
 	// If the pod has been processed successfully or the max retries have been reached, remove the scheduling gate.
@@ -189,6 +211,16 @@ func (r *PodReconciler) processPod(ctx context.Context, pod *Pod) {
 	}
 }
 
+// sortedByPriority returns a copy of the given PodPlacementConfigs sorted by descending priority. The order of the
+// PodPlacementConfigs with the same priority is preserved.
+func sortedByPriority(matchingPPCs []multiarchv1beta1.PodPlacementConfig) []multiarchv1beta1.PodPlacementConfig {
+	ppcs := slices.Clone(matchingPPCs)
+	sort.SliceStable(ppcs, func(i, j int) bool {
+		return ppcs[i].Spec.Priority > ppcs[j].Spec.Priority
+	})
+	return ppcs
+}
+
 // applyMatchingPPCs applies the pre-filtered matching PodPlacementConfigs to the pod.
 // The matchingPPCs slice should already be filtered to only include PPCs whose label selector matches the pod.
 func (r *PodReconciler) applyMatchingPPCs(ctx context.Context, matchingPPCs []multiarchv1beta1.PodPlacementConfig, pod *Pod) {
@@ -245,13 +277,30 @@ func (r *PodReconciler) trackSkippedMatchingConfigs(ctx context.Context, pod *Po
 	}
 }
 
-// pullSecretDataList returns the list of secrets data for the given pod given its imagePullSecrets field
-func (r *PodReconciler) pullSecretDataList(ctx context.Context, pod *Pod) ([][]byte, error) {
+// pullSecretDataList returns the list of secrets data for the given pod: the ones of the inspectionSecrets of the
+// matching PodPlacementConfigs, followed by the ones of its imagePullSecrets field, that take precedence.
+// The inspection secrets are only used to inspect the images and are never injected in the pod.
+func (r *PodReconciler) pullSecretDataList(ctx context.Context, pod *Pod,
+	matchingPPCs []multiarchv1beta1.PodPlacementConfig) ([][]byte, error) {
+	return inspectionPullSecretDataList(ctx, r.ClientSet, pod.Namespace, inspectionSecretNames(matchingPPCs),
+		pod.getPodImagePullSecrets()), nil
+}
+
+// inspectionPullSecretDataList returns the auth data used to inspect the images of the pods of the given namespace:
+// the ones of the given inspection secrets, followed by the ones of the given imagePullSecrets, that take precedence.
+func inspectionPullSecretDataList(ctx context.Context, clientSet kubernetes.Interface, namespace string,
+	inspectionSecrets, imagePullSecrets []string) [][]byte {
+	return append(pullSecretDataList(ctx, clientSet, namespace, inspectionSecrets),
+		pullSecretDataList(ctx, clientSet, namespace, imagePullSecrets)...)
+}
+
+// pullSecretDataList returns the auth data of the given secrets in the given namespace. The secrets that cannot be
+// retrieved or do not contain valid auth data are logged and skipped.
+func pullSecretDataList(ctx context.Context, clientSet kubernetes.Interface, namespace string, secretNames []string) [][]byte {
 	log := ctrllog.FromContext(ctx)
 	secretAuths := make([][]byte, 0)
-	secretList := pod.getPodImagePullSecrets()
-	for _, pullsecret := range secretList {
-		secret, err := r.ClientSet.CoreV1().Secrets(pod.Namespace).Get(ctx, pullsecret, metav1.GetOptions{})
+	for _, pullsecret := range secretNames {
+		secret, err := clientSet.CoreV1().Secrets(namespace).Get(ctx, pullsecret, metav1.GetOptions{})
 		if err != nil {
 			log.Error(err, "Error getting secret", "secret", pullsecret)
 			continue
@@ -263,7 +312,7 @@ func (r *PodReconciler) pullSecretDataList(ctx context.Context, pod *Pod) ([][]b
 			secretAuths = append(secretAuths, secretData)
 		}
 	}
-	return secretAuths, nil
+	return secretAuths
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -0,0 +1,144 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podplacement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	multiarchv1beta1 "github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// inspectionSecretsResyncPeriod is the period of the resolution of the inspection secrets: the secrets are not
+// watched, to avoid caching all the secrets of the cluster.
+const inspectionSecretsResyncPeriod = 5 * time.Minute
+
+// PodPlacementConfigReconciler reports in the status of the PodPlacementConfigs whether the secrets referenced by
+// their inspectionSecrets exist and hold registry credentials.
+type PodPlacementConfigReconciler struct {
+	client.Client
+	ClientSet kubernetes.Interface
+}
+
+func (r *PodPlacementConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+	ppc := &multiarchv1beta1.PodPlacementConfig{}
+	if err := r.Get(ctx, req.NamespacedName, ppc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	var statuses []multiarchv1beta1.InspectionSecretStatus
+	for _, ref := range ppc.Spec.InspectionSecrets {
+		statuses = append(statuses, inspectionSecretStatus(ctx, r.ClientSet, ppc.Namespace, ref.Name))
+	}
+	result := ctrl.Result{}
+	if len(statuses) > 0 {
+		result.RequeueAfter = inspectionSecretsResyncPeriod
+	}
+	if equality.Semantic.DeepEqual(ppc.Status.InspectionSecrets, statuses) {
+		return result, nil
+	}
+	log.V(1).Info("Updating the status of the inspection secrets", "inspectionSecrets", statuses)
+	patch := client.MergeFrom(ppc.DeepCopy())
+	ppc.Status.InspectionSecrets = statuses
+	if err := r.Status().Patch(ctx, ppc, patch); err != nil {
+		log.Error(err, "Unable to update the status of the PodPlacementConfig")
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PodPlacementConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// The status updates do not change the generation and do not trigger a new reconciliation.
+		For(&multiarchv1beta1.PodPlacementConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// inspectionSecretStatus resolves the given inspection secret and reports whether it holds registry credentials.
+func inspectionSecretStatus(ctx context.Context, clientSet kubernetes.Interface, namespace,
+	name string) multiarchv1beta1.InspectionSecretStatus {
+	status := multiarchv1beta1.InspectionSecretStatus{Name: name}
+	secret, err := clientSet.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		status.Reason = multiarchv1beta1.InspectionSecretNotFoundReason
+		status.Message = "The secret does not exist"
+	case err != nil:
+		status.Reason = multiarchv1beta1.InspectionSecretErrorReason
+		status.Message = err.Error()
+	default:
+		registries, err := registriesOfAuthData(utils.ExtractAuthFromSecret(secret))
+		if err != nil {
+			status.Reason = multiarchv1beta1.InspectionSecretInvalidReason
+			status.Message = err.Error()
+			break
+		}
+		status.Resolved = true
+		status.Reason = multiarchv1beta1.InspectionSecretResolvedReason
+		status.Message = fmt.Sprintf("The secret holds the credentials of %d registries", registries)
+	}
+	return status
+}
+
+// registriesOfAuthData returns the number of registries in the given auth data, or an error if the data cannot be
+// parsed or holds no credentials.
+func registriesOfAuthData(authData []byte, err error) (int, error) {
+	if err != nil {
+		return 0, err
+	}
+	var auths map[string]json.RawMessage
+	if err = json.Unmarshal(authData, &auths); err != nil {
+		return 0, fmt.Errorf("unable to parse the registry credentials: %w", err)
+	}
+	if len(auths) == 0 {
+		return 0, errors.New("the secret holds no registry credentials")
+	}
+	return len(auths), nil
+}
+
+// inspectionSecretNames returns the names of the inspection secrets of the given PodPlacementConfigs, by ascending
+// priority: the credentials of the PodPlacementConfigs with a higher priority take precedence.
+func inspectionSecretNames(ppcs []multiarchv1beta1.PodPlacementConfig) []string {
+	sorted := make([]multiarchv1beta1.PodPlacementConfig, len(ppcs))
+	copy(sorted, ppcs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Spec.Priority < sorted[j].Spec.Priority
+	})
+	var names []string
+	for _, ppc := range sorted {
+		for _, ref := range ppc.Spec.InspectionSecrets {
+			names = append(names, ref.Name)
+		}
+	}
+	return names
+}
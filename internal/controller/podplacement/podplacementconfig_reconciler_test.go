@@ -0,0 +1,69 @@
+package podplacement
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/gomega"
+
+	multiarchv1beta1 "github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+)
+
+func Test_inspectionSecretStatus(t *testing.T) {
+	secret := func(name string, secretType v1.SecretType, data map[string][]byte) *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name}, Type: secretType, Data: data}
+	}
+	clientSet := fakeclientset.NewSimpleClientset(
+		secret("dockerconfigjson", v1.SecretTypeDockerConfigJson, map[string][]byte{
+			v1.DockerConfigJsonKey: []byte(`{"auths": {"quay.io": {"auth": "dXNlcjpwYXNzd29yZA=="}}}`),
+		}),
+		secret("dockercfg", v1.SecretTypeDockercfg, map[string][]byte{
+			v1.DockerConfigKey: []byte(`{"quay.io": {"auth": "dXNlcjpwYXNzd29yZA=="}, "docker.io": {"auth": ""}}`),
+		}),
+		secret("no-auths", v1.SecretTypeDockerConfigJson, map[string][]byte{v1.DockerConfigJsonKey: []byte(`{}`)}),
+		secret("malformed", v1.SecretTypeDockercfg, map[string][]byte{v1.DockerConfigKey: []byte(`{`)}),
+		secret("opaque", v1.SecretTypeOpaque, nil),
+	)
+	tests := []struct {
+		name     string
+		resolved bool
+		reason   string
+	}{
+		{name: "dockerconfigjson", resolved: true, reason: multiarchv1beta1.InspectionSecretResolvedReason},
+		{name: "dockercfg", resolved: true, reason: multiarchv1beta1.InspectionSecretResolvedReason},
+		{name: "no-auths", reason: multiarchv1beta1.InspectionSecretInvalidReason},
+		{name: "malformed", reason: multiarchv1beta1.InspectionSecretInvalidReason},
+		{name: "opaque", reason: multiarchv1beta1.InspectionSecretInvalidReason},
+		{name: "missing", reason: multiarchv1beta1.InspectionSecretNotFoundReason},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			status := inspectionSecretStatus(ctx, clientSet, "test", tt.name)
+			g.Expect(status.Name).To(Equal(tt.name))
+			g.Expect(status.Resolved).To(Equal(tt.resolved))
+			g.Expect(status.Reason).To(Equal(tt.reason))
+			g.Expect(status.Message).NotTo(BeEmpty())
+		})
+	}
+}
+
+func Test_inspectionSecretNames(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ppc := func(priority uint8, secrets ...string) multiarchv1beta1.PodPlacementConfig {
+		ppc := multiarchv1beta1.PodPlacementConfig{Spec: multiarchv1beta1.PodPlacementConfigSpec{Priority: priority}}
+		for _, secret := range secrets {
+			ppc.Spec.InspectionSecrets = append(ppc.Spec.InspectionSecrets,
+				multiarchv1beta1.InspectionSecretReference{Name: secret})
+		}
+		return ppc
+	}
+	ppcs := []multiarchv1beta1.PodPlacementConfig{ppc(10, "high-1", "high-2"), ppc(0), ppc(5, "low")}
+	g.Expect(inspectionSecretNames(ppcs)).To(Equal([]string{"low", "high-1", "high-2"}),
+		"the secrets of the PodPlacementConfigs with a higher priority should come last to take precedence")
+	g.Expect(ppcs[0].Spec.Priority).To(Equal(uint8(10)), "the given PodPlacementConfigs should not be reordered")
+	g.Expect(inspectionSecretNames(nil)).To(BeEmpty())
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podplacement
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
+)
+
+const (
+	// registriesConfigKey is the only key of the queue of the RegistriesConfigSyncer: the whole configuration is
+	// rebuilt on any change.
+	registriesConfigKey = "registries-config"
+	// clusterProxyName is the name of the cluster-wide Proxy object.
+	clusterProxyName = "cluster"
+	// clusterImageConfigName is the name of the cluster-wide image configuration.
+	clusterImageConfigName = "cluster"
+	// hostRegistriesConfResyncPeriod is the period the registries configuration of the host is reloaded with when
+	// none of the mirror sets is served.
+	hostRegistriesConfResyncPeriod = time.Minute
+)
+
+// registriesConfigKinds are the kinds of the objects watched by the RegistriesConfigSyncer.
+var registriesConfigKinds = []client.Object{
+	&configv1.ImageDigestMirrorSet{},
+	&configv1.ImageTagMirrorSet{},
+	&operatorv1alpha1.ImageContentSourcePolicy{},
+	&configv1.Proxy{},
+	&configv1.Image{},
+}
+
+// RegistriesConfigSyncer watches the ImageDigestMirrorSets, ImageTagMirrorSets, ImageContentSourcePolicies, the
+// cluster Proxy and the cluster image configuration, and configures the mirrors, the proxy and the hostnames of the
+// internal registry used by the image inspections accordingly. The mirrors are applied without waiting for the
+// registries.conf of the nodes to be updated. The kinds not served by the cluster are skipped: if none of the mirror
+// sets is served, the registries configuration of the host is used, and reloaded periodically when it changes.
+type RegistriesConfigSyncer struct {
+	cache      ctrlcache.Cache
+	restMapper meta.RESTMapper
+	scheme     *runtime.Scheme
+	queue      workqueue.TypedRateLimitingInterface[string]
+	served     sets.Set[schema.GroupKind]
+	log        logr.Logger
+}
+
+func NewRegistriesConfigSyncer(cache ctrlcache.Cache, restMapper meta.RESTMapper, scheme *runtime.Scheme) *RegistriesConfigSyncer {
+	return &RegistriesConfigSyncer{
+		cache:      cache,
+		restMapper: restMapper,
+		scheme:     scheme,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{
+				Name: "registries-config-syncer",
+			}),
+		served: sets.New[schema.GroupKind](),
+		log:    ctrllog.Log.WithName("RegistriesConfigSyncer"),
+	}
+}
+
+func (s *RegistriesConfigSyncer) Start(ctx context.Context) error {
+	s.log = ctrllog.FromContext(ctx, "handler", "RegistriesConfigSyncer")
+	s.log.Info("Starting the Registries Config Syncer")
+	defer s.queue.ShutDown()
+	for _, obj := range registriesConfigKinds {
+		gvk, err := apiutil.GVKForObject(obj, s.scheme)
+		if err != nil {
+			return err
+		}
+		if _, err = s.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version); meta.IsNoMatchError(err) {
+			// e.g., the mirror sets are not served by the clusters without the OpenShift config API.
+			s.log.Info("The kind is not served by the cluster, skipping", "kind", gvk.String())
+			continue
+		} else if err != nil {
+			return err
+		}
+		informer, err := s.cache.GetInformer(ctx, obj)
+		if err != nil {
+			s.log.Error(err, "Unable to get the informer", "kind", gvk.String())
+			return err
+		}
+		if _, err = informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { s.queue.Add(registriesConfigKey) },
+			UpdateFunc: func(interface{}, interface{}) { s.queue.Add(registriesConfigKey) },
+			DeleteFunc: func(interface{}) { s.queue.Add(registriesConfigKey) },
+		}); err != nil {
+			s.log.Error(err, "Error registering the handler", "kind", gvk.String())
+			return err
+		}
+		s.served.Insert(gvk.GroupKind())
+	}
+	// Sync once even if no object exists
+	s.queue.Add(registriesConfigKey)
+	go func() {
+		for s.processNextItem(ctx) {
+		}
+	}()
+	<-ctx.Done()
+	s.log.Info("Stopping the Registries Config Syncer")
+	return nil
+}
+
+func (s *RegistriesConfigSyncer) processNextItem(ctx context.Context) bool {
+	key, shutdown := s.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer s.queue.Done(key)
+	if err := s.sync(ctx); err != nil {
+		s.log.Error(err, "Unable to sync the registries configuration")
+		s.queue.AddRateLimited(key)
+		return true
+	}
+	s.queue.Forget(key)
+	return true
+}
+
+// sync configures the mirrors, the proxy and the internal registry hostnames of the image inspections from the
+// objects in the cache.
+func (s *RegistriesConfigSyncer) sync(ctx context.Context) error {
+	ctx = ctrllog.IntoContext(ctx, s.log)
+	if s.servesAny(&configv1.ImageDigestMirrorSet{}, &configv1.ImageTagMirrorSet{},
+		&operatorv1alpha1.ImageContentSourcePolicy{}) {
+		mirrors, err := s.registryMirrors(ctx)
+		if err != nil {
+			return err
+		}
+		if err = image.FacadeSingleton().ConfigureRegistryMirrors(ctx, mirrors); err != nil {
+			return err
+		}
+	} else {
+		image.FacadeSingleton().ReloadHostRegistriesConfig(ctx)
+		s.queue.AddAfter(registriesConfigKey, hostRegistriesConfResyncPeriod)
+	}
+	if s.servesAny(&configv1.Proxy{}) {
+		proxy := &configv1.Proxy{}
+		// A missing Proxy restores the proxy of the environment
+		if err := s.cache.Get(ctx, client.ObjectKey{Name: clusterProxyName}, proxy); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		image.FacadeSingleton().ConfigureProxy(ctx, image.ProxyConfig{
+			HTTPProxy:  proxy.Status.HTTPProxy,
+			HTTPSProxy: proxy.Status.HTTPSProxy,
+			NoProxy:    proxy.Status.NoProxy,
+		})
+	}
+	if s.servesAny(&configv1.Image{}) {
+		imageConfig := &configv1.Image{}
+		// A missing image configuration restores the default hostnames of the internal registry
+		if err := s.cache.Get(ctx, client.ObjectKey{Name: clusterImageConfigName}, imageConfig); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		image.FacadeSingleton().ConfigureInternalRegistryHostnames(ctx, imageConfig.Status.InternalRegistryHostname,
+			imageConfig.Status.ExternalRegistryHostnames)
+	}
+	return nil
+}
+
+// registryMirrors returns the mirrors of the ImageDigestMirrorSets, ImageTagMirrorSets and
+// ImageContentSourcePolicies, sorted by kind and name. Like on the nodes, the mirrors of the ImageDigestMirrorSets
+// and ImageContentSourcePolicies are only used for the references by digest, and the ones of the
+// ImageTagMirrorSets for the references by tag.
+func (s *RegistriesConfigSyncer) registryMirrors(ctx context.Context) ([]image.RegistryMirrors, error) {
+	var mirrors []image.RegistryMirrors
+	if s.servesAny(&configv1.ImageDigestMirrorSet{}) {
+		list := &configv1.ImageDigestMirrorSetList{}
+		if err := s.cache.List(ctx, list); err != nil {
+			return nil, err
+		}
+		sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+		for _, idms := range list.Items {
+			for _, m := range idms.Spec.ImageDigestMirrors {
+				mirrors = append(mirrors, registryMirrorsOf(m.Source, m.Mirrors, image.PullFromMirrorDigestOnly,
+					m.MirrorSourcePolicy))
+			}
+		}
+	}
+	if s.servesAny(&configv1.ImageTagMirrorSet{}) {
+		list := &configv1.ImageTagMirrorSetList{}
+		if err := s.cache.List(ctx, list); err != nil {
+			return nil, err
+		}
+		sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+		for _, itms := range list.Items {
+			for _, m := range itms.Spec.ImageTagMirrors {
+				mirrors = append(mirrors, registryMirrorsOf(m.Source, m.Mirrors, image.PullFromMirrorTagOnly,
+					m.MirrorSourcePolicy))
+			}
+		}
+	}
+	if s.servesAny(&operatorv1alpha1.ImageContentSourcePolicy{}) {
+		list := &operatorv1alpha1.ImageContentSourcePolicyList{}
+		if err := s.cache.List(ctx, list); err != nil {
+			return nil, err
+		}
+		sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+		for _, icsp := range list.Items {
+			for _, m := range icsp.Spec.RepositoryDigestMirrors {
+				locations := make([]configv1.ImageMirror, 0, len(m.Mirrors))
+				for _, location := range m.Mirrors {
+					locations = append(locations, configv1.ImageMirror(location))
+				}
+				mirrors = append(mirrors, registryMirrorsOf(m.Source, locations, image.PullFromMirrorDigestOnly, ""))
+			}
+		}
+	}
+	return mirrors, nil
+}
+
+// servesAny returns true if the cluster serves any of the kinds of the given objects.
+func (s *RegistriesConfigSyncer) servesAny(objs ...client.Object) bool {
+	for _, obj := range objs {
+		if gvk, err := apiutil.GVKForObject(obj, s.scheme); err == nil && s.served.Has(gvk.GroupKind()) {
+			return true
+		}
+	}
+	return false
+}
+
+func registryMirrorsOf(source string, locations []configv1.ImageMirror, pullFromMirror string,
+	policy configv1.MirrorSourcePolicy) image.RegistryMirrors {
+	mirrors := image.RegistryMirrors{
+		Source:  source,
+		Blocked: policy == configv1.NeverContactSource,
+	}
+	for _, location := range locations {
+		mirrors.Mirrors = append(mirrors.Mirrors, image.RegistryMirror{
+			Location:       string(location),
+			PullFromMirror: pullFromMirror,
+		})
+	}
+	return mirrors
+}
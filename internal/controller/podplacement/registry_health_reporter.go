@@ -0,0 +1,79 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podplacement
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	multiarchv1beta1 "github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
+)
+
+// registryHealthReportInterval is the interval between two reports of the state of the circuit breakers.
+const registryHealthReportInterval = 15 * time.Second
+
+// RegistryHealthReporter reports the registries whose circuit breaker is open in the RegistriesUnhealthy condition
+// of the ClusterPodPlacementConfig. The circuit breakers are local to the replica reconciling the pods: the reporter
+// runs only in the leader.
+type RegistryHealthReporter struct {
+	client client.Client
+}
+
+func NewRegistryHealthReporter(c client.Client) *RegistryHealthReporter {
+	return &RegistryHealthReporter{
+		client: c,
+	}
+}
+
+func (r *RegistryHealthReporter) Start(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx, "handler", "RegistryHealthReporter")
+	log.Info("Starting the Registry Health Reporter")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.report(ctx); err != nil {
+			log.Error(err, "Unable to report the health of the registries")
+		}
+	}, registryHealthReportInterval)
+	log.Info("Stopping the Registry Health Reporter")
+	return nil
+}
+
+// report updates the RegistriesUnhealthy condition of the ClusterPodPlacementConfig, if it changed.
+func (r *RegistryHealthReporter) report(ctx context.Context) error {
+	registries := image.FacadeSingleton().UnhealthyRegistries()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cppc := &multiarchv1beta1.ClusterPodPlacementConfig{}
+		if err := r.client.Get(ctx, client.ObjectKey{Name: common.SingletonResourceObjectName}, cppc); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !cppc.DeletionTimestamp.IsZero() {
+			return nil
+		}
+		patch := client.MergeFromWithOptions(cppc.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		if !cppc.Status.SetUnhealthyRegistries(registries) {
+			return nil
+		}
+		ctrllog.FromContext(ctx).Info("Reporting the unhealthy registries", "registries", registries)
+		return r.client.Status().Patch(ctx, cppc, patch)
+	})
+}
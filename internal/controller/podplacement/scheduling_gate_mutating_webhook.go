@@ -19,14 +19,17 @@ package podplacement
 import (
 	"context"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
@@ -39,10 +42,14 @@ import (
 	"github.com/openshift/multiarch-tuning-operator/api/common"
 	multiarchv1beta1 "github.com/openshift/multiarch-tuning-operator/api/v1beta1"
 	"github.com/openshift/multiarch-tuning-operator/internal/controller/podplacement/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
 	"github.com/openshift/multiarch-tuning-operator/pkg/informers/clusterpodplacementconfig"
 	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
 )
 
+// ImageArchitectureImageReferenceIndex is the name of the index of the ImageArchitecture objects by image reference.
+const ImageArchitectureImageReferenceIndex = "spec.imageReference"
+
 // [disabled:operator]kubebuilder:webhook:path=/add-pod-scheduling-gate,mutating=true,sideEffects=None,admissionReviewVersions=v1,failurePolicy=ignore,groups="",resources=pods,verbs=create,versions=v1,name=pod-placement-scheduling-gate.multiarch.openshift.io
 
 // PodSchedulingGateMutatingWebHook annotates Pods
@@ -104,6 +111,11 @@ func (a *PodSchedulingGateMutatingWebHook) Handle(ctx context.Context, req admis
 		log.V(3).Info("Ignoring the pod")
 		return a.patchedPodResponse(pod.PodObject(), req)
 	}
+	// The node selector of the RuntimeClass can constrain the architecture of the nodes
+	if a.setRuntimeClass(ctx, pod, cppc.RuntimeClassMappings()) && pod.shouldIgnorePod(cppc, matchingPPCs) {
+		log.V(3).Info("Ignoring the pod as its RuntimeClass constrains the architecture")
+		return a.patchedPodResponse(pod.PodObject(), req)
+	}
 
 	pod.ensureSchedulingGate()
 	// We also add a label to the pod to indicate that the scheduling gate was added
@@ -122,6 +134,97 @@ func (a *PodSchedulingGateMutatingWebHook) Handle(ctx context.Context, req admis
 	return a.patchedPodResponse(pod.PodObject(), req)
 }
 
+// setRuntimeClass sets the RuntimeClass of the pod when all its images only support the platform of one of the given
+// RuntimeClass mappings. As the webhook does not inspect the images and the runtimeClassName cannot be changed once
+// the pod is created, the platforms of the images are the ones recorded in the ImageArchitecture objects by the pod
+// placement controller. It returns true if the RuntimeClass was set.
+func (a *PodSchedulingGateMutatingWebHook) setRuntimeClass(ctx context.Context, pod *Pod,
+	mappings []multiarchv1beta1.RuntimeClassMapping) bool {
+	if len(mappings) == 0 || pod.Spec.RuntimeClassName != nil {
+		return false
+	}
+	log := ctrllog.FromContext(ctx).WithValues("namespace", pod.Namespace, "name", pod.Name)
+	platforms, ok := a.recordedImagesPlatforms(ctx, pod)
+	if !ok {
+		return false
+	}
+	mapping := runtimeClassMappingOf(platforms, mappings)
+	if mapping == nil {
+		return false
+	}
+	runtimeClass := &nodev1.RuntimeClass{}
+	if err := a.client.Get(ctx, client.ObjectKey{Name: mapping.RuntimeClassName}, runtimeClass); err != nil {
+		log.Error(err, "Unable to get the RuntimeClass of the mapping", "runtimeClass", mapping.RuntimeClassName)
+		return false
+	}
+	if !pod.applyRuntimeClass(runtimeClass) {
+		log.V(1).Info("The pod conflicts with the RuntimeClass of the mapping", "runtimeClass", runtimeClass.Name)
+		return false
+	}
+	log.V(2).Info("Set the RuntimeClass of the pod", "runtimeClass", runtimeClass.Name, "platform", mapping.Platform)
+	return true
+}
+
+// recordedImagesPlatforms returns the (os, architecture) pairs supported by all the images of the pod constraining its
+// placement, as recorded in the ImageArchitecture objects. The digest-pinned images are looked up by digest and the
+// others by the image reference that was inspected, using the most recent record. It returns false if any of the
+// images has no record.
+func (a *PodSchedulingGateMutatingWebHook) recordedImagesPlatforms(ctx context.Context, pod *Pod) (sets.Set[image.Platform], bool) {
+	var platforms sets.Set[image.Platform]
+	for imageContainer := range pod.imagesNamesSet() {
+		imageReference := strings.TrimPrefix(imageContainer.imageName, "//")
+		var recorded *multiarchv1beta1.ImageArchitecture
+		if _, d, ok := strings.Cut(imageReference, "@"); ok {
+			imageArchitecture := &multiarchv1beta1.ImageArchitecture{}
+			if err := a.client.Get(ctx, client.ObjectKey{Name: multiarchv1beta1.ImageArchitectureNameForDigest(d)},
+				imageArchitecture); err == nil && imageArchitecture.Spec.Digest == d {
+				recorded = imageArchitecture
+			}
+		} else {
+			imageArchitectures := &multiarchv1beta1.ImageArchitectureList{}
+			if err := a.client.List(ctx, imageArchitectures,
+				client.MatchingFields{ImageArchitectureImageReferenceIndex: imageReference}); err != nil {
+				ctrllog.FromContext(ctx).Error(err, "Unable to list the ImageArchitecture objects", "imageReference", imageReference)
+				return nil, false
+			}
+			for i := range imageArchitectures.Items {
+				if recorded == nil || recorded.Spec.InspectionTime.Before(&imageArchitectures.Items[i].Spec.InspectionTime) {
+					recorded = &imageArchitectures.Items[i]
+				}
+			}
+		}
+		if recorded == nil {
+			return nil, false
+		}
+		imagePlatforms := sets.New[image.Platform]()
+		for _, p := range recorded.Spec.Platforms {
+			imagePlatforms.Insert(image.Platform{OS: p.OS, Architecture: p.Architecture})
+		}
+		if !imageContainer.constrainsPlacement(imagePlatforms) {
+			continue
+		}
+		if platforms == nil {
+			platforms = imagePlatforms
+		} else {
+			platforms = platforms.Intersection(imagePlatforms)
+		}
+	}
+	return platforms, platforms != nil
+}
+
+// IndexImageArchitectureReferences indexes the ImageArchitecture objects by their image reference, so that the
+// webhook can look up the platforms of the images of the pods.
+func IndexImageArchitectureReferences(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &multiarchv1beta1.ImageArchitecture{}, ImageArchitectureImageReferenceIndex,
+		func(obj client.Object) []string {
+			imageArchitecture, ok := obj.(*multiarchv1beta1.ImageArchitecture)
+			if !ok || imageArchitecture.Spec.ImageReference == "" {
+				return nil
+			}
+			return []string{imageArchitecture.Spec.ImageReference}
+		})
+}
+
 func (a *PodSchedulingGateMutatingWebHook) delayedSchedulingGatedEvent(ctx context.Context, pod *corev1.Pod) {
 	err := a.workerPool.Submit(func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
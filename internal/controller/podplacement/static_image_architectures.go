@@ -0,0 +1,89 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podplacement
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/image/v5/docker/reference"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
+)
+
+// staticImageRule is a static image architecture rule along with the configuration it comes from, e.g.,
+// PodPlacementConfig-<name> or ClusterPodPlacementConfig.
+type staticImageRule struct {
+	v1beta1.StaticImageArchitecture
+	source string
+}
+
+// staticImageRules returns the static image architecture rules applying to the pods matching the given
+// PodPlacementConfigs: the ones of the PodPlacementConfigs, by descending priority, followed by the ones of the
+// ClusterPodPlacementConfig.
+func staticImageRules(cppc *v1beta1.ClusterPodPlacementConfig, matchingPPCs []v1beta1.PodPlacementConfig) []staticImageRule {
+	var rules []staticImageRule
+	for _, ppc := range sortedByPriority(matchingPPCs) {
+		source := fmt.Sprintf("%s-%s", v1beta1.PodPlacementConfigKind, ppc.Name)
+		for _, rule := range ppc.Spec.StaticImageArchitectures {
+			rules = append(rules, staticImageRule{StaticImageArchitecture: rule, source: source})
+		}
+	}
+	for _, rule := range cppc.StaticImageArchitectures() {
+		rules = append(rules, staticImageRule{StaticImageArchitecture: rule, source: v1beta1.ClusterPodPlacementConfigKind})
+	}
+	return rules
+}
+
+// matchStaticImageRule returns the first of the given rules matching the fully qualified reference of the given image,
+// e.g., docker.io/library/busybox:latest for //busybox, or nil if none matches.
+func matchStaticImageRule(rules []staticImageRule, imageReference string) *staticImageRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(imageReference, "//"))
+	if err != nil {
+		return nil
+	}
+	fullyQualifiedReference := reference.TagNameOnly(named).String()
+	for i := range rules {
+		if rules[i].Matches(fullyQualifiedReference) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// platforms returns the platforms of the images matching the rule: the ones of its architectures or, if the rule
+// skips the images, the ones of all the supported architectures.
+func (r *staticImageRule) platforms() sets.Set[image.Platform] {
+	if r.Skip {
+		return image.PlatformsOf(supportedArchitectures())
+	}
+	return image.PlatformsOf(sets.New[string](r.Architectures...))
+}
+
+// String returns a description of the rule for the events of the pods.
+func (r *staticImageRule) String() string {
+	decision := "skip"
+	if !r.Skip {
+		decision = fmt.Sprintf("{%s}", strings.Join(r.Architectures, ", "))
+	}
+	return fmt.Sprintf("pattern: %s, source: %s, architectures: %s", r.ImagePattern, r.source, decision)
+}
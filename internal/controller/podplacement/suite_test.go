@@ -387,6 +387,9 @@ func runManager() {
 	By("Setting up Cluster Podplacement Config informer")
 	err = mgr.Add(clusterpodplacementconfig.NewCPPCSyncer(mgr))
 	Expect(err).NotTo(HaveOccurred())
+	By("Setting up the Image Inspection Config Syncer")
+	err = mgr.Add(NewImageInspectionConfigSyncer(mgr.GetCache()))
+	Expect(err).NotTo(HaveOccurred())
 	By("Checking the cache is empty")
 	Expect(clusterpodplacementconfig.GetClusterPodPlacementConfig()).To(BeNil())
 
@@ -65,6 +65,18 @@ func (w *PodPlacementConfigWebhook) Handle(ctx context.Context, req admission.Re
 			}
 		}
 
+		// Check for self-substitutions and duplicate images in ImageSubstitution
+		if newPPC.PluginsEnabled(common.ImageSubstitutionPluginName) {
+			if ok, err := newPPC.Spec.Plugins.ImageSubstitution.ValidateRules(); !ok {
+				return admission.Denied(err.Error())
+			}
+		}
+
+		if err := multiarchv1beta1.ValidateStaticImageArchitectures(".spec.staticImageArchitectures",
+			newPPC.Spec.StaticImageArchitectures); err != nil {
+			return admission.Denied(err.Error())
+		}
+
 		// List existing PodPlacementConfigs in the same namespace
 		existingPPCs := &multiarchv1beta1.PodPlacementConfigList{}
 		if err := w.apiReader.List(ctx, existingPPCs, client.InNamespace(req.Namespace)); err != nil {
@@ -0,0 +1,116 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"sync"
+
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+)
+
+// architectureAgnosticRules holds the rules of the ClusterPodPlacementConfig marking the images as
+// architecture-agnostic, in addition to the operator bundle images.
+type architectureAgnosticRules struct {
+	mutex sync.RWMutex
+	rules []v1beta1.ArchitectureAgnosticImageRule
+}
+
+// configure sets the given rules. It returns true if they changed.
+func (r *architectureAgnosticRules) configure(rules []v1beta1.ArchitectureAgnosticImageRule) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if (len(r.rules) == 0 && len(rules) == 0) || reflect.DeepEqual(r.rules, rules) {
+		return false
+	}
+	r.rules = rules
+	return true
+}
+
+// configured returns true if any rule is configured.
+func (r *architectureAgnosticRules) configured() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.rules) > 0
+}
+
+// list returns the configured rules.
+func (r *architectureAgnosticRules) list() []v1beta1.ArchitectureAgnosticImageRule {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.rules
+}
+
+// manifestMetadata holds the fields of an image manifest, or manifest list, matched by the rules.
+type manifestMetadata struct {
+	Annotations  map[string]string  `json:"annotations,omitempty"`
+	ArtifactType string             `json:"artifactType,omitempty"`
+	Config       ociv1.Descriptor   `json:"config"`
+	Layers       []ociv1.Descriptor `json:"layers"`
+}
+
+// match returns the name of the first rule matching the given config labels or the annotations and the media types
+// of the given raw manifests, e.g., the manifest list of an image and the manifest of its first entry.
+// It returns false if no rule matches.
+func (r *architectureAgnosticRules) match(labels map[string]string, rawManifests ...[]byte) (string, bool) {
+	r.mutex.RLock()
+	rules := r.rules
+	r.mutex.RUnlock()
+	if len(rules) == 0 {
+		return "", false
+	}
+	annotations := map[string]string{}
+	var mediaTypes []string
+	for _, rawManifest := range rawManifests {
+		var m manifestMetadata
+		if err := json.Unmarshal(rawManifest, &m); err != nil {
+			continue
+		}
+		maps.Copy(annotations, m.Annotations)
+		mediaTypes = append(mediaTypes, m.ArtifactType, m.Config.MediaType)
+		for _, layer := range m.Layers {
+			mediaTypes = append(mediaTypes, layer.MediaType)
+		}
+	}
+	for i := range rules {
+		if rules[i].Matches(labels, annotations, mediaTypes) {
+			return rules[i].Name, true
+		}
+	}
+	return "", false
+}
+
+type architectureAgnosticRuleHandlerKey struct{}
+
+// WithArchitectureAgnosticRuleHandler returns a copy of ctx in which the lookups of the image platforms call handler
+// with the image reference and the name of the rule when the image matched an architecture-agnostic image rule,
+// including when the result is cached. The handler may be called concurrently.
+func WithArchitectureAgnosticRuleHandler(ctx context.Context, handler func(imageReference, rule string)) context.Context {
+	return context.WithValue(ctx, architectureAgnosticRuleHandlerKey{}, handler)
+}
+
+// reportArchitectureAgnosticRule passes the given rule, matched by the given image, to the handler of ctx, if any.
+func reportArchitectureAgnosticRule(ctx context.Context, imageReference, rule string) {
+	if handler, ok := ctx.Value(architectureAgnosticRuleHandlerKey{}).(func(string, string)); ok {
+		handler(imageReference, rule)
+	}
+}
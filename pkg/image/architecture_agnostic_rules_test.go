@@ -0,0 +1,165 @@
+package image
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+const helmChartConfigMediaType = "application/vnd.cncf.helm.config.v1+json"
+
+var testArchitectureAgnosticRules = []v1beta1.ArchitectureAgnosticImageRule{
+	{Name: "scripts", Label: &v1beta1.ImageMetadataMatcher{Key: "io.example.content", Value: "scripts"}},
+	{Name: "noarch", Annotation: &v1beta1.ImageMetadataMatcher{Key: "io.example.noarch"}},
+	{Name: "helm-charts", MediaType: helmChartConfigMediaType},
+}
+
+func Test_architectureAgnosticRules_match(t *testing.T) {
+	tests := []struct {
+		name         string
+		labels       map[string]string
+		rawManifests []string
+		want         string
+		wantOk       bool
+	}{
+		{
+			name:   "label with value",
+			labels: map[string]string{"io.example.content": "scripts"},
+			want:   "scripts",
+			wantOk: true,
+		},
+		{
+			name:   "label with another value",
+			labels: map[string]string{"io.example.content": "binaries"},
+		},
+		{
+			name:         "annotation of the manifest list",
+			rawManifests: []string{`{"annotations": {"io.example.noarch": "true"}}`, `{"config": {}}`},
+			want:         "noarch",
+			wantOk:       true,
+		},
+		{
+			name:         "config media type of the manifest",
+			rawManifests: []string{`{"config": {"mediaType": "` + helmChartConfigMediaType + `"}}`},
+			want:         "helm-charts",
+			wantOk:       true,
+		},
+		{
+			name:         "first matching rule",
+			labels:       map[string]string{"io.example.content": "scripts"},
+			rawManifests: []string{`{"annotations": {"io.example.noarch": ""}}`},
+			want:         "scripts",
+			wantOk:       true,
+		},
+		{
+			name:         "invalid manifest",
+			rawManifests: []string{`not a manifest`},
+		},
+	}
+	r := &architectureAgnosticRules{}
+	if _, ok := r.match(map[string]string{"io.example.content": "scripts"}); ok {
+		t.Fatal("expected no match with no rule configured")
+	}
+	r.configure(testArchitectureAgnosticRules)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawManifests := make([][]byte, 0, len(tt.rawManifests))
+			for _, rawManifest := range tt.rawManifests {
+				rawManifests = append(rawManifests, []byte(rawManifest))
+			}
+			got, ok := r.match(tt.labels, rawManifests...)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("match() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_architectureAgnosticRules_configure(t *testing.T) {
+	r := &architectureAgnosticRules{}
+	if r.configure(nil) || r.configure([]v1beta1.ArchitectureAgnosticImageRule{}) {
+		t.Error("expected no change when no rule is configured")
+	}
+	if !r.configure(testArchitectureAgnosticRules) || !r.configured() {
+		t.Error("expected the rules to be configured")
+	}
+	if r.configure(testArchitectureAgnosticRules) {
+		t.Error("expected no change when the rules are the same")
+	}
+	if !r.configure(nil) || r.configured() {
+		t.Error("expected the rules to be removed")
+	}
+}
+
+func Test_inspectSource_architectureAgnosticRules(t *testing.T) {
+	metrics.InitCommonMetrics()
+	usePolicyConf(t, `{"default": [{"type": "insecureAcceptAnything"}]}`)
+	root := t.TempDir()
+
+	layout := newOCILayoutBuilder()
+	layout.tag(layout.addImage(t, utils.ArchitectureAmd64, map[string]string{"io.example.content": "scripts"}),
+		"scripts")
+	layout.tag(layout.addImage(t, utils.ArchitectureAmd64, nil), "binaries")
+	layout.tag(layout.addArtifact(t, "", helmChartConfigMediaType, "application/vnd.cncf.helm.chart.content.v1.tar+gzip"),
+		"chart")
+	layout.writeDir(t, filepath.Join(root, "origin"))
+
+	s := newInspectionState()
+	s.offlineSources.configure(root, &v1beta1.OfflineImageSourcesConfig{Sources: []v1beta1.OfflineImageSource{
+		{Prefix: "quay.io/openshift", Mode: v1beta1.OfflineImageSourceModeOfflineOnly},
+	}})
+	s.architectureAgnosticRules.configure(testArchitectureAgnosticRules)
+
+	allPlatforms := PlatformsOf(utils.AllSupportedArchitecturesSet())
+	tests := []struct {
+		tag           string
+		wantPlatforms sets.Set[Platform]
+		wantRule      string
+	}{
+		{tag: "scripts", wantPlatforms: allPlatforms, wantRule: "scripts"},
+		{tag: "binaries", wantPlatforms: PlatformsOf(sets.New[string](utils.ArchitectureAmd64))},
+		{tag: "chart", wantPlatforms: allPlatforms, wantRule: "helm-charts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			result, _, err := s.inspectOffline(context.Background(), "//quay.io/openshift/origin:"+tt.tag)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.platforms.Equal(tt.wantPlatforms) {
+				t.Errorf("expected the platforms %v, got %v", tt.wantPlatforms, result.platforms)
+			}
+			if result.architectureAgnosticRule != tt.wantRule {
+				t.Errorf("expected the rule %q, got %q", tt.wantRule, result.architectureAgnosticRule)
+			}
+		})
+	}
+}
+
+func Test_cacheProxy_GetCompatiblePlatformsSet_reportsArchitectureAgnosticRule(t *testing.T) {
+	c := newCacheProxy()
+	c.registryInspector = &countingInspector{
+		digest:    digest.Digest(testDigest),
+		platforms: PlatformsOf(utils.AllSupportedArchitecturesSet()),
+		rule:      "scripts",
+	}
+	for _, lookup := range []string{"miss", "hit"} {
+		var reported []string
+		ctx := WithArchitectureAgnosticRuleHandler(context.Background(), func(imageReference, rule string) {
+			reported = append(reported, imageReference+" "+rule)
+		})
+		if _, err := c.GetCompatiblePlatformsSet(ctx, "//quay.io/foo/bar:latest", false, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(reported) != 1 || reported[0] != "//quay.io/foo/bar:latest scripts" {
+			t.Errorf("expected the rule to be reported on a cache %s, got %v", lookup, reported)
+		}
+	}
+}
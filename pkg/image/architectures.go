@@ -0,0 +1,128 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"maps"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// builtinArchitectureAliases maps the non-canonical architecture names reported by some images, usually the
+// ones used by the kernel (uname -m) or by the Debian ports, to the GOARCH names reported by the kubernetes.io/arch
+// node label and the CPU variant they imply.
+var builtinArchitectureAliases = map[string]Platform{
+	"aarch64": {Architecture: utils.ArchitectureArm64},
+	"x86_64":  {Architecture: utils.ArchitectureAmd64},
+	"x86-64":  {Architecture: utils.ArchitectureAmd64},
+	"i386":    {Architecture: "386"},
+	"i486":    {Architecture: "386"},
+	"i586":    {Architecture: "386"},
+	"i686":    {Architecture: "386"},
+	"x86":     {Architecture: "386"},
+	"armhf":   {Architecture: "arm", Variant: "v7"},
+	"armv7l":  {Architecture: "arm", Variant: "v7"},
+	"armel":   {Architecture: "arm", Variant: "v6"},
+	"armv6l":  {Architecture: "arm", Variant: "v6"},
+	"ppc64el": {Architecture: utils.ArchitecturePpc64le},
+}
+
+// architectureConfig is the set of supported architectures and the architecture aliases configured in the
+// ClusterPodPlacementConfig. It is never modified once created.
+type architectureConfig struct {
+	supportedArchitectures sets.Set[string]
+	aliases                map[string]Platform
+}
+
+func newArchitectureConfig(supportedArchitectures sets.Set[string], aliases []v1beta1.ArchitectureAlias) *architectureConfig {
+	if supportedArchitectures.Len() == 0 {
+		supportedArchitectures = utils.AllSupportedArchitecturesSet()
+	}
+	config := &architectureConfig{
+		supportedArchitectures: supportedArchitectures.Clone(),
+		aliases:                maps.Clone(builtinArchitectureAliases),
+	}
+	for _, alias := range aliases {
+		config.aliases[alias.Name] = Platform{Architecture: alias.Architecture, Variant: alias.Variant}
+	}
+	return config
+}
+
+func (c *architectureConfig) equal(other *architectureConfig) bool {
+	return c.supportedArchitectures.Equal(other.supportedArchitectures) && maps.Equal(c.aliases, other.aliases)
+}
+
+// architectures is the architecture configuration applied to the inspection results.
+type architectures struct {
+	config atomic.Pointer[architectureConfig]
+}
+
+// newArchitectures returns the default architecture configuration: all the architectures supported by the operator
+// and the builtin aliases.
+func newArchitectures() *architectures {
+	a := &architectures{}
+	a.config.Store(newArchitectureConfig(nil, nil))
+	return a
+}
+
+// configure applies the given supported architectures and aliases. It returns true if the configuration changed. An
+// empty set of supported architectures restores the default one.
+func (a *architectures) configure(supportedArchitectures sets.Set[string], aliases []v1beta1.ArchitectureAlias) bool {
+	desired := newArchitectureConfig(supportedArchitectures, aliases)
+	for {
+		current := a.config.Load()
+		if current.equal(desired) {
+			return false
+		}
+		if a.config.CompareAndSwap(current, desired) {
+			return true
+		}
+	}
+}
+
+// supported returns the set of the architectures supported by the cluster, as configured in the
+// ClusterPodPlacementConfig.
+func (a *architectures) supported() sets.Set[string] {
+	return a.config.Load().supportedArchitectures.Clone()
+}
+
+// normalizePlatform returns the given platform with the architecture name normalized to the one reported by the
+// kubernetes.io/arch node label. The variant implied by an alias only applies if the platform does not report one.
+func (a *architectures) normalizePlatform(platform Platform) Platform {
+	alias, ok := a.config.Load().aliases[platform.Architecture]
+	if !ok {
+		return platform
+	}
+	platform.Architecture = alias.Architecture
+	if platform.Variant == "" {
+		platform.Variant = alias.Variant
+	}
+	return platform
+}
+
+// normalizePlatforms returns the set of the normalized platforms.
+func (a *architectures) normalizePlatforms(platforms sets.Set[Platform]) sets.Set[Platform] {
+	normalized := sets.New[Platform]()
+	for platform := range platforms {
+		normalized.Insert(a.normalizePlatform(platform))
+	}
+	return normalized
+}
@@ -0,0 +1,82 @@
+package image
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+func Test_normalizePlatform(t *testing.T) {
+	aliases := []v1beta1.ArchitectureAlias{
+		{Name: "armv8l", Architecture: "arm", Variant: "v8"},
+		{Name: "x86_64", Architecture: "amd64", Variant: "v2"},
+	}
+	tests := []struct {
+		name     string
+		platform Platform
+		expected Platform
+	}{
+		{
+			name:     "canonical architectures are not changed",
+			platform: Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureArm64, Variant: "v8"},
+			expected: Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureArm64, Variant: "v8"},
+		},
+		{
+			name:     "built-in aliases are normalized",
+			platform: Platform{OS: utils.OSLinux, Architecture: "aarch64"},
+			expected: Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureArm64},
+		},
+		{
+			name:     "the variant implied by the alias applies if the platform does not report one",
+			platform: Platform{OS: utils.OSLinux, Architecture: "armhf"},
+			expected: Platform{OS: utils.OSLinux, Architecture: "arm", Variant: "v7"},
+		},
+		{
+			name:     "the variant reported by the platform is kept",
+			platform: Platform{OS: utils.OSLinux, Architecture: "armhf", Variant: "v6"},
+			expected: Platform{OS: utils.OSLinux, Architecture: "arm", Variant: "v6"},
+		},
+		{
+			name:     "configured aliases are normalized",
+			platform: Platform{OS: utils.OSLinux, Architecture: "armv8l"},
+			expected: Platform{OS: utils.OSLinux, Architecture: "arm", Variant: "v8"},
+		},
+		{
+			name:     "configured aliases override the built-in ones",
+			platform: Platform{OS: utils.OSLinux, Architecture: "x86_64"},
+			expected: Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureAmd64, Variant: "v2"},
+		},
+	}
+	a := newArchitectures()
+	a.configure(nil, aliases)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.normalizePlatform(tt.platform); got != tt.expected {
+				t.Errorf("normalizePlatform() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_architectures_configure(t *testing.T) {
+	a := newArchitectures()
+	if a.configure(nil, nil) {
+		t.Errorf("expected the default configuration not to change")
+	}
+	supportedArchitectures := sets.New[string](utils.ArchitectureAmd64, "riscv64")
+	if !a.configure(supportedArchitectures, nil) {
+		t.Errorf("expected the configuration to change")
+	}
+	if !a.supported().Equal(supportedArchitectures) {
+		t.Errorf("unexpected supported architectures %v", sets.List(a.supported()))
+	}
+	if a.configure(supportedArchitectures, nil) {
+		t.Errorf("expected the configuration not to change")
+	}
+	if !a.configure(nil, nil) || !a.supported().Equal(utils.AllSupportedArchitecturesSet()) {
+		t.Errorf("expected the default supported architectures to be restored")
+	}
+}
@@ -0,0 +1,417 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/types"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+const (
+	// defaultPath is the PATH of the images whose config does not set one, as in the container runtimes.
+	defaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	// binaryVerificationCacheSize is the number of manifests whose binary architectures are kept in memory.
+	binaryVerificationCacheSize = 1024
+	// maxVerifiedImageSize is the size of the layers of an image above which its binaries are not verified.
+	maxVerifiedImageSize = 2 << 30
+	// elfHeaderSize is the size of the ELF identification and of the e_type and e_machine fields.
+	elfHeaderSize = 20
+	// whiteoutPrefix and whiteoutOpaqueDir are the markers of the deleted files and directories in the layers.
+	whiteoutPrefix    = ".wh."
+	whiteoutOpaqueDir = ".wh..wh..opq"
+
+	binaryVerificationResultMatch        = "match"
+	binaryVerificationResultMismatch     = "mismatch"
+	binaryVerificationResultInconclusive = "inconclusive"
+	binaryVerificationResultError        = "error"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	// elfCompatibleArchitectures maps the architectures of the binaries to the other architectures able to run them.
+	elfCompatibleArchitectures = map[string][]string{
+		"386": {utils.ArchitectureAmd64},
+	}
+)
+
+// manifestInstance is a single-architecture manifest of an image, with the platform the image declares for it.
+type manifestInstance struct {
+	digest   digest.Digest
+	platform Platform
+}
+
+// BinaryArchitectureMismatch describes a platform declared by an image but not supported by its binaries.
+type BinaryArchitectureMismatch struct {
+	// ImageReference is the reference of the image, e.g., docker://quay.io/openshift/origin-cli:latest.
+	ImageReference string
+	// Digest is the digest of the manifest of the platform.
+	Digest digest.Digest
+	// Platform is the platform declared by the image.
+	Platform Platform
+	// DetectedArchitectures are the architectures supported by all the binaries of the manifest. It is empty if the
+	// binaries do not share any architecture.
+	DetectedArchitectures []string
+}
+
+type binaryArchitectureMismatchHandlerKey struct{}
+
+// WithBinaryArchitectureMismatchHandler returns a copy of ctx in which the inspections call handler for each
+// platform dropped by the binary verification. As the inspection results are cached, the mismatches are only
+// reported to the caller triggering the inspection. The handler may be called concurrently.
+func WithBinaryArchitectureMismatchHandler(ctx context.Context, handler func(BinaryArchitectureMismatch)) context.Context {
+	return context.WithValue(ctx, binaryArchitectureMismatchHandlerKey{}, handler)
+}
+
+// binaryVerification verifies the architectures of the ELF binaries of the images matching its patterns: the
+// entrypoint and the executables in the PATH of the images are read from their layers.
+type binaryVerification struct {
+	mutex    sync.RWMutex
+	patterns []string
+	// results maps the digests of the manifests to the architectures supported by all their binaries, or to nil if
+	// they do not hold any ELF binary. Digests are immutable.
+	results *expirable.LRU[digest.Digest, sets.Set[string]]
+}
+
+// newBinaryVerification returns a binaryVerification disabled until patterns are configured.
+func newBinaryVerification() *binaryVerification {
+	return &binaryVerification{
+		results: expirable.NewLRU[digest.Digest, sets.Set[string]](binaryVerificationCacheSize, nil, 0),
+	}
+}
+
+// configure sets the patterns of the images whose binaries are verified. It returns true if they changed.
+func (v *binaryVerification) configure(config *v1beta1.BinaryVerificationConfig) bool {
+	var patterns []string
+	if config != nil {
+		patterns = slices.Clone(config.ImagePatterns)
+	}
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if slices.Equal(v.patterns, patterns) {
+		return false
+	}
+	v.patterns = patterns
+	return true
+}
+
+// imagePatterns returns the patterns of the images whose binaries are verified.
+func (v *binaryVerification) imagePatterns() []string {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	return v.patterns
+}
+
+// enabledFor returns true if the binaries of the given image are verified.
+func (v *binaryVerification) enabledFor(imageReference string) bool {
+	v.mutex.RLock()
+	patterns := v.patterns
+	v.mutex.RUnlock()
+	if len(patterns) == 0 {
+		return false
+	}
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(imageReference, "//"))
+	if err != nil {
+		return false
+	}
+	repository := named.Name()
+	for _, pattern := range patterns {
+		if matched, err := URLsMatchStr(pattern, repository); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// verify returns the platforms of the given instances supported by their binaries. The platforms whose binaries
+// cannot be verified, e.g., because they do not hold any ELF binary or their layers cannot be fetched, are kept.
+// Each platform dropped is logged, counted and reported to the handler of ctx, if any.
+func (v *binaryVerification) verify(ctx context.Context, sys *types.SystemContext, src types.ImageSource,
+	imageReference string, instances []manifestInstance) sets.Set[Platform] {
+	log := ctrllog.FromContext(ctx)
+	platforms := sets.New[Platform]()
+	for _, instance := range instances {
+		detected, err := v.binaryArchitectures(ctx, sys, src, instance.digest)
+		switch {
+		case err != nil:
+			log.V(1).Info("Unable to verify the binaries of the image", "digest", instance.digest,
+				"error", err.Error())
+			metrics.BinaryVerifications.WithLabelValues(binaryVerificationResultError).Inc()
+		case detected == nil:
+			log.V(3).Info("No ELF binary to verify in the image", "digest", instance.digest)
+			metrics.BinaryVerifications.WithLabelValues(binaryVerificationResultInconclusive).Inc()
+		case detected.Has(instance.platform.Architecture):
+			metrics.BinaryVerifications.WithLabelValues(binaryVerificationResultMatch).Inc()
+		default:
+			mismatch := BinaryArchitectureMismatch{
+				ImageReference:        imageReference,
+				Digest:                instance.digest,
+				Platform:              instance.platform,
+				DetectedArchitectures: sets.List(detected),
+			}
+			log.Info("Dropping a platform not supported by the binaries of the image", "digest", mismatch.Digest,
+				"platform", mismatch.Platform.String(), "detectedArchitectures", mismatch.DetectedArchitectures)
+			metrics.BinaryVerifications.WithLabelValues(binaryVerificationResultMismatch).Inc()
+			if handler, ok := ctx.Value(binaryArchitectureMismatchHandlerKey{}).(func(BinaryArchitectureMismatch)); ok {
+				handler(mismatch)
+			}
+			continue
+		}
+		platforms.Insert(instance.platform)
+	}
+	return platforms
+}
+
+// binaryArchitectures returns the architectures supported by all the ELF binaries of the manifest of the given
+// digest, or nil if it does not hold any. The results are cached by digest.
+func (v *binaryVerification) binaryArchitectures(ctx context.Context, sys *types.SystemContext,
+	src types.ImageSource, instanceDigest digest.Digest) (sets.Set[string], error) {
+	if detected, ok := v.results.Get(instanceDigest); ok {
+		return detected, nil
+	}
+	parsedImage, err := image.FromUnparsedImage(ctx, sys, image.UnparsedInstance(src, &instanceDigest))
+	if err != nil {
+		return nil, err
+	}
+	config, err := parsedImage.OCIConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	layers := parsedImage.LayerInfos()
+	var size int64
+	for _, layer := range layers {
+		size += max(layer.Size, 0)
+	}
+	if size > maxVerifiedImageSize {
+		return nil, errors.New("the layers of the image are too large to verify its binaries")
+	}
+	scanner := newBinaryScanner(config.Config)
+	// The upper layers hide the files of the lower ones: they are scanned first.
+	for i := len(layers) - 1; i >= 0; i-- {
+		if err = scanner.scanLayer(ctx, src, layers[i]); err != nil {
+			return nil, err
+		}
+	}
+	v.results.Add(instanceDigest, scanner.detected)
+	return scanner.detected, nil
+}
+
+// binaryScanner computes the architectures supported by the binaries of an image from its layers, from the upper to
+// the lower one.
+type binaryScanner struct {
+	// dirs are the directories of the PATH of the image, and files the absolute paths of its entrypoint.
+	dirs, files sets.Set[string]
+	// seen are the paths found, or deleted, in the upper layers, and opaque the directories whose content in the
+	// lower layers is hidden.
+	seen, opaque sets.Set[string]
+	// detected are the architectures supported by all the binaries scanned so far, or nil if none was.
+	detected sets.Set[string]
+}
+
+func newBinaryScanner(config ociv1.ImageConfig) *binaryScanner {
+	s := &binaryScanner{dirs: sets.New[string](), files: sets.New[string](), seen: sets.New[string](),
+		opaque: sets.New[string]()}
+	pathEnv := defaultPath
+	for _, env := range config.Env {
+		if value, ok := strings.CutPrefix(env, "PATH="); ok {
+			pathEnv = value
+		}
+	}
+	for _, dir := range strings.Split(pathEnv, ":") {
+		if path.IsAbs(dir) {
+			s.dirs.Insert(path.Clean(dir))
+		}
+	}
+	entrypoint := config.Entrypoint
+	if len(entrypoint) == 0 {
+		entrypoint = config.Cmd
+	}
+	// The bare names of the entrypoints are looked up in the PATH.
+	if len(entrypoint) > 0 && strings.Contains(entrypoint[0], "/") {
+		workingDir := config.WorkingDir
+		if workingDir == "" {
+			workingDir = "/"
+		}
+		if path.IsAbs(entrypoint[0]) {
+			s.files.Insert(path.Clean(entrypoint[0]))
+		} else {
+			s.files.Insert(path.Join(workingDir, entrypoint[0]))
+		}
+	}
+	return s
+}
+
+// scanLayer reads the ELF headers of the binaries of the given layer not hidden by the upper layers.
+func (s *binaryScanner) scanLayer(ctx context.Context, src types.ImageSource, layer types.BlobInfo) error {
+	blob, _, err := src.GetBlob(ctx, layer, none.NoCache)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = blob.Close() }()
+	reader, err := decompressedReader(blob)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reader.Close() }()
+	tarReader := tar.NewReader(reader)
+	opaque := sets.New[string]()
+	header := make([]byte, elfHeaderSize)
+	for {
+		entry, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean("/" + entry.Name)
+		dir, base := path.Split(name)
+		dir = path.Clean(dir)
+		if base == whiteoutOpaqueDir {
+			opaque.Insert(dir)
+			continue
+		}
+		if deleted, ok := strings.CutPrefix(base, whiteoutPrefix); ok {
+			s.seen.Insert(path.Join(dir, deleted))
+			continue
+		}
+		if s.seen.Has(name) || s.hidden(name) {
+			continue
+		}
+		s.seen.Insert(name)
+		if entry.Typeflag != tar.TypeReg || entry.Mode&0o111 == 0 || (!s.files.Has(name) && !s.dirs.Has(dir)) {
+			continue
+		}
+		if _, err = io.ReadFull(tarReader, header); err != nil {
+			continue
+		}
+		architecture, ok := elfArchitecture(header)
+		if !ok {
+			continue
+		}
+		supported := sets.New(architecture).Insert(elfCompatibleArchitectures[architecture]...)
+		if s.detected == nil {
+			s.detected = supported
+		} else {
+			s.detected = s.detected.Intersection(supported)
+		}
+	}
+	// The opaque directories only hide the content of the lower layers.
+	s.opaque = s.opaque.Union(opaque)
+	return nil
+}
+
+// hidden returns true if the given path is in an opaque directory of an upper layer.
+func (s *binaryScanner) hidden(name string) bool {
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		if s.opaque.Has(dir) {
+			return true
+		}
+		if dir == "/" {
+			return false
+		}
+	}
+}
+
+// decompressedReader returns a reader of the uncompressed content of a gzip, zstd or uncompressed layer.
+func decompressedReader(blob io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(blob)
+	magic, _ := buffered.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(buffered)
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return io.NopCloser(buffered), nil
+	}
+}
+
+// elfArchitecture returns the architecture, as reported by the kubernetes.io/arch node label, of the ELF binary
+// starting with the given header. It returns false if the header is not the one of an ELF binary of a known
+// architecture.
+func elfArchitecture(header []byte) (string, bool) {
+	if len(header) < elfHeaderSize || !bytes.HasPrefix(header, []byte(elf.ELFMAG)) {
+		return "", false
+	}
+	var order binary.ByteOrder
+	switch elf.Data(header[elf.EI_DATA]) {
+	case elf.ELFDATA2LSB:
+		order = binary.LittleEndian
+	case elf.ELFDATA2MSB:
+		order = binary.BigEndian
+	default:
+		return "", false
+	}
+	is64Bit := elf.Class(header[elf.EI_CLASS]) == elf.ELFCLASS64
+	switch elf.Machine(order.Uint16(header[18:20])) {
+	case elf.EM_X86_64:
+		return utils.ArchitectureAmd64, true
+	case elf.EM_386:
+		return "386", true
+	case elf.EM_AARCH64:
+		return utils.ArchitectureArm64, true
+	case elf.EM_ARM:
+		return "arm", true
+	case elf.EM_PPC64:
+		if order == binary.LittleEndian {
+			return utils.ArchitecturePpc64le, true
+		}
+		return "ppc64", true
+	case elf.EM_S390:
+		if is64Bit {
+			return utils.ArchitectureS390x, true
+		}
+		return "s390", true
+	case elf.EM_RISCV:
+		if is64Bit {
+			return "riscv64", true
+		}
+	}
+	return "", false
+}
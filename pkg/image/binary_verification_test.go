@@ -0,0 +1,273 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// elfHeader returns the first bytes of an ELF binary of the given class, byte order and machine.
+func elfHeader(class elf.Class, data elf.Data, machine elf.Machine) []byte {
+	header := make([]byte, elfHeaderSize)
+	copy(header, elf.ELFMAG)
+	header[elf.EI_CLASS], header[elf.EI_DATA] = byte(class), byte(data)
+	if data == elf.ELFDATA2MSB {
+		binary.BigEndian.PutUint16(header[18:], uint16(machine))
+	} else {
+		binary.LittleEndian.PutUint16(header[18:], uint16(machine))
+	}
+	return append(header, "binary"...)
+}
+
+// addLayer adds a gzip-compressed layer holding the given executable files. The files with an empty content are
+// whiteouts.
+func (b *ociLayoutBuilder) addLayer(t *testing.T, files map[string][]byte) ociv1.Descriptor {
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	writer := tar.NewWriter(gzipWriter)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(files[name])),
+			Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write(files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	d := digest.FromBytes(buffer.Bytes())
+	b.files[filepath.Join(ociLayoutBlobsDir, d.Algorithm().String(), d.Encoded())] = buffer.Bytes()
+	return ociv1.Descriptor{MediaType: ociv1.MediaTypeImageLayerGzip, Digest: d, Size: int64(buffer.Len())}
+}
+
+// addImageWithLayers adds an image of the given declared architecture and config, with the given layers.
+func (b *ociLayoutBuilder) addImageWithLayers(t *testing.T, architecture string, config ociv1.ImageConfig,
+	layers ...ociv1.Descriptor) ociv1.Descriptor {
+	configDescriptor := b.addBlob(t, ociv1.MediaTypeImageConfig, ociv1.Image{
+		Platform: ociv1.Platform{OS: "linux", Architecture: architecture},
+		Config:   config,
+		RootFS:   ociv1.RootFS{Type: "layers"},
+	})
+	m := ociv1.Manifest{MediaType: ociv1.MediaTypeImageManifest, Config: configDescriptor, Layers: layers}
+	m.SchemaVersion = 2
+	return b.addBlob(t, ociv1.MediaTypeImageManifest, m)
+}
+
+func Test_elfArchitecture(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+		wantOk bool
+	}{
+		{name: "x86-64", header: elfHeader(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64), want: utils.ArchitectureAmd64, wantOk: true},
+		{name: "i386", header: elfHeader(elf.ELFCLASS32, elf.ELFDATA2LSB, elf.EM_386), want: "386", wantOk: true},
+		{name: "aarch64", header: elfHeader(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_AARCH64), want: utils.ArchitectureArm64, wantOk: true},
+		{name: "ppc64le", header: elfHeader(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_PPC64), want: utils.ArchitecturePpc64le, wantOk: true},
+		{name: "ppc64", header: elfHeader(elf.ELFCLASS64, elf.ELFDATA2MSB, elf.EM_PPC64), want: "ppc64", wantOk: true},
+		{name: "s390x", header: elfHeader(elf.ELFCLASS64, elf.ELFDATA2MSB, elf.EM_S390), want: utils.ArchitectureS390x, wantOk: true},
+		{name: "riscv64", header: elfHeader(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_RISCV), want: "riscv64", wantOk: true},
+		{name: "unknown machine", header: elfHeader(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_SPARCV9)},
+		{name: "script", header: []byte("#!/bin/sh\necho hello world\n")},
+		{name: "truncated", header: elfHeader(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64)[:10]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := elfArchitecture(tt.header)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("elfArchitecture() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_binaryVerification_enabledFor(t *testing.T) {
+	v := newBinaryVerification()
+	if v.enabledFor("//quay.io/openshift/origin:latest") {
+		t.Error("expected the binary verification to be disabled without patterns")
+	}
+	if !v.configure(&v1beta1.BinaryVerificationConfig{ImagePatterns: []string{"quay.io/openshift", "*.example.com"}}) {
+		t.Error("expected the patterns to change")
+	}
+	for imageReference, want := range map[string]bool{
+		"//quay.io/openshift/origin:latest":                             true,
+		"//quay.io/openshift/origin@" + digest.FromString("a").String(): true,
+		"//quay.io/other/origin:latest":                                 false,
+		"//registry.example.com/app:latest":                             true,
+		"//example.com/app:latest":                                      false,
+		"//docker.io/library/busybox":                                   false,
+	} {
+		if got := v.enabledFor(imageReference); got != want {
+			t.Errorf("enabledFor(%q) = %v, want %v", imageReference, got, want)
+		}
+	}
+	if v.configure(&v1beta1.BinaryVerificationConfig{ImagePatterns: []string{"quay.io/openshift", "*.example.com"}}) {
+		t.Error("expected the patterns not to change")
+	}
+	if !v.configure(nil) || v.enabledFor("//quay.io/openshift/origin:latest") {
+		t.Error("expected the binary verification to be disabled")
+	}
+}
+
+func Test_inspectSource_binaryVerification(t *testing.T) {
+	metrics.InitCommonMetrics()
+	usePolicyConf(t, `{"default": [{"type": "insecureAcceptAnything"}]}`)
+	root := t.TempDir()
+
+	amd64 := elfHeader(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64)
+	i386 := elfHeader(elf.ELFCLASS32, elf.ELFDATA2LSB, elf.EM_386)
+	arm64 := elfHeader(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_AARCH64)
+	script := []byte("#!/bin/sh\nexec /usr/bin/app\n")
+
+	layout := newOCILayoutBuilder()
+	tag := func(name, architecture string, config ociv1.ImageConfig, layers ...map[string][]byte) ociv1.Descriptor {
+		descriptors := make([]ociv1.Descriptor, 0, len(layers))
+		for _, files := range layers {
+			descriptors = append(descriptors, layout.addLayer(t, files))
+		}
+		descriptor := layout.addImageWithLayers(t, architecture, config, descriptors...)
+		layout.tag(descriptor, name)
+		return descriptor
+	}
+	tag("match", utils.ArchitectureAmd64, ociv1.ImageConfig{Entrypoint: []string{"/opt/app/run"}},
+		map[string][]byte{"usr/bin/ls": amd64, "usr/lib/libfoo.so": arm64, "opt/app/run": i386, "opt/app/data": arm64})
+	mislabelled := tag("mislabelled", utils.ArchitectureAmd64, ociv1.ImageConfig{},
+		map[string][]byte{"usr/bin/ls": arm64, "./usr/local/bin/app": arm64})
+	tag("mixed", utils.ArchitectureAmd64, ociv1.ImageConfig{},
+		map[string][]byte{"usr/bin/ls": amd64}, map[string][]byte{"usr/local/bin/app": arm64})
+	tag("whiteout", utils.ArchitectureAmd64, ociv1.ImageConfig{Env: []string{"PATH=/usr/local/bin:/usr/bin:/opt/bin"}},
+		map[string][]byte{"usr/bin/ls": amd64, "usr/local/bin/app": arm64, "opt/bin/tool": arm64},
+		map[string][]byte{"usr/local/bin/.wh.app": nil, "opt/bin/.wh..wh..opq": nil})
+	tag("custom-path", utils.ArchitectureAmd64, ociv1.ImageConfig{Env: []string{"PATH=/opt/bin"}},
+		map[string][]byte{"usr/bin/ls": arm64, "opt/bin/tool": amd64})
+	tag("scripts", utils.ArchitectureAmd64, ociv1.ImageConfig{Entrypoint: []string{"./run.sh"}, WorkingDir: "/app"},
+		map[string][]byte{"app/run.sh": script, "usr/bin/app": script})
+
+	index := ociv1.Index{MediaType: ociv1.MediaTypeImageIndex}
+	index.SchemaVersion = 2
+	for _, entry := range []struct {
+		architecture string
+		binary       []byte
+	}{
+		{utils.ArchitectureAmd64, amd64},
+		{utils.ArchitectureArm64, amd64},
+	} {
+		descriptor := layout.addImageWithLayers(t, entry.architecture, ociv1.ImageConfig{},
+			layout.addLayer(t, map[string][]byte{"usr/bin/app": entry.binary}))
+		descriptor.Platform = &ociv1.Platform{OS: "linux", Architecture: entry.architecture}
+		index.Manifests = append(index.Manifests, descriptor)
+	}
+	layout.tag(layout.addBlob(t, ociv1.MediaTypeImageIndex, index), "list")
+	layout.writeDir(t, filepath.Join(root, "origin"))
+	layout.writeDir(t, filepath.Join(root, "other", "origin"))
+
+	s := newInspectionState()
+	s.offlineSources.configure(root, &v1beta1.OfflineImageSourcesConfig{Sources: []v1beta1.OfflineImageSource{
+		{Prefix: "quay.io/openshift", Mode: v1beta1.OfflineImageSourceModeOfflineOnly},
+		{Prefix: "quay.io/other", Path: "other", Mode: v1beta1.OfflineImageSourceModeOfflineOnly},
+	}})
+	s.binaryVerification.configure(&v1beta1.BinaryVerificationConfig{ImagePatterns: []string{"quay.io/openshift"}})
+	var mutex sync.Mutex
+	var mismatches []BinaryArchitectureMismatch
+	ctx := WithBinaryArchitectureMismatchHandler(context.Background(), func(m BinaryArchitectureMismatch) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		mismatches = append(mismatches, m)
+	})
+
+	tests := []struct {
+		name           string
+		imageReference string
+		wantPlatforms  sets.Set[Platform]
+		wantMismatches int
+	}{
+		{
+			name:           "binaries of the declared architecture",
+			imageReference: "//quay.io/openshift/origin:match",
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+		},
+		{
+			name:           "binaries of another architecture",
+			imageReference: "//quay.io/openshift/origin:mislabelled",
+			wantPlatforms:  sets.New[Platform](),
+			wantMismatches: 1,
+		},
+		{
+			name:           "binaries of several architectures across the layers",
+			imageReference: "//quay.io/openshift/origin:mixed",
+			wantPlatforms:  sets.New[Platform](),
+			wantMismatches: 1,
+		},
+		{
+			name:           "binaries of another architecture deleted by an upper layer",
+			imageReference: "//quay.io/openshift/origin:whiteout",
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+		},
+		{
+			name:           "binaries out of the PATH of the image",
+			imageReference: "//quay.io/openshift/origin:custom-path",
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+		},
+		{
+			name:           "no ELF binary",
+			imageReference: "//quay.io/openshift/origin:scripts",
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+		},
+		{
+			name:           "manifest list with a lying entry",
+			imageReference: "//quay.io/openshift/origin:list",
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+			wantMismatches: 1,
+		},
+		{
+			name:           "image not matching the patterns",
+			imageReference: "//quay.io/other/origin:mislabelled",
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatches = nil
+			result, _, err := s.inspectOffline(ctx, tt.imageReference)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.platforms.Equal(tt.wantPlatforms) {
+				t.Errorf("expected the platforms %v, got %v", tt.wantPlatforms, result.platforms)
+			}
+			if len(mismatches) != tt.wantMismatches {
+				t.Errorf("expected %d mismatches, got %v", tt.wantMismatches, mismatches)
+			}
+		})
+	}
+
+	detected, ok := s.binaryVerification.results.Get(mislabelled.Digest)
+	if !ok || !detected.Equal(sets.New[string](utils.ArchitectureArm64)) {
+		t.Errorf("expected the architectures of the binaries to be cached by digest, got %v, %v", detected, ok)
+	}
+}
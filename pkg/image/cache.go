@@ -20,72 +20,325 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
 	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
 	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
 
 	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/sync/singleflight"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+const (
+	defaultTagCacheSize    = 256
+	defaultTagTTL          = time.Hour * 6
+	defaultDigestCacheSize = 1024
+	defaultDigestTTL       = time.Hour * 24 * 7
+	// inflightLookupTimeout bounds the lookups shared by the coalesced callers, as they are not bound by the context
+	// of any of them.
+	inflightLookupTimeout = 5 * time.Minute
+)
+
+// cacheConfig is the effective configuration of the two levels of the cache.
+type cacheConfig struct {
+	tagCacheSize    int
+	tagTTL          time.Duration
+	digestCacheSize int
+	digestTTL       time.Duration
+}
+
+// tagCacheEntry is the value of the tag-to-digest level of the cache. Entries are never modified once added.
+type tagCacheEntry struct {
+	digest digest.Digest
+	// authorizedCredentials is the set of the hashes of the credentials that were successfully used to access the
+	// image reference. Credentials do not fragment the cache: they are only used to authorize access to the entry.
+	authorizedCredentials sets.Set[string]
+}
+
 type cacheProxy struct {
 	registryInspector IRegistryInspector
-	imageRefsCache    *expirable.LRU[string, sets.Set[string]] // LRU cache with expirable keys
+	// state is the configuration of the inspections, shared with the registryInspector.
+	state *inspectionState
+	// mutex protects the caches and their configuration from concurrent reconfiguration
+	mutex  sync.RWMutex
+	config cacheConfig
+	// tagCache maps the image references to the digest they resolve to
+	tagCache *expirable.LRU[string, *tagCacheEntry]
+	// digestCache maps the digests to the result of their inspection, e.g., the set of platforms they support.
+	// Digests are immutable.
+	digestCache *expirable.LRU[digest.Digest, *inspectionResult]
+	// store is the shared, persistent, digest-keyed store of the inspection results. It is nil when disabled.
+	store IArchitectureStore
+	// inflight coalesces the concurrent lookups of the same image reference
+	inflight singleflight.Group
 }
 
 func (c *cacheProxy) GetCompatibleArchitecturesSet(ctx context.Context, imageReference string,
 	skipCache bool, secrets [][]byte) (sets.Set[string], error) {
+	platforms, err := c.GetCompatiblePlatformsSet(ctx, imageReference, skipCache, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return ArchitecturesOf(platforms), nil
+}
+
+// GetCompatiblePlatformsSet returns the set of platforms supported by the given image reference. Concurrent
+// lookups of the same image reference, with the same credentials and imagePullPolicy, are coalesced: only one of them
+// reaches the caches and the registry, and the others wait for and share its result. The shared lookup is not
+// canceled with the context of the caller that started it: each caller stops waiting when its own context is done.
+func (c *cacheProxy) GetCompatiblePlatformsSet(ctx context.Context, imageReference string,
+	skipCache bool, secrets [][]byte) (sets.Set[Platform], error) {
 	metrics.InitCommonMetrics()
-	metrics.InspectionGauge.Set(float64(c.imageRefsCache.Len()))
-	now := time.Now()
-	authJSON, err := marshaledImagePullSecrets(imageReference, secrets)
+	authJSON, err := c.state.marshaledImagePullSecrets(ctx, imageReference, secrets)
 	if err != nil {
 		return nil, err
 	}
+	credentialsHash := computeHash(authJSON)
+	key := computeHash([]byte(imageReference), []byte(strconv.FormatBool(skipCache)), []byte(credentialsHash))
+	// executed is only read once the result is received, after the lookup set it.
+	executed := false
+	resultChan := c.inflight.DoChan(key, func() (any, error) {
+		executed = true
+		lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), inflightLookupTimeout)
+		defer cancel()
+		return c.getInspectionResult(lookupCtx, imageReference, skipCache, secrets, credentialsHash)
+	})
+	var lookup singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case lookup = <-resultChan:
+	}
+	if !executed {
+		metrics.CoalescedInspections.Inc()
+		ctrllog.FromContext(ctx).V(3).Info("Coalesced with an in-flight lookup", "imageReference", imageReference)
+	}
+	if lookup.Err != nil {
+		return nil, lookup.Err
+	}
+	result := lookup.Val.(*inspectionResult)
+	// The rule, the manifest list discrepancies and the digest are reported to every caller, including the ones
+	// hitting the cache or coalesced with the lookup.
+	if rule := result.architectureAgnosticRule; rule != "" {
+		reportArchitectureAgnosticRule(ctx, imageReference, rule)
+	}
+	reportManifestListDiscrepancies(ctx, imageReference, result.discrepancies)
+	reportInspectedDigest(ctx, c.state.registriesConfig, imageReference, result.digest)
+	return result.platforms, nil
+}
+
+// getInspectionResult returns the result of the inspection of the given image reference from the caches, the store
+// or the registry.
+func (c *cacheProxy) getInspectionResult(ctx context.Context, imageReference string,
+	skipCache bool, secrets [][]byte, credentialsHash string) (*inspectionResult, error) {
+	c.mutex.RLock()
+	tagCache, digestCache, store := c.tagCache, c.digestCache, c.store
+	c.mutex.RUnlock()
+	metrics.InspectionGauge.Set(float64(digestCache.Len()))
+	metrics.TagCacheGauge.Set(float64(tagCache.Len()))
+	now := time.Now()
 
 	log := ctrllog.FromContext(ctx).WithValues("imageReference", imageReference)
-	hash := computeHash(imageReference, authJSON)
-	if architectures, ok := c.imageRefsCache.Get(hash); ok && !skipCache {
-		log.V(3).Info("Cache hit", "architectures", architectures, "hash", hash)
+	// The image API knows the digest of the images of the internal registry, and often their platforms. The images
+	// whose binaries are verified are inspected anyway.
+	resolved := c.state.imageStreams.resolve(ctx, imageReference)
+	if resolved != nil && resolved.platforms != nil && !c.state.binaryVerification.enabledFor(imageReference) {
+		log.V(3).Info("Image API hit", "platforms", resolved.platforms, "digest", resolved.digest)
+		result := &inspectionResult{digest: resolved.digest, platforms: resolved.platforms}
+		digestCache.Add(resolved.digest, result)
 		defer utils.HistogramObserve(now, metrics.TimeToInspectImageGivenHit)
-		return architectures, nil
+		return result, nil
 	}
-	architectures, err := c.registryInspector.GetCompatibleArchitecturesSet(ctx, imageReference, true, secrets)
-	if err != nil {
-		return nil, err
+	var entry *tagCacheEntry
+	var ok bool
+	var d digest.Digest
+	var err error
+	if resolved != nil {
+		// Missing the platforms, the pull spec of the image is inspected on a cache miss.
+		d, imageReference = resolved.digest, resolved.pullSpec
+		log = log.WithValues("pullSpec", imageReference)
+	} else if pinned, isPinned := digestFromReference(imageReference); isPinned {
+		// The digest of the digest-pinned references is known without a manifest HEAD request, which would fail when
+		// only the mirrors serve the image.
+		d = digest.Digest(pinned)
+	} else if entry, ok = tagCache.Get(imageReference); ok && !skipCache &&
+		entry.authorizedCredentials.Has(credentialsHash) {
+		d = entry.digest
+	} else {
+		// The entry is missing, expired, the pod requires the image to be pulled (imagePullPolicy: Always) or the
+		// credentials were never used to access the image reference: revalidate the entry with a manifest HEAD request.
+		metrics.TagRevalidations.Inc()
+		d, err = c.registryInspector.headDigest(ctx, imageReference, secrets)
+		if err != nil {
+			// The HEAD request does not consider the mirrors: fall back to the full inspection.
+			log.V(3).Info("Unable to revalidate the digest with a manifest HEAD request", "error", err.Error())
+		} else {
+			c.authorize(tagCache, imageReference, d, entry, credentialsHash)
+		}
 	}
 
-	log.V(3).Info("Cache miss...adding to cache", "architectures", architectures, "hash", hash)
-	if !skipCache {
-		c.imageRefsCache.Add(hash, architectures)
+	if d != "" {
+		if result, ok := digestCache.Get(d); ok {
+			log.V(3).Info("Cache hit", "platforms", result.platforms, "digest", d)
+			defer utils.HistogramObserve(now, metrics.TimeToInspectImageGivenHit)
+			return result, nil
+		}
+		// The platforms of a digest only change with the inspection configuration: they can be retrieved from the shared
+		// store, which ignores the objects inspected with a different configuration.
+		if store != nil {
+			if result, ok := store.get(ctx, d.String()); ok {
+				log.V(3).Info("ImageArchitecture store hit", "platforms", result.platforms, "digest", d)
+				digestCache.Add(d, result)
+				defer utils.HistogramObserve(now, metrics.TimeToInspectImageGivenHit)
+				return result, nil
+			}
+		}
+	}
+
+	result, err := c.registryInspector.inspect(ctx, imageReference, secrets)
+	if err != nil {
+		return nil, newInspectionError(err)
+	}
+	log.V(3).Info("Cache miss...adding to cache", "platforms", result.platforms, "digest", result.digest)
+	digestCache.Add(result.digest, result)
+	c.authorize(tagCache, imageReference, result.digest, entry, credentialsHash)
+	if store != nil {
+		store.store(ctx, imageReference, result)
 	}
 	defer utils.HistogramObserve(now, metrics.TimeToInspectImageGivenMiss)
-	return architectures, nil
+	return result, nil
+}
+
+// authorize records that the image reference resolves to the given digest and that the credentials identified by
+// credentialsHash can access it. The credentials previously authorized are kept if the digest did not change.
+func (c *cacheProxy) authorize(tagCache *expirable.LRU[string, *tagCacheEntry], imageReference string,
+	d digest.Digest, previous *tagCacheEntry, credentialsHash string) {
+	authorizedCredentials := sets.New[string](credentialsHash)
+	if previous != nil && previous.digest == d {
+		authorizedCredentials = authorizedCredentials.Union(previous.authorizedCredentials)
+	}
+	tagCache.Add(imageReference, &tagCacheEntry{
+		digest:                d,
+		authorizedCredentials: authorizedCredentials,
+	})
 }
 
 func (c *cacheProxy) GetRegistryInspector() IRegistryInspector {
 	return c.registryInspector
 }
 
-// clearCache purges the image metadata cache
+// clearDigestCache purges the digest-to-architectures level of the cache, so that the images are inspected again.
+func (c *cacheProxy) clearDigestCache() {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	c.digestCache.Purge()
+}
+
+// clearCache purges the tag-to-digest level of the cache, so that the credentials are authorized again.
+// The digest-to-architectures level is immutable and kept.
 func (c *cacheProxy) clearCache() {
-	c.imageRefsCache.Purge()
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	c.tagCache.Purge()
+}
+
+// purgeReferences removes the tag-to-digest entries of the image references matching any of the given prefixes of
+// the registries configuration, so that they are resolved again, e.g., through the new mirrors. The
+// digest-to-architectures level is immutable and kept.
+func (c *cacheProxy) purgeReferences(prefixes []string) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for _, imageReference := range c.tagCache.Keys() {
+		for _, prefix := range prefixes {
+			if referenceMatchesPrefix(imageReference, prefix) {
+				c.tagCache.Remove(imageReference)
+				break
+			}
+		}
+	}
+}
+
+func (c *cacheProxy) setStore(store IArchitectureStore) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.store = store
+}
+
+// configure applies the given configuration to the cache. The caches are re-created, and their entries dropped,
+// only when the configuration changes.
+func (c *cacheProxy) configure(ctx context.Context, config *v1beta1.ImageInspectionCacheConfig) {
+	desired := newCacheConfig(config)
+	c.mutex.RLock()
+	unchanged := c.config == desired
+	c.mutex.RUnlock()
+	if unchanged {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.config == desired {
+		return
+	}
+	ctrllog.FromContext(ctx).Info("Configuring the image inspection cache", "tagCacheSize", desired.tagCacheSize,
+		"tagTTL", desired.tagTTL, "digestCacheSize", desired.digestCacheSize, "digestTTL", desired.digestTTL)
+	c.config = desired
+	c.tagCache = expirable.NewLRU[string, *tagCacheEntry](desired.tagCacheSize, nil, desired.tagTTL)
+	c.digestCache = expirable.NewLRU[digest.Digest, *inspectionResult](desired.digestCacheSize, nil, desired.digestTTL)
+}
+
+// newCacheConfig returns the effective configuration of the cache, applying the defaults to the unset fields.
+func newCacheConfig(config *v1beta1.ImageInspectionCacheConfig) cacheConfig {
+	cfg := cacheConfig{
+		tagCacheSize:    defaultTagCacheSize,
+		tagTTL:          defaultTagTTL,
+		digestCacheSize: defaultDigestCacheSize,
+		digestTTL:       defaultDigestTTL,
+	}
+	if config == nil {
+		return cfg
+	}
+	if config.TagCacheSize > 0 {
+		cfg.tagCacheSize = int(config.TagCacheSize)
+	}
+	if config.TagTTL != nil && config.TagTTL.Duration > 0 {
+		cfg.tagTTL = config.TagTTL.Duration
+	}
+	if config.DigestCacheSize > 0 {
+		cfg.digestCacheSize = int(config.DigestCacheSize)
+	}
+	if config.DigestTTL != nil && config.DigestTTL.Duration > 0 {
+		cfg.digestTTL = config.DigestTTL.Duration
+	}
+	return cfg
 }
 
 func newCacheProxy() *cacheProxy {
-	return &cacheProxy{
-		registryInspector: newRegistryInspector(),
-		imageRefsCache:    expirable.NewLRU[string, sets.Set[string]](256, nil, time.Hour*6),
+	config := newCacheConfig(nil)
+	state := newInspectionState()
+	c := &cacheProxy{
+		registryInspector: newRegistryInspector(state),
+		state:             state,
+		config:            config,
+		tagCache:          expirable.NewLRU[string, *tagCacheEntry](config.tagCacheSize, nil, config.tagTTL),
+		digestCache:       expirable.NewLRU[digest.Digest, *inspectionResult](config.digestCacheSize, nil, config.digestTTL),
 	}
+	state.registriesConfig.onChange = c.purgeReferences
+	state.offlineSources.onChange = c.purgeReferences
+	return c
 }
 
-func computeHash(imageReference string, secrets []byte) string {
+func computeHash(data ...[]byte) string {
 	h := sha256.New()
-	h.Write([]byte(imageReference))
-	h.Write([]byte{0})
-	h.Write(secrets)
+	for _, d := range data {
+		h.Write(d)
+		h.Write([]byte{0})
+	}
 	return hex.EncodeToString(h.Sum(nil))
 }
@@ -0,0 +1,395 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// countingInspector is a registry inspector that resolves all the image references to the same digest and counts the
+// HEAD requests and the full inspections.
+type countingInspector struct {
+	digest        digest.Digest
+	platforms     sets.Set[Platform]
+	rule          string
+	discrepancies []ManifestListDiscrepancy
+	headErr       error
+	heads         int
+	inspections   int
+}
+
+func (f *countingInspector) GetCompatibleArchitecturesSet(ctx context.Context, imageReference string, _ bool, secrets [][]byte) (sets.Set[string], error) {
+	result, err := f.inspect(ctx, imageReference, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return result.architectures(), nil
+}
+
+func (f *countingInspector) GetCompatiblePlatformsSet(ctx context.Context, imageReference string, _ bool, secrets [][]byte) (sets.Set[Platform], error) {
+	result, err := f.inspect(ctx, imageReference, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return result.platforms, nil
+}
+
+func (f *countingInspector) storeGlobalPullSecret(_ []byte) {}
+
+func (f *countingInspector) inspect(_ context.Context, _ string, _ [][]byte) (*inspectionResult, error) {
+	f.inspections++
+	return &inspectionResult{digest: f.digest, platforms: f.platforms, architectureAgnosticRule: f.rule,
+		discrepancies: f.discrepancies}, nil
+}
+
+func (f *countingInspector) headDigest(_ context.Context, _ string, _ [][]byte) (digest.Digest, error) {
+	f.heads++
+	if f.headErr != nil {
+		return "", f.headErr
+	}
+	return f.digest, nil
+}
+
+func Test_cacheProxy_GetCompatibleArchitecturesSet(t *testing.T) {
+	secret := []byte(`{"auths":{"quay.io":{"auth":"dXNlcjpwYXNz"}}}`)
+	type request struct {
+		imageReference string
+		skipCache      bool
+		secrets        [][]byte
+	}
+	tests := []struct {
+		name                string
+		headErr             error
+		requests            []request
+		expectedHeads       int
+		expectedInspections int
+	}{
+		{
+			name: "tag hit does not revalidate the digest nor inspect the image",
+			requests: []request{
+				{imageReference: "//quay.io/foo/bar:latest"},
+				{imageReference: "//quay.io/foo/bar:latest"},
+			},
+			expectedHeads:       1,
+			expectedInspections: 1,
+		},
+		{
+			name: "imagePullPolicy Always revalidates the digest but reuses the digest cache",
+			requests: []request{
+				{imageReference: "//quay.io/foo/bar:latest"},
+				{imageReference: "//quay.io/foo/bar:latest", skipCache: true},
+			},
+			expectedHeads:       2,
+			expectedInspections: 1,
+		},
+		{
+			name: "tags resolving to the same digest share the digest cache entry",
+			requests: []request{
+				{imageReference: "//quay.io/foo/bar:latest"},
+				{imageReference: "//quay.io/foo/bar:v1"},
+			},
+			expectedHeads:       2,
+			expectedInspections: 1,
+		},
+		{
+			name: "new credentials are authorized with a HEAD request and do not fragment the cache",
+			requests: []request{
+				{imageReference: "//quay.io/foo/bar:latest"},
+				{imageReference: "//quay.io/foo/bar:latest", secrets: [][]byte{secret}},
+				{imageReference: "//quay.io/foo/bar:latest"},
+				{imageReference: "//quay.io/foo/bar:latest", secrets: [][]byte{secret}},
+			},
+			expectedHeads:       2,
+			expectedInspections: 1,
+		},
+		{
+			name:    "digest-pinned references use the digest cache without HEAD requests",
+			headErr: errors.New("the source registry is blocked"),
+			requests: []request{
+				{imageReference: "//quay.io/foo/bar@" + testDigest},
+				{imageReference: "//quay.io/foo/bar@" + testDigest, skipCache: true},
+				{imageReference: "//quay.io/foo/bar:latest@" + testDigest},
+			},
+			expectedHeads:       0,
+			expectedInspections: 1,
+		},
+		{
+			name:    "HEAD failures fall back to the full inspection",
+			headErr: errors.New("HEAD not supported"),
+			requests: []request{
+				{imageReference: "//quay.io/foo/bar:latest"},
+				{imageReference: "//quay.io/foo/bar:latest"},
+			},
+			expectedHeads:       1,
+			expectedInspections: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspector := &countingInspector{
+				digest: digest.Digest(testDigest),
+				platforms: sets.New[Platform](Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureAmd64, Variant: "v3"},
+					Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureArm64}),
+				headErr: tt.headErr,
+			}
+			c := newCacheProxy()
+			c.registryInspector = inspector
+			for _, r := range tt.requests {
+				platforms, err := c.GetCompatiblePlatformsSet(context.Background(), r.imageReference, r.skipCache, r.secrets)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !platforms.Equal(inspector.platforms) {
+					t.Errorf("unexpected platforms %v", platforms.UnsortedList())
+				}
+			}
+			if inspector.heads != tt.expectedHeads {
+				t.Errorf("expected %d HEAD requests, got %d", tt.expectedHeads, inspector.heads)
+			}
+			if inspector.inspections != tt.expectedInspections {
+				t.Errorf("expected %d inspections, got %d", tt.expectedInspections, inspector.inspections)
+			}
+		})
+	}
+}
+
+// blockingInspector is a countingInspector whose HEAD requests block until released.
+type blockingInspector struct {
+	countingInspector
+	started chan struct{}
+	release chan struct{}
+	// ctxErr is the error of the context of the HEAD request once released.
+	ctxErr error
+}
+
+func (f *blockingInspector) headDigest(ctx context.Context, imageReference string, secrets [][]byte) (digest.Digest, error) {
+	close(f.started)
+	<-f.release
+	f.ctxErr = ctx.Err()
+	return f.countingInspector.headDigest(ctx, imageReference, secrets)
+}
+
+func newBlockingInspector() *blockingInspector {
+	return &blockingInspector{
+		countingInspector: countingInspector{
+			digest:    digest.Digest(testDigest),
+			platforms: PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+		},
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+// waitingContext is a context closing waiting when the lookup using it waits for its result, i.e., once it joined
+// the in-flight lookup.
+type waitingContext struct {
+	context.Context
+	waiting chan struct{}
+	once    sync.Once
+}
+
+func newWaitingContext() *waitingContext {
+	return &waitingContext{Context: context.Background(), waiting: make(chan struct{})}
+}
+
+func (c *waitingContext) Done() <-chan struct{} {
+	c.once.Do(func() { close(c.waiting) })
+	return c.Context.Done()
+}
+
+func Test_cacheProxy_GetCompatiblePlatformsSet_coalescing(t *testing.T) {
+	const lookups = 10
+	inspector := newBlockingInspector()
+	c := newCacheProxy()
+	c.registryInspector = inspector
+	var wg sync.WaitGroup
+	errs := make(chan error, lookups)
+	lookup := func(ctx context.Context) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetCompatiblePlatformsSet(ctx, "//quay.io/foo/bar:latest", false, nil)
+			errs <- err
+		}()
+	}
+	lookup(context.Background())
+	<-inspector.started
+	// The other lookups join the in-flight one before it is released.
+	for range lookups - 1 {
+		ctx := newWaitingContext()
+		lookup(ctx)
+		<-ctx.waiting
+	}
+	close(inspector.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if inspector.heads != 1 {
+		t.Errorf("expected 1 HEAD request, got %d", inspector.heads)
+	}
+	if inspector.inspections != 1 {
+		t.Errorf("expected 1 inspection, got %d", inspector.inspections)
+	}
+}
+
+func Test_cacheProxy_GetCompatiblePlatformsSet_leaderCanceled(t *testing.T) {
+	inspector := newBlockingInspector()
+	c := newCacheProxy()
+	c.registryInspector = inspector
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := c.GetCompatiblePlatformsSet(leaderCtx, "//quay.io/foo/bar:latest", false, nil)
+		leaderErr <- err
+	}()
+	<-inspector.started
+	followerCtx := newWaitingContext()
+	type lookup struct {
+		platforms sets.Set[Platform]
+		err       error
+	}
+	follower := make(chan lookup, 1)
+	go func() {
+		platforms, err := c.GetCompatiblePlatformsSet(followerCtx, "//quay.io/foo/bar:latest", false, nil)
+		follower <- lookup{platforms: platforms, err: err}
+	}()
+	<-followerCtx.waiting
+	// The caller that started the lookup stops waiting, while the lookup goes on for the other one.
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the canceled caller to return its context error, got %v", err)
+	}
+	close(inspector.release)
+	result := <-follower
+	if result.err != nil {
+		t.Fatalf("unexpected error: %v", result.err)
+	}
+	if !result.platforms.Equal(inspector.platforms) {
+		t.Errorf("unexpected platforms %v", result.platforms.UnsortedList())
+	}
+	if inspector.ctxErr != nil {
+		t.Errorf("expected the shared lookup not to be canceled, got %v", inspector.ctxErr)
+	}
+}
+
+func Test_newCacheConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *v1beta1.ImageInspectionCacheConfig
+		expected cacheConfig
+	}{
+		{
+			name:   "nil configuration uses the defaults",
+			config: nil,
+			expected: cacheConfig{
+				tagCacheSize:    defaultTagCacheSize,
+				tagTTL:          defaultTagTTL,
+				digestCacheSize: defaultDigestCacheSize,
+				digestTTL:       defaultDigestTTL,
+			},
+		},
+		{
+			name: "configured values override the defaults",
+			config: &v1beta1.ImageInspectionCacheConfig{
+				TagCacheSize: 10,
+				TagTTL:       &metav1.Duration{Duration: time.Minute},
+				DigestTTL:    &metav1.Duration{Duration: time.Hour},
+			},
+			expected: cacheConfig{
+				tagCacheSize:    10,
+				tagTTL:          time.Minute,
+				digestCacheSize: defaultDigestCacheSize,
+				digestTTL:       time.Hour,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newCacheConfig(tt.config); got != tt.expected {
+				t.Errorf("newCacheConfig() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_cacheProxy_purgeReferences(t *testing.T) {
+	c := newCacheProxy()
+	for _, imageReference := range []string{"//quay.io/openshift/origin:latest", "//quay.io/other/app:latest",
+		"//registry.example.com/app:latest"} {
+		c.tagCache.Add(imageReference, &tagCacheEntry{digest: digest.Digest(testDigest)})
+	}
+	c.digestCache.Add(digest.Digest(testDigest), &inspectionResult{
+		digest:    digest.Digest(testDigest),
+		platforms: PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+	})
+	c.purgeReferences([]string{"quay.io/openshift", "*.example.com"})
+	if keys := c.tagCache.Keys(); len(keys) != 1 || keys[0] != "//quay.io/other/app:latest" {
+		t.Errorf("expected only the matching references to be purged, got %v", keys)
+	}
+	if c.digestCache.Len() != 1 {
+		t.Errorf("expected the digest-to-architectures level to be kept")
+	}
+}
+
+func Test_cacheProxy_GetCompatiblePlatformsSet_reportsInspectedDigest(t *testing.T) {
+	c := newCacheProxy()
+	c.registryInspector = &countingInspector{
+		digest:    digest.Digest(testDigest),
+		platforms: PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+	}
+	for _, lookup := range []string{"miss", "hit"} {
+		var reported []digest.Digest
+		ctx := WithInspectedDigestHandler(context.Background(), func(_ string, d digest.Digest) {
+			reported = append(reported, d)
+		})
+		if _, err := c.GetCompatiblePlatformsSet(ctx, "//quay.io/foo/bar:latest", false, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(reported) != 1 || reported[0] != digest.Digest(testDigest) {
+			t.Errorf("expected the digest to be reported on a cache %s, got %v", lookup, reported)
+		}
+	}
+}
+
+func Test_cacheProxy_GetCompatiblePlatformsSet_reportsManifestListDiscrepancies(t *testing.T) {
+	c := newCacheProxy()
+	c.registryInspector = &countingInspector{
+		digest:    digest.Digest(testDigest),
+		platforms: PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+		discrepancies: []ManifestListDiscrepancy{{
+			ImageReference: "docker://quay.io/foo/bar:latest",
+			Digest:         digest.FromString("dangling"),
+			Platform:       Platform{OS: "linux", Architecture: utils.ArchitectureArm64},
+			Reason:         DiscrepancyReasonUnresolvable,
+		}},
+	}
+	for _, imageReference := range []string{"//quay.io/foo/bar:latest", "//quay.io/foo/bar:v1"} {
+		var reported []ManifestListDiscrepancy
+		ctx := WithManifestListDiscrepancyHandler(context.Background(), func(d ManifestListDiscrepancy) {
+			reported = append(reported, d)
+		})
+		if _, err := c.GetCompatiblePlatformsSet(ctx, imageReference, false, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(reported) != 1 || reported[0].ImageReference != imageReference[2:] {
+			t.Errorf("expected the discrepancy of %s to be reported, got %v", imageReference, reported)
+		}
+	}
+	if inspections := c.registryInspector.(*countingInspector).inspections; inspections != 1 {
+		t.Errorf("expected the second lookup to hit the cache, got %d inspections", inspections)
+	}
+}
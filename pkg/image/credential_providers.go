@@ -0,0 +1,413 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+)
+
+const (
+	credentialProviderConfigKind   = "CredentialProviderConfig"
+	credentialProviderRequestKind  = "CredentialProviderRequest"
+	credentialProviderResponseKind = "CredentialProviderResponse"
+
+	// credentialProviderExecTimeout is the maximum time a credential provider can take to answer a request.
+	credentialProviderExecTimeout = time.Minute
+
+	cacheKeyTypeImage    = "Image"
+	cacheKeyTypeRegistry = "Registry"
+	cacheKeyTypeGlobal   = "Global"
+	// globalCacheKey is the key of the credentials cached with the Global cache key type.
+	globalCacheKey = "global"
+)
+
+var (
+	// credentialProviderConfigAPIVersions are the supported versions of the kubelet's CredentialProviderConfig.
+	credentialProviderConfigAPIVersions = sets.New("kubelet.config.k8s.io/v1", "kubelet.config.k8s.io/v1beta1",
+		"kubelet.config.k8s.io/v1alpha1")
+	// credentialProviderAPIVersions are the supported versions of the CredentialProviderRequest and
+	// CredentialProviderResponse exchanged with the credential providers.
+	credentialProviderAPIVersions = sets.New("credentialprovider.kubelet.k8s.io/v1",
+		"credentialprovider.kubelet.k8s.io/v1beta1", "credentialprovider.kubelet.k8s.io/v1alpha1")
+	credentialProviderConfigExtensions = sets.New(".json", ".yaml", ".yml")
+)
+
+// credentialProviderConfig is the subset of the kubelet's CredentialProviderConfig used by the inspections.
+// See https://kubernetes.io/docs/reference/config-api/kubelet-config.v1/#kubelet-config-k8s-io-v1-CredentialProviderConfig
+type credentialProviderConfig struct {
+	metav1.TypeMeta `json:",inline"`
+	Providers       []credentialProviderSpec `json:"providers"`
+}
+
+type credentialProviderSpec struct {
+	Name                 string           `json:"name"`
+	MatchImages          []string         `json:"matchImages"`
+	DefaultCacheDuration *metav1.Duration `json:"defaultCacheDuration"`
+	APIVersion           string           `json:"apiVersion"`
+	Args                 []string         `json:"args,omitempty"`
+	Env                  []execEnvVar     `json:"env,omitempty"`
+	// TokenAttributes is only set by the providers requiring the service account token of the pods, that the
+	// inspections cannot provide.
+	TokenAttributes *json.RawMessage `json:"tokenAttributes,omitempty"`
+}
+
+type execEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type credentialProviderRequest struct {
+	metav1.TypeMeta `json:",inline"`
+	Image           string `json:"image"`
+}
+
+type credentialProviderResponse struct {
+	metav1.TypeMeta `json:",inline"`
+	CacheKeyType    string                            `json:"cacheKeyType"`
+	CacheDuration   *metav1.Duration                  `json:"cacheDuration,omitempty"`
+	Auth            map[string]credentialProviderAuth `json:"auth,omitempty"`
+}
+
+type credentialProviderAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// cachedCredentials are the credentials returned by a credential provider, until they expire.
+type cachedCredentials struct {
+	auths     map[string]authData
+	expiresAt time.Time
+}
+
+// credentialProvider runs a kubelet credential provider exec plugin and caches the credentials it returns according
+// to the cache key type and duration of its responses.
+type credentialProvider struct {
+	credentialProviderSpec
+	path     string
+	mutex    sync.Mutex
+	cache    map[string]cachedCredentials
+	inflight singleflight.Group
+	now      func() time.Time
+}
+
+// matches returns true if any of the matchImages of the provider matches the image.
+func (p *credentialProvider) matches(image string) bool {
+	for _, pattern := range p.MatchImages {
+		if matched, err := URLsMatchStr(pattern, image); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// provide returns the credentials of the provider for the image, from the cache if they did not expire. Concurrent
+// requests for the same image run the provider once, detached from the context of the caller that started it, so that
+// its cancellation does not fail the other callers: the execution is only bound by credentialProviderExecTimeout.
+func (p *credentialProvider) provide(ctx context.Context, image string) (map[string]authData, error) {
+	if auths, ok := p.cached(image); ok {
+		return auths, nil
+	}
+	resultChan := p.inflight.DoChan(image, func() (any, error) {
+		response, err := p.exec(context.WithoutCancel(ctx), image)
+		if err != nil {
+			metrics.CredentialProviderExecutions.WithLabelValues(p.Name, "failure").Inc()
+			return nil, err
+		}
+		metrics.CredentialProviderExecutions.WithLabelValues(p.Name, "success").Inc()
+		auths := make(map[string]authData, len(response.Auth))
+		for registry, auth := range response.Auth {
+			auths[registry] = authData{
+				Auth: base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password)),
+			}
+		}
+		p.store(image, response, auths)
+		return auths, nil
+	})
+	var result singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-resultChan:
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Val.(map[string]authData), nil
+}
+
+// cached looks up the credentials of the image in the cache, by image, registry and global keys, like the kubelet.
+func (p *credentialProvider) cached(image string) (map[string]authData, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := p.now()
+	for _, key := range []string{image, registryOf(image), globalCacheKey} {
+		entry, ok := p.cache[key]
+		if !ok {
+			continue
+		}
+		if now.Before(entry.expiresAt) {
+			return entry.auths, true
+		}
+		delete(p.cache, key)
+	}
+	return nil, false
+}
+
+// store caches the credentials of a response for its cache duration, or the default one of the provider if unset.
+// A zero duration disables the caching.
+func (p *credentialProvider) store(image string, response *credentialProviderResponse, auths map[string]authData) {
+	duration := p.DefaultCacheDuration.Duration
+	if response.CacheDuration != nil {
+		duration = response.CacheDuration.Duration
+	}
+	if duration <= 0 {
+		return
+	}
+	var key string
+	switch response.CacheKeyType {
+	case cacheKeyTypeImage:
+		key = image
+	case cacheKeyTypeRegistry:
+		key = registryOf(image)
+	case cacheKeyTypeGlobal:
+		key = globalCacheKey
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := p.now()
+	for k, entry := range p.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(p.cache, k)
+		}
+	}
+	p.cache[key] = cachedCredentials{auths: auths, expiresAt: now.Add(duration)}
+}
+
+// exec runs the provider with a CredentialProviderRequest for the image on its standard input and decodes the
+// CredentialProviderResponse on its standard output.
+func (p *credentialProvider) exec(ctx context.Context, image string) (*credentialProviderResponse, error) {
+	request, err := json.Marshal(credentialProviderRequest{
+		TypeMeta: metav1.TypeMeta{APIVersion: p.APIVersion, Kind: credentialProviderRequestKind},
+		Image:    image,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, credentialProviderExecTimeout)
+	defer cancel()
+	// #nosec G204 -- the provider and its arguments are defined by the CredentialProviderConfig of the nodes
+	cmd := exec.CommandContext(ctx, p.path, p.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range p.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("credential provider %s failed: %w, stderr: %s", p.Name, err,
+			strings.TrimSpace(stderr.String()))
+	}
+	response := &credentialProviderResponse{}
+	if err = json.Unmarshal(stdout.Bytes(), response); err != nil {
+		return nil, fmt.Errorf("unable to decode the response of the credential provider %s: %w", p.Name, err)
+	}
+	if response.Kind != credentialProviderResponseKind || response.APIVersion != p.APIVersion {
+		return nil, fmt.Errorf("unexpected response %s/%s of the credential provider %s, expected %s/%s",
+			response.APIVersion, response.Kind, p.Name, p.APIVersion, credentialProviderResponseKind)
+	}
+	switch response.CacheKeyType {
+	case cacheKeyTypeImage, cacheKeyTypeRegistry, cacheKeyTypeGlobal:
+	default:
+		return nil, fmt.Errorf("invalid cache key type %q in the response of the credential provider %s",
+			response.CacheKeyType, p.Name)
+	}
+	return response, nil
+}
+
+// credentialProviders are the kubelet credential providers configured for the inspections.
+type credentialProviders struct {
+	mutex     sync.RWMutex
+	providers []*credentialProvider
+}
+
+// configure loads the CredentialProviderConfig at configPath, a file or a directory of files, and the providers in
+// binDir. Like the kubelet, the configuration is only loaded once: the credentials cached by the previous providers
+// are dropped. It returns the names of the loaded providers.
+func (c *credentialProviders) configure(configPath, binDir string) ([]string, error) {
+	specs, err := loadCredentialProviderConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	providers := make([]*credentialProvider, 0, len(specs))
+	var names []string
+	for _, spec := range specs {
+		path := filepath.Join(binDir, spec.Name)
+		if _, err = os.Stat(path); err != nil {
+			return nil, fmt.Errorf("unable to find the credential provider %s: %w", spec.Name, err)
+		}
+		providers = append(providers, &credentialProvider{
+			credentialProviderSpec: spec,
+			path:                   path,
+			cache:                  map[string]cachedCredentials{},
+			now:                    time.Now,
+		})
+		names = append(names, spec.Name)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.providers = providers
+	return names, nil
+}
+
+// provide returns the credentials of all the providers matching the image reference. The failures of the providers
+// are logged and skipped, as the image may still be accessible with the pull secrets, or anonymously.
+func (c *credentialProviders) provide(ctx context.Context, imageReference string) map[string]authData {
+	c.mutex.RLock()
+	providers := c.providers
+	c.mutex.RUnlock()
+	image := strings.TrimPrefix(imageReference, "//")
+	auths := map[string]authData{}
+	for _, p := range providers {
+		if !p.matches(image) {
+			continue
+		}
+		providerAuths, err := p.provide(ctx, image)
+		if err != nil {
+			ctrllog.FromContext(ctx).Error(err, "Unable to get the credentials from the credential provider",
+				"provider", p.Name, "imageReference", imageReference)
+			continue
+		}
+		for registry, auth := range providerAuths {
+			auths[registry] = auth
+		}
+	}
+	return auths
+}
+
+// loadCredentialProviderConfig reads and validates the providers of the CredentialProviderConfig at path. If path is
+// a directory, the providers of its json and yaml files are loaded in lexical order.
+func loadCredentialProviderConfig(path string) ([]credentialProviderSpec, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() && credentialProviderConfigExtensions.Has(filepath.Ext(entry.Name())) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no CredentialProviderConfig found in %s", path)
+		}
+	}
+	var specs []credentialProviderSpec
+	names := sets.New[string]()
+	for _, file := range files {
+		config, err := decodeCredentialProviderConfig(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range config.Providers {
+			if err = validateCredentialProvider(spec); err != nil {
+				return nil, fmt.Errorf("invalid credential provider in %s: %w", file, err)
+			}
+			if names.Has(spec.Name) {
+				return nil, fmt.Errorf("duplicate credential provider %s in %s", spec.Name, file)
+			}
+			names.Insert(spec.Name)
+			if spec.TokenAttributes != nil {
+				ctrllog.Log.WithName("credentialProviders").Info(
+					"Skipping the credential provider requiring the service account tokens of the pods",
+					"provider", spec.Name)
+				continue
+			}
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
+}
+
+func decodeCredentialProviderConfig(file string) (*credentialProviderConfig, error) {
+	f, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	config := &credentialProviderConfig{}
+	if err = yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(config); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", file, err)
+	}
+	if config.Kind != credentialProviderConfigKind || !credentialProviderConfigAPIVersions.Has(config.APIVersion) {
+		return nil, fmt.Errorf("unsupported configuration %s/%s in %s", config.APIVersion, config.Kind, file)
+	}
+	return config, nil
+}
+
+// validateCredentialProvider validates a provider like the kubelet does.
+func validateCredentialProvider(spec credentialProviderSpec) error {
+	if spec.Name == "" {
+		return errors.New("name is required")
+	}
+	if strings.ContainsAny(spec.Name, `/\`) || spec.Name == "." || spec.Name == ".." {
+		return fmt.Errorf("invalid name %q: it must be a file name", spec.Name)
+	}
+	if len(spec.MatchImages) == 0 {
+		return fmt.Errorf("%s: matchImages is required", spec.Name)
+	}
+	if slices.Contains(spec.MatchImages, "") {
+		return fmt.Errorf("%s: matchImages cannot contain empty patterns", spec.Name)
+	}
+	if spec.DefaultCacheDuration == nil {
+		return fmt.Errorf("%s: defaultCacheDuration is required", spec.Name)
+	}
+	if spec.DefaultCacheDuration.Duration < 0 {
+		return fmt.Errorf("%s: defaultCacheDuration must be greater than or equal to 0", spec.Name)
+	}
+	if !credentialProviderAPIVersions.Has(spec.APIVersion) {
+		return fmt.Errorf("%s: unsupported apiVersion %q", spec.Name, spec.APIVersion)
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package image
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/json"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+)
+
+// stubCredentialProvider writes a credential provider to binDir that stores its requests in the directory set by
+// the REQUESTS_DIR environment variable of its configuration and answers with the given response.
+func stubCredentialProvider(t *testing.T, binDir, name, response string) {
+	script := fmt.Sprintf("#!/bin/sh\ncat > \"$(mktemp -p \"$REQUESTS_DIR\")\"\nprintf '%%s' '%s'\n", response)
+	if err := os.WriteFile(filepath.Join(binDir, name), []byte(script), 0700); err != nil { // #nosec G306
+		t.Fatal(err)
+	}
+}
+
+// requestsOf returns the images of the requests received by the stub credential providers.
+func requestsOf(t *testing.T, requestsDir string) []string {
+	entries, err := os.ReadDir(requestsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var images []string
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(requestsDir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		request := credentialProviderRequest{}
+		if err = json.Unmarshal(content, &request); err != nil {
+			t.Fatal(err)
+		}
+		if request.Kind != credentialProviderRequestKind {
+			t.Errorf("unexpected request kind %q", request.Kind)
+		}
+		images = append(images, request.Image)
+	}
+	slices.Sort(images)
+	return images
+}
+
+func writeCredentialProviderConfig(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_credentialProviders_provide(t *testing.T) {
+	metrics.InitCommonMetrics()
+	binDir, configDir, requestsDir := t.TempDir(), t.TempDir(), t.TempDir()
+	stubCredentialProvider(t, binDir, "ecr-credential-provider", `{"apiVersion": "credentialprovider.kubelet.k8s.io/v1",
+"kind": "CredentialProviderResponse", "cacheKeyType": "Registry", "cacheDuration": "5m",
+"auth": {"*.dkr.ecr.*.amazonaws.com": {"username": "AWS", "password": "token"}}}`)
+	stubCredentialProvider(t, binDir, "no-cache-provider", `{"apiVersion": "credentialprovider.kubelet.k8s.io/v1",
+"kind": "CredentialProviderResponse", "cacheKeyType": "Image",
+"auth": {"registry.local": {"username": "user", "password": "password"}}}`)
+	writeCredentialProviderConfig(t, filepath.Join(configDir, "10-ecr.yaml"), fmt.Sprintf(`apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: ecr-credential-provider
+  matchImages: ["*.dkr.ecr.*.amazonaws.com"]
+  defaultCacheDuration: 12h
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  env:
+  - name: REQUESTS_DIR
+    value: %s
+`, requestsDir))
+	writeCredentialProviderConfig(t, filepath.Join(configDir, "20-local.json"), fmt.Sprintf(`{
+"apiVersion": "kubelet.config.k8s.io/v1", "kind": "CredentialProviderConfig",
+"providers": [{"name": "no-cache-provider", "matchImages": ["registry.local"], "defaultCacheDuration": "0s",
+"apiVersion": "credentialprovider.kubelet.k8s.io/v1", "env": [{"name": "REQUESTS_DIR", "value": %q}]}]}`, requestsDir))
+	writeCredentialProviderConfig(t, filepath.Join(configDir, "README"), "ignored")
+
+	c := &credentialProviders{}
+	providers, err := c.configure(configDir, binDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(providers, []string{"ecr-credential-provider", "no-cache-provider"}) {
+		t.Fatalf("unexpected providers %v", providers)
+	}
+	ctx := context.Background()
+	const ecrImage = "123456789012.dkr.ecr.us-east-1.amazonaws.com/app:latest"
+	auths := c.provide(ctx, "//"+ecrImage)
+	expectedAuth := base64.StdEncoding.EncodeToString([]byte("AWS:token"))
+	if len(auths) != 1 || auths["*.dkr.ecr.*.amazonaws.com"].Auth != expectedAuth {
+		t.Errorf("unexpected credentials %v", auths)
+	}
+	// The credentials are cached by registry
+	c.provide(ctx, "//123456789012.dkr.ecr.us-east-1.amazonaws.com/other:latest")
+	if got := requestsOf(t, requestsDir); !slices.Equal(got, []string{ecrImage}) {
+		t.Errorf("expected the credentials to be cached by registry, got the requests %v", got)
+	}
+	c.providers[0].now = func() time.Time { return time.Now().Add(6 * time.Minute) }
+	c.provide(ctx, "//"+ecrImage)
+	if got := requestsOf(t, requestsDir); len(got) != 2 {
+		t.Errorf("expected the expired credentials to be requested again, got the requests %v", got)
+	}
+
+	// A zero cache duration disables the caching
+	for range 2 {
+		if auths = c.provide(ctx, "//registry.local/app:latest"); len(auths) != 1 {
+			t.Errorf("unexpected credentials %v", auths)
+		}
+	}
+	if got := requestsOf(t, requestsDir); len(got) != 4 {
+		t.Errorf("expected the credentials not to be cached, got the requests %v", got)
+	}
+
+	if auths = c.provide(ctx, "//quay.io/app:latest"); len(auths) != 0 {
+		t.Errorf("expected no credentials for the images not matched by the providers, got %v", auths)
+	}
+	if got := requestsOf(t, requestsDir); len(got) != 4 {
+		t.Errorf("expected the providers not to run for the images they do not match, got the requests %v", got)
+	}
+}
+
+func Test_credentialProviders_provide_failure(t *testing.T) {
+	metrics.InitCommonMetrics()
+	binDir, configDir := t.TempDir(), t.TempDir()
+	configPath := filepath.Join(configDir, "config.yaml")
+	stubCredentialProvider(t, binDir, "wrong-version-provider", `{"apiVersion": "credentialprovider.kubelet.k8s.io/v1beta1",
+"kind": "CredentialProviderResponse", "cacheKeyType": "Global"}`)
+	writeCredentialProviderConfig(t, configPath, fmt.Sprintf(`apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: wrong-version-provider
+  matchImages: ["*.local"]
+  defaultCacheDuration: 1m
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  env:
+  - name: REQUESTS_DIR
+    value: %s
+`, t.TempDir()))
+	c := &credentialProviders{}
+	if _, err := c.configure(configPath, binDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auths := c.provide(context.Background(), "//registry.local/app:latest"); len(auths) != 0 {
+		t.Errorf("expected the failing providers to be skipped, got %v", auths)
+	}
+}
+
+func Test_credentialProvider_provide_leaderCanceled(t *testing.T) {
+	metrics.InitCommonMetrics()
+	binDir, requestsDir := t.TempDir(), t.TempDir()
+	// The provider answers slowly, so that the second caller is coalesced with the first one.
+	script := "#!/bin/sh\ncat > \"$(mktemp -p \"$REQUESTS_DIR\")\"\nsleep 0.5\n" +
+		`printf '%s' '{"apiVersion": "credentialprovider.kubelet.k8s.io/v1", "kind": "CredentialProviderResponse",` +
+		` "cacheKeyType": "Image", "auth": {"registry.local": {"username": "user", "password": "password"}}}'` + "\n"
+	if err := os.WriteFile(filepath.Join(binDir, "slow-provider"), []byte(script), 0700); err != nil { // #nosec G306
+		t.Fatal(err)
+	}
+	p := &credentialProvider{
+		credentialProviderSpec: credentialProviderSpec{
+			Name:                 "slow-provider",
+			MatchImages:          []string{"registry.local"},
+			DefaultCacheDuration: &metav1.Duration{Duration: time.Minute},
+			APIVersion:           "credentialprovider.kubelet.k8s.io/v1",
+			Env:                  []execEnvVar{{Name: "REQUESTS_DIR", Value: requestsDir}},
+		},
+		path:  filepath.Join(binDir, "slow-provider"),
+		cache: map[string]cachedCredentials{},
+		now:   time.Now,
+	}
+	const image = "registry.local/app:latest"
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := p.provide(leaderCtx, image)
+		leaderErr <- err
+	}()
+	// Wait for the provider to run before the second caller is coalesced with it.
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if entries, err := os.ReadDir(requestsDir); err == nil && len(entries) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the credential provider did not run")
+		}
+	}
+	followerAuths := make(chan map[string]authData, 1)
+	followerErr := make(chan error, 1)
+	go func() {
+		auths, err := p.provide(context.Background(), image)
+		followerAuths <- auths
+		followerErr <- err
+	}()
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the canceled caller to get its context error, got %v", err)
+	}
+	if err := <-followerErr; err != nil {
+		t.Fatalf("expected the coalesced caller not to be failed by the canceled one, got %v", err)
+	}
+	if auths := <-followerAuths; len(auths) != 1 {
+		t.Errorf("unexpected credentials %v", auths)
+	}
+	if got := requestsOf(t, requestsDir); len(got) != 1 {
+		t.Errorf("expected the provider to run once, got the requests %v", got)
+	}
+}
+
+func Test_loadCredentialProviderConfig(t *testing.T) {
+	const provider = `
+  matchImages: ["*.azurecr.io"]
+  defaultCacheDuration: 10m
+  apiVersion: credentialprovider.kubelet.k8s.io/v1`
+	tests := []struct {
+		name        string
+		config      string
+		expectError string
+		expect      []string
+	}{
+		{
+			name: "valid configuration",
+			config: `apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: acr-credential-provider` + provider + `
+- name: token-provider` + provider + `
+  tokenAttributes:
+    serviceAccountTokenAudience: audience`,
+			expect: []string{"acr-credential-provider"},
+		},
+		{
+			name:        "unsupported kind",
+			config:      "apiVersion: kubelet.config.k8s.io/v1\nkind: KubeletConfiguration\n",
+			expectError: "unsupported configuration",
+		},
+		{
+			name: "invalid name",
+			config: `apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: ../acr-credential-provider` + provider,
+			expectError: "invalid name",
+		},
+		{
+			name: "duplicate providers",
+			config: `apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: acr-credential-provider` + provider + `
+- name: acr-credential-provider` + provider,
+			expectError: "duplicate credential provider",
+		},
+		{
+			name: "missing default cache duration",
+			config: `apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: acr-credential-provider
+  matchImages: ["*.azurecr.io"]
+  apiVersion: credentialprovider.kubelet.k8s.io/v1`,
+			expectError: "defaultCacheDuration is required",
+		},
+		{
+			name: "unsupported provider api version",
+			config: `apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: acr-credential-provider
+  matchImages: ["*.azurecr.io"]
+  defaultCacheDuration: 10m
+  apiVersion: credentialprovider.kubelet.k8s.io/v2`,
+			expectError: "unsupported apiVersion",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			writeCredentialProviderConfig(t, path, tt.config)
+			specs, err := loadCredentialProviderConfig(path)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected an error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, spec := range specs {
+				names = append(names, spec.Name)
+			}
+			if !slices.Equal(names, tt.expect) {
+				t.Errorf("expected the providers %v, got %v", tt.expect, names)
+			}
+		})
+	}
+}
+
+func Test_marshaledImagePullSecrets_credentialProviders(t *testing.T) {
+	metrics.InitCommonMetrics()
+	binDir, configDir := t.TempDir(), t.TempDir()
+	configPath := filepath.Join(configDir, "config.yaml")
+	stubCredentialProvider(t, binDir, "gcp-credential-provider", `{"apiVersion": "credentialprovider.kubelet.k8s.io/v1",
+"kind": "CredentialProviderResponse", "cacheKeyType": "Global",
+"auth": {"*-docker.pkg.dev": {"username": "_token", "password": "token"},
+"gcr.io": {"username": "_token", "password": "token"}}}`)
+	writeCredentialProviderConfig(t, configPath, fmt.Sprintf(`apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: gcp-credential-provider
+  matchImages: ["*-docker.pkg.dev", "gcr.io"]
+  defaultCacheDuration: 1m
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  env:
+  - name: REQUESTS_DIR
+    value: %s
+`, t.TempDir()))
+	s := newInspectionState()
+	if _, err := s.credentialProviders.configure(configPath, binDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	providerAuth := base64.StdEncoding.EncodeToString([]byte("_token:token"))
+	secretAuth := base64.StdEncoding.EncodeToString([]byte("user:password"))
+	authJSON, err := s.marshaledImagePullSecrets(context.Background(), "//us-docker.pkg.dev/project/app:latest",
+		[][]byte{[]byte(fmt.Sprintf(`{"gcr.io": {"auth": %q}}`, secretAuth))})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	auths := authCfg{}
+	if err = json.Unmarshal(authJSON, &auths); err != nil {
+		t.Fatal(err)
+	}
+	if auths.Auths["us-docker.pkg.dev"].Auth != providerAuth {
+		t.Errorf("expected the credentials of the provider to be expanded for the registry, got %v", auths.Auths)
+	}
+	if auths.Auths["gcr.io"].Auth != secretAuth {
+		t.Errorf("expected the secrets to take precedence over the credentials of the providers, got %v", auths.Auths)
+	}
+}
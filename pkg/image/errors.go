@@ -0,0 +1,129 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/signature"
+	"github.com/docker/distribution/registry/api/errcode"
+	v2 "github.com/docker/distribution/registry/api/v2"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+)
+
+// InspectionError is an image inspection error annotated with its class.
+type InspectionError struct {
+	Class common.ImageInspectionErrorClass
+	Err   error
+}
+
+func (e *InspectionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InspectionError) Unwrap() error {
+	return e.Err
+}
+
+// newInspectionError wraps the given error into an InspectionError, classifying it.
+// It returns nil if err is nil and err itself if it is already an InspectionError.
+func newInspectionError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var inspectionError *InspectionError
+	if errors.As(err, &inspectionError) {
+		return err
+	}
+	return &InspectionError{
+		Class: classifyError(err),
+		Err:   err,
+	}
+}
+
+// malformedManifestError wraps the given error into an InspectionError of class MalformedManifest.
+func malformedManifestError(err error) error {
+	return &InspectionError{
+		Class: common.ImageInspectionErrorMalformedManifest,
+		Err:   err,
+	}
+}
+
+// ErrorClass returns the class of the given error. Errors that are not InspectionErrors are classified as Unknown.
+func ErrorClass(err error) common.ImageInspectionErrorClass {
+	var inspectionError *InspectionError
+	if errors.As(err, &inspectionError) {
+		return inspectionError.Class
+	}
+	return common.ImageInspectionErrorUnknown
+}
+
+// classifyError maps the errors returned by the containers/image library to their class.
+func classifyError(err error) common.ImageInspectionErrorClass {
+	var policyRequirementError signature.PolicyRequirementError
+	if errors.As(err, &policyRequirementError) {
+		return common.ImageInspectionErrorPolicyRejected
+	}
+	if errors.Is(err, errOfflineImageNotFound) {
+		return common.ImageInspectionErrorNotFound
+	}
+	if errors.Is(err, docker.ErrTooManyRequests) {
+		return common.ImageInspectionErrorRateLimited
+	}
+	var unauthorizedError docker.ErrUnauthorizedForCredentials
+	if errors.As(err, &unauthorizedError) {
+		return common.ImageInspectionErrorAuthDenied
+	}
+	var errorCoder errcode.ErrorCoder
+	if errors.As(err, &errorCoder) {
+		switch errorCoder.ErrorCode() {
+		case errcode.ErrorCodeUnauthorized, errcode.ErrorCodeDenied:
+			return common.ImageInspectionErrorAuthDenied
+		case errcode.ErrorCodeTooManyRequests:
+			return common.ImageInspectionErrorRateLimited
+		case errcode.ErrorCodeUnavailable:
+			return common.ImageInspectionErrorUnreachable
+		case v2.ErrorCodeManifestUnknown, v2.ErrorCodeNameUnknown, v2.ErrorCodeBlobUnknown:
+			return common.ImageInspectionErrorNotFound
+		case v2.ErrorCodeManifestInvalid:
+			return common.ImageInspectionErrorMalformedManifest
+		}
+	}
+	var httpStatusError docker.UnexpectedHTTPStatusError
+	if errors.As(err, &httpStatusError) {
+		switch {
+		case httpStatusError.StatusCode == http.StatusUnauthorized || httpStatusError.StatusCode == http.StatusForbidden:
+			return common.ImageInspectionErrorAuthDenied
+		case httpStatusError.StatusCode == http.StatusNotFound:
+			return common.ImageInspectionErrorNotFound
+		case httpStatusError.StatusCode == http.StatusTooManyRequests:
+			return common.ImageInspectionErrorRateLimited
+		case httpStatusError.StatusCode >= http.StatusInternalServerError:
+			return common.ImageInspectionErrorUnreachable
+		}
+	}
+	var netError net.Error
+	if errors.As(err, &netError) || errors.Is(err, context.DeadlineExceeded) {
+		return common.ImageInspectionErrorUnreachable
+	}
+	return common.ImageInspectionErrorUnknown
+}
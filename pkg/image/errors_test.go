@@ -0,0 +1,81 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/signature"
+	"github.com/docker/distribution/registry/api/errcode"
+	v2 "github.com/docker/distribution/registry/api/v2"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+)
+
+func Test_newInspectionError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected common.ImageInspectionErrorClass
+	}{
+		{
+			name:     "unauthorized",
+			err:      docker.ErrUnauthorizedForCredentials{Err: errors.New("invalid credentials")},
+			expected: common.ImageInspectionErrorAuthDenied,
+		},
+		{
+			name:     "denied",
+			err:      fmt.Errorf("reading manifest: %w", errcode.ErrorCodeDenied.WithMessage("requested access to the resource is denied")),
+			expected: common.ImageInspectionErrorAuthDenied,
+		},
+		{
+			name:     "manifest unknown",
+			err:      fmt.Errorf("reading manifest: %w", v2.ErrorCodeManifestUnknown.WithMessage("manifest unknown")),
+			expected: common.ImageInspectionErrorNotFound,
+		},
+		{
+			name:     "signature policy rejection",
+			err:      signature.PolicyRequirementError("Running image is rejected by policy."),
+			expected: common.ImageInspectionErrorPolicyRejected,
+		},
+		{
+			name:     "too many requests",
+			err:      fmt.Errorf("reading manifest: %w", docker.ErrTooManyRequests),
+			expected: common.ImageInspectionErrorRateLimited,
+		},
+		{
+			name:     "network error",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			expected: common.ImageInspectionErrorUnreachable,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      fmt.Errorf("pinging container registry: %w", context.DeadlineExceeded),
+			expected: common.ImageInspectionErrorUnreachable,
+		},
+		{
+			name:     "already classified errors are kept",
+			err:      malformedManifestError(errors.New("unexpected end of JSON input")),
+			expected: common.ImageInspectionErrorMalformedManifest,
+		},
+		{
+			name:     "unknown errors",
+			err:      errors.New("something went wrong"),
+			expected: common.ImageInspectionErrorUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newInspectionError(tt.err)
+			if class := ErrorClass(err); class != tt.expected {
+				t.Errorf("ErrorClass() = %v, expected %v", class, tt.expected)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("the inspection error does not wrap the original error")
+			}
+		})
+	}
+}
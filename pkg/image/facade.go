@@ -21,6 +21,11 @@ import (
 	"sync"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
 )
 
 var (
@@ -33,23 +38,203 @@ type Facade struct {
 	inspectionCache       ICache
 	storeGlobalPullSecret func(pullSecret []byte)
 	clearCache            func()
+	setStore              func(store IArchitectureStore)
+	configureCache        func(ctx context.Context, config *v1beta1.ImageInspectionCacheConfig)
+	clearDigestCache      func()
+	// state is the configuration of the inspections of the inspectionCache.
+	state *inspectionState
 }
 
 func (i *Facade) GetCompatibleArchitecturesSet(ctx context.Context, imageReference string, skipCache bool, secrets [][]byte) (architectures sets.Set[string], err error) {
 	return i.inspectionCache.GetCompatibleArchitecturesSet(ctx, imageReference, skipCache, secrets)
 }
 
+func (i *Facade) GetCompatiblePlatformsSet(ctx context.Context, imageReference string, skipCache bool, secrets [][]byte) (platforms sets.Set[Platform], err error) {
+	return i.inspectionCache.GetCompatiblePlatformsSet(ctx, imageReference, skipCache, secrets)
+}
+
 func (i *Facade) StoreGlobalPullSecret(pullSecret []byte) {
 	i.storeGlobalPullSecret(pullSecret)
 	i.clearCache()
 }
 
+// EnableImageArchitectureStore configures the facade to read through and persist the inspection results
+// in the cluster-scoped ImageArchitecture objects.
+func (i *Facade) EnableImageArchitectureStore(c client.Client) {
+	i.setStore(newImageArchitectureStore(c, i.state.inspectionRules))
+}
+
+// ConfigureCache applies the ClusterPodPlacementConfig's image inspection cache configuration.
+// A nil configuration restores the defaults.
+func (i *Facade) ConfigureCache(ctx context.Context, config *v1beta1.ImageInspectionCacheConfig) {
+	i.configureCache(ctx, config)
+}
+
+// ConfigureArchitectures applies the ClusterPodPlacementConfig's supported architectures and architecture aliases.
+// As the cached inspection results depend on them, the digest-to-architectures level of the cache is purged when
+// they change.
+func (i *Facade) ConfigureArchitectures(ctx context.Context, supportedArchitectures sets.Set[string],
+	aliases []v1beta1.ArchitectureAlias) {
+	if i.state.architectures.configure(supportedArchitectures, aliases) {
+		ctrllog.FromContext(ctx).Info("Configuring the supported architectures",
+			"supportedArchitectures", sets.List(supportedArchitectures), "aliases", aliases)
+		i.clearDigestCache()
+	}
+}
+
+// SupportedArchitectures returns the set of the architectures supported by the cluster, as configured in the
+// ClusterPodPlacementConfig.
+func (i *Facade) SupportedArchitectures() sets.Set[string] {
+	return i.state.architectures.supported()
+}
+
+// ConfigureRegistryPolicies applies the ClusterPodPlacementConfig's registry policies. The state of the circuit
+// breakers is reset when they change.
+func (i *Facade) ConfigureRegistryPolicies(ctx context.Context, policies []v1beta1.RegistryPolicy) {
+	if i.state.registryGuards.configure(policies) {
+		ctrllog.FromContext(ctx).Info("Configuring the registry policies", "registryPolicies", policies)
+	}
+}
+
+// UnhealthyRegistries returns the sorted list of the registries whose circuit breaker is open: the inspections of
+// their images fail fast.
+func (i *Facade) UnhealthyRegistries() []string {
+	return i.state.registryGuards.unhealthyRegistries()
+}
+
+// ConfigureRegistryMirrors replaces the mirrors of the registries configuration used by the inspections, e.g., with
+// the ones of the ImageDigestMirrorSets, ImageTagMirrorSets and ImageContentSourcePolicies. The tag-to-digest entries
+// of the cache matching the registries whose mirrors changed are purged.
+func (i *Facade) ConfigureRegistryMirrors(ctx context.Context, mirrors []RegistryMirrors) error {
+	changed, err := i.state.registriesConfig.configureMirrors(mirrors)
+	if err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		ctrllog.FromContext(ctx).Info("Configuring the registry mirrors", "changedRegistries", changed)
+	}
+	return nil
+}
+
+// ReloadHostRegistriesConfig reloads the registries configuration of the host, if it changed, as long as the mirrors
+// are not configured.
+func (i *Facade) ReloadHostRegistriesConfig(ctx context.Context) {
+	if i.state.registriesConfig.reloadHostConf() {
+		ctrllog.FromContext(ctx).Info("Reloading the registries configuration of the host", "path", RegistriesConfPath())
+	}
+}
+
+// ConfigureProxy sets the proxy used to access the registries, e.g., to the one of the cluster Proxy. An empty
+// configuration restores the proxy of the environment.
+func (i *Facade) ConfigureProxy(ctx context.Context, proxy ProxyConfig) {
+	if i.state.registriesConfig.configureProxy(proxy) {
+		// The proxy addresses may embed credentials: they are not logged.
+		ctrllog.FromContext(ctx).Info("Configuring the proxy of the registries", "noProxy", proxy.NoProxy)
+	}
+}
+
+// ConfigureCredentialProviders loads the kubelet CredentialProviderConfig at configPath, a file or a directory, to
+// authenticate the inspections with the credential providers in binDir, like the kubelet does on the nodes.
+func (i *Facade) ConfigureCredentialProviders(ctx context.Context, configPath, binDir string) error {
+	providers, err := i.state.credentialProviders.configure(configPath, binDir)
+	if err != nil {
+		return err
+	}
+	ctrllog.FromContext(ctx).Info("Configuring the credential providers", "configPath", configPath,
+		"binDir", binDir, "providers", providers)
+	return nil
+}
+
+// EnableImageStreamResolution resolves the references to the images of the OpenShift internal registry, and the bare
+// names of the ImageStreams with local lookup, through the image API with the given reader. The reader should not be
+// backed by a cache: the ImageStreamTags and ImageStreamImages cannot be watched.
+func (i *Facade) EnableImageStreamResolution(reader client.Reader) {
+	i.state.imageStreams.enable(reader)
+}
+
+// ConfigureInternalRegistryHostnames sets the internal and external hostnames of the OpenShift internal registry,
+// e.g., to the ones in the status of the cluster image configuration.
+func (i *Facade) ConfigureInternalRegistryHostnames(ctx context.Context, internal string, external []string) {
+	if i.state.imageStreams.configureInternalRegistryHostnames(internal, external) {
+		ctrllog.FromContext(ctx).Info("Configuring the hostnames of the internal registry",
+			"internalRegistryHostname", internal, "externalRegistryHostnames", external)
+	}
+}
+
+// LocalImageStreamReference returns the reference to the internal registry of an image referenced by the bare name of
+// an ImageStream with local lookup in the given namespace. Any other image reference is returned as is.
+func (i *Facade) LocalImageStreamReference(ctx context.Context, namespace, imageReference string) string {
+	return i.state.imageStreams.localReference(ctx, namespace, imageReference)
+}
+
+// EnableNodeImageInventory infers the platforms of the images whose inspection fails from the nodes holding them,
+// listed with the given reader. The reader should be backed by a cache of the nodes indexed with IndexNodeImages.
+func (i *Facade) EnableNodeImageInventory(reader client.Reader) {
+	i.state.nodeImages.enable(reader)
+}
+
+// NodeImagePlatforms returns the platforms of the nodes holding the given image in their .status.images, or an empty
+// set if none holds it or the node image inventory is not enabled.
+func (i *Facade) NodeImagePlatforms(ctx context.Context, imageReference string) (sets.Set[Platform], error) {
+	return i.state.nodeImages.platforms(ctx, imageReference)
+}
+
+// ConfigureOfflineSources applies the ClusterPodPlacementConfig's offline image sources, whose volume is mounted at
+// utils.OfflineImageSourcesMountPath. The tag-to-digest entries of the cache matching the changed sources are purged.
+func (i *Facade) ConfigureOfflineSources(ctx context.Context, config *v1beta1.OfflineImageSourcesConfig) {
+	if changed := i.state.offlineSources.configure(utils.OfflineImageSourcesMountPath, config); len(changed) > 0 {
+		ctrllog.FromContext(ctx).Info("Configuring the offline image sources", "changedPrefixes", changed)
+	}
+}
+
+// ConfigureManifestListValidation applies the ClusterPodPlacementConfig's manifest list validation mode. As the
+// cached inspection results depend on it, the digest-to-architectures level of the cache is purged when it changes.
+func (i *Facade) ConfigureManifestListValidation(ctx context.Context, mode v1beta1.ManifestListValidationMode) {
+	if i.state.manifestListValidation.configure(mode == v1beta1.ManifestListValidationModeDeep) {
+		ctrllog.FromContext(ctx).Info("Configuring the manifest list validation", "mode", mode)
+		i.clearDigestCache()
+	}
+}
+
+// ConfigureBinaryVerification applies the ClusterPodPlacementConfig's binary verification. As the cached inspection
+// results depend on it, the digest-to-architectures level of the cache is purged when it changes.
+func (i *Facade) ConfigureBinaryVerification(ctx context.Context, config *v1beta1.BinaryVerificationConfig) {
+	if i.state.binaryVerification.configure(config) {
+		ctrllog.FromContext(ctx).Info("Configuring the binary verification", "binaryVerification", config)
+		i.clearDigestCache()
+	}
+}
+
+// ConfigureRuntimeClassMappings applies the media types of the ClusterPodPlacementConfig's RuntimeClass mappings.
+// As the cached inspection results depend on them, the digest-to-architectures level of the cache is purged when
+// they change.
+func (i *Facade) ConfigureRuntimeClassMappings(ctx context.Context, mappings []v1beta1.RuntimeClassMapping) {
+	if i.state.runtimePlatforms.configure(mappings) {
+		ctrllog.FromContext(ctx).Info("Configuring the RuntimeClass mappings", "runtimeClassMappings", mappings)
+		i.clearDigestCache()
+	}
+}
+
+// ConfigureArchitectureAgnosticImages applies the ClusterPodPlacementConfig's rules marking the images as
+// architecture-agnostic. As the cached inspection results depend on them, the digest-to-architectures level of the
+// cache is purged when they change.
+func (i *Facade) ConfigureArchitectureAgnosticImages(ctx context.Context, rules []v1beta1.ArchitectureAgnosticImageRule) {
+	if i.state.architectureAgnosticRules.configure(rules) {
+		ctrllog.FromContext(ctx).Info("Configuring the architecture-agnostic image rules", "architectureAgnosticImages", rules)
+		i.clearDigestCache()
+	}
+}
+
 func newImageFacade() *Facade {
 	inspectionCache := newCacheProxy()
 	return &Facade{
 		inspectionCache:       inspectionCache,
+		state:                 inspectionCache.state,
 		storeGlobalPullSecret: inspectionCache.registryInspector.storeGlobalPullSecret,
 		clearCache:            inspectionCache.clearCache,
+		setStore:              inspectionCache.setStore,
+		configureCache:        inspectionCache.configure,
+		clearDigestCache:      inspectionCache.clearDigestCache,
 	}
 }
 
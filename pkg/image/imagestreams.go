@@ -0,0 +1,319 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/openshift/api/image/docker10"
+	imagev1 "github.com/openshift/api/image/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+)
+
+const (
+	// defaultInternalRegistryHostname is the hostname of the service of the OpenShift internal registry.
+	defaultInternalRegistryHostname = "image-registry.openshift-image-registry.svc:5000"
+	// defaultTag is the tag of the image references without tag nor digest.
+	defaultTag = "latest"
+
+	imageStreamResolutionMetadata = "metadata"
+	imageStreamResolutionPullSpec = "pullSpec"
+)
+
+// defaultInternalRegistryHostnames are the hostnames of the OpenShift internal registry known without reading the
+// cluster image configuration.
+var defaultInternalRegistryHostnames = []string{
+	defaultInternalRegistryHostname,
+	"image-registry.openshift-image-registry.svc.cluster.local:5000",
+}
+
+// imageStreamResolution is the result of the resolution of an image reference through the image API.
+type imageStreamResolution struct {
+	// digest is the digest of the manifest, or manifest list, of the image.
+	digest digest.Digest
+	// pullSpec is the reference to inspect when the platforms are not known by the image API, e.g., the external
+	// reference of an imported image.
+	pullSpec string
+	// platforms are the platforms of the image according to its metadata, or nil if they are unknown.
+	platforms sets.Set[Platform]
+}
+
+// imageStreams resolves the references to the images of the OpenShift internal registry, and the bare names of the
+// ImageStreams with local lookup, through the image API. The ImageStreamTags and ImageStreamImages cannot be
+// watched: the reader should not be backed by a cache.
+type imageStreams struct {
+	mutex  sync.RWMutex
+	reader client.Reader
+	// internalRegistryHostname is the hostname used to rewrite the bare names of the ImageStreams with local lookup.
+	internalRegistryHostname string
+	// internalRegistryHostnames are all the hostnames of the internal registry, including the external ones.
+	internalRegistryHostnames sets.Set[string]
+	rules                     *inspectionRules
+}
+
+// newImageStreams returns an imageStreams disabled until a reader is set, e.g., on the clusters not serving the image
+// API. The platforms of the images are computed from their metadata with the given rules, unless they require
+// inspecting the images.
+func newImageStreams(rules *inspectionRules) *imageStreams {
+	return &imageStreams{
+		internalRegistryHostname:  defaultInternalRegistryHostname,
+		internalRegistryHostnames: sets.New(defaultInternalRegistryHostnames...),
+		rules:                     rules,
+	}
+}
+
+func (s *imageStreams) enable(reader client.Reader) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reader = reader
+}
+
+// configureInternalRegistryHostnames sets the hostnames of the internal registry, in addition to the default ones.
+// It returns true if the hostnames changed.
+func (s *imageStreams) configureInternalRegistryHostnames(internal string, external []string) bool {
+	hostnames := sets.New(defaultInternalRegistryHostnames...).Insert(external...)
+	if internal != "" {
+		hostnames.Insert(internal)
+	} else {
+		internal = defaultInternalRegistryHostname
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.internalRegistryHostname == internal && s.internalRegistryHostnames.Equal(hostnames) {
+		return false
+	}
+	s.internalRegistryHostname = internal
+	s.internalRegistryHostnames = hostnames
+	return true
+}
+
+// localReference returns the reference to the internal registry of the bare name of an ImageStream with local lookup
+// in the given namespace, like the image policy admission of OpenShift does. Any other reference is returned as is.
+func (s *imageStreams) localReference(ctx context.Context, namespace, imageReference string) string {
+	s.mutex.RLock()
+	reader, hostname := s.reader, s.internalRegistryHostname
+	s.mutex.RUnlock()
+	bareName := strings.TrimPrefix(imageReference, "//")
+	// Only the references without registry nor repository can refer to an ImageStream.
+	if reader == nil || namespace == "" || strings.Contains(bareName, "/") {
+		return imageReference
+	}
+	named, err := reference.ParseNormalizedNamed(bareName)
+	if err != nil {
+		return imageReference
+	}
+	name := reference.FamiliarName(named)
+	is := &imagev1.ImageStream{}
+	if err = reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, is); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			ctrllog.FromContext(ctx).V(3).Info("Unable to get the ImageStream", "imageReference", imageReference,
+				"error", err.Error())
+		}
+		return imageReference
+	}
+	if !is.Spec.LookupPolicy.Local {
+		return imageReference
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		if !imageStreamHasImage(is, digested.Digest().String()) {
+			return imageReference
+		}
+		return "//" + hostname + "/" + namespace + "/" + name + "@" + digested.Digest().String()
+	}
+	tag := defaultTag
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+	if !imageStreamHasTag(is, tag) {
+		return imageReference
+	}
+	return "//" + hostname + "/" + namespace + "/" + name + ":" + tag
+}
+
+// resolve resolves a reference to an image of the internal registry through the ImageStreamTags and
+// ImageStreamImages. It returns nil if the reference is not an ImageStream reference or cannot be resolved: the
+// image is then inspected in the registry.
+func (s *imageStreams) resolve(ctx context.Context, imageReference string) *imageStreamResolution {
+	s.mutex.RLock()
+	reader, hostnames := s.reader, s.internalRegistryHostnames
+	s.mutex.RUnlock()
+	if reader == nil {
+		return nil
+	}
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(imageReference, "//"))
+	if err != nil || !hostnames.Has(reference.Domain(named)) {
+		return nil
+	}
+	namespace, name, ok := strings.Cut(reference.Path(named), "/")
+	if !ok || strings.Contains(name, "/") {
+		return nil
+	}
+	log := ctrllog.FromContext(ctx).WithValues("imageReference", imageReference)
+	var image *imagev1.Image
+	if digested, ok := named.(reference.Digested); ok {
+		isi := &imagev1.ImageStreamImage{}
+		err = reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name + "@" + digested.Digest().String()}, isi)
+		image = &isi.Image
+	} else {
+		tag := defaultTag
+		if tagged, ok := named.(reference.Tagged); ok {
+			tag = tagged.Tag()
+		}
+		ist := &imagev1.ImageStreamTag{}
+		err = reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name + ":" + tag}, ist)
+		image = &ist.Image
+	}
+	if err != nil {
+		log.V(3).Info("Unable to resolve the image through the image API", "error", err.Error())
+		return nil
+	}
+	d, err := digest.Parse(image.Name)
+	if err != nil {
+		log.V(3).Info("The image API returned an image with an invalid digest", "image", image.Name)
+		return nil
+	}
+	resolution := &imageStreamResolution{digest: d, pullSpec: imageReference}
+	if image.DockerImageReference != "" {
+		resolution.pullSpec = "//" + image.DockerImageReference
+	}
+	resolution.platforms = s.platformsOfImage(ctx, reader, image)
+	if resolution.platforms != nil {
+		metrics.ImageStreamResolutions.WithLabelValues(imageStreamResolutionMetadata).Inc()
+	} else {
+		metrics.ImageStreamResolutions.WithLabelValues(imageStreamResolutionPullSpec).Inc()
+	}
+	return resolution
+}
+
+// platformsOfImage returns the platforms of the image according to the metadata stored by the image API, or nil if
+// the metadata is not enough to know them. Like in the inspections, the operator bundle images support all the
+// architectures and the config of the first valid manifest of a manifest list tells whether the image is a bundle.
+func (s *imageStreams) platformsOfImage(ctx context.Context, reader client.Reader, image *imagev1.Image) sets.Set[Platform] {
+	// The architecture-agnostic image rules match the annotations and the media types of the manifests, which the
+	// image API does not expose.
+	if s.rules.architectureAgnosticRules.configured() {
+		return nil
+	}
+	if len(image.DockerImageManifests) == 0 {
+		config, ok := imageConfigOf(image)
+		if !ok {
+			return nil
+		}
+		if isBundleImage(config.Config) {
+			return PlatformsOf(s.rules.architectures.supported())
+		}
+		return sets.New[Platform](s.rules.architectures.normalizePlatform(Platform{
+			OS:           osOrDefault(config.OS),
+			Architecture: config.Architecture,
+			Variant:      config.Variant,
+		}))
+	}
+	// The deep validation of the manifest lists requires fetching the manifests and the configs of their entries.
+	if s.rules.manifestListValidation.enabled() {
+		return nil
+	}
+	platforms := sets.New[Platform]()
+	var firstDigest string
+	for _, m := range image.DockerImageManifests {
+		// e.g., the attestation manifests are imported with the unknown architecture
+		if m.Architecture == "" || m.Architecture == "unknown" {
+			continue
+		}
+		platforms.Insert(s.rules.architectures.normalizePlatform(Platform{
+			OS:           osOrDefault(m.OS),
+			Architecture: m.Architecture,
+			Variant:      m.Variant,
+		}))
+		if firstDigest == "" {
+			firstDigest = m.Digest
+		}
+	}
+	if firstDigest == "" {
+		return nil
+	}
+	// The manifests of a manifest list are stored as cluster-scoped Images named after their digest.
+	first := &imagev1.Image{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: firstDigest}, first); err != nil {
+		ctrllog.FromContext(ctx).V(3).Info("Unable to get the first image of the manifest list", "digest", firstDigest,
+			"error", err.Error())
+		return nil
+	}
+	config, ok := imageConfigOf(first)
+	if !ok {
+		return nil
+	}
+	if isBundleImage(config.Config) {
+		return PlatformsOf(s.rules.architectures.supported())
+	}
+	return platforms
+}
+
+// imageConfigOf returns the config of a single-manifest image from its config blob, when the image API returns it,
+// or from its metadata otherwise. It returns false if the architecture of the image is unknown.
+func imageConfigOf(image *imagev1.Image) (*ociv1.Image, bool) {
+	config := &ociv1.Image{}
+	if image.DockerImageConfig != "" {
+		if err := json.Unmarshal([]byte(image.DockerImageConfig), config); err == nil && config.Architecture != "" {
+			return config, true
+		}
+	}
+	if len(image.DockerImageMetadata.Raw) == 0 {
+		return nil, false
+	}
+	metadata := &docker10.DockerImage{}
+	if err := json.Unmarshal(image.DockerImageMetadata.Raw, metadata); err != nil || metadata.Architecture == "" {
+		return nil, false
+	}
+	// The metadata of the image API does not store the OS: only linux images can be pushed to the internal registry.
+	config = &ociv1.Image{Platform: ociv1.Platform{Architecture: metadata.Architecture}}
+	if metadata.Config != nil {
+		config.Config.Labels = metadata.Config.Labels
+	}
+	return config, true
+}
+
+// imageStreamHasTag returns true if the tag of the ImageStream points to an image.
+func imageStreamHasTag(is *imagev1.ImageStream, tag string) bool {
+	for _, t := range is.Status.Tags {
+		if t.Tag == tag {
+			return len(t.Items) > 0
+		}
+	}
+	return false
+}
+
+// imageStreamHasImage returns true if the image of the given digest is in the history of a tag of the ImageStream.
+func imageStreamHasImage(is *imagev1.ImageStream, imageDigest string) bool {
+	for _, t := range is.Status.Tags {
+		for _, item := range t.Items {
+			if item.Image == imageDigest {
+				return true
+			}
+		}
+	}
+	return false
+}
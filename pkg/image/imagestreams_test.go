@@ -0,0 +1,287 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/openshift/api/image/docker10"
+	imagev1 "github.com/openshift/api/image/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+const (
+	testListDigest  = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testArm64Digest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// fakeImageReader serves the given image API objects by namespace and name.
+type fakeImageReader struct {
+	objects map[client.ObjectKey]client.Object
+}
+
+func newFakeImageReader(objects ...client.Object) *fakeImageReader {
+	r := &fakeImageReader{objects: map[client.ObjectKey]client.Object{}}
+	for _, obj := range objects {
+		r.objects[client.ObjectKeyFromObject(obj)] = obj
+	}
+	return r
+}
+
+func (r *fakeImageReader) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	stored, ok := r.objects[key]
+	if !ok || reflect.TypeOf(stored) != reflect.TypeOf(obj) {
+		return apierrors.NewNotFound(schema.GroupResource{Group: imagev1.GroupName}, key.Name)
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(stored).Elem())
+	return nil
+}
+
+func (r *fakeImageReader) List(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+	return nil
+}
+
+// newEnabledImageStreams returns an imageStreams resolving the references with the given reader.
+func newEnabledImageStreams(reader client.Reader) *imageStreams {
+	metrics.InitCommonMetrics()
+	s := newImageStreams(newInspectionRules())
+	s.enable(reader)
+	return s
+}
+
+func metadataOf(architecture string, labels map[string]string) runtime.RawExtension {
+	raw, _ := json.Marshal(docker10.DockerImage{
+		TypeMeta:     metav1.TypeMeta{Kind: "DockerImage", APIVersion: "image.openshift.io/1.0"},
+		Architecture: architecture,
+		Config:       &docker10.DockerConfig{Labels: labels},
+	})
+	return runtime.RawExtension{Raw: raw}
+}
+
+func imageStreamTag(namespace, name string, image imagev1.Image) *imagev1.ImageStreamTag {
+	return &imagev1.ImageStreamTag{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}, Image: image}
+}
+
+func Test_imageStreams_resolve(t *testing.T) {
+	s := newEnabledImageStreams(newFakeImageReader(
+		imageStreamTag("test", "single:latest", imagev1.Image{
+			ObjectMeta:           metav1.ObjectMeta{Name: testDigest},
+			DockerImageReference: "quay.io/test/single@" + testDigest,
+			DockerImageMetadata:  metadataOf(utils.ArchitecturePpc64le, nil),
+		}),
+		imageStreamTag("test", "list:v1", imagev1.Image{
+			ObjectMeta:           metav1.ObjectMeta{Name: testListDigest},
+			DockerImageReference: "quay.io/test/list@" + testListDigest,
+			DockerImageManifests: []imagev1.ImageManifest{
+				{Digest: "sha256:attestation", Architecture: "unknown", OS: "unknown"},
+				{Digest: testArm64Digest, Architecture: utils.ArchitectureArm64, OS: "linux", Variant: "v8"},
+				{Digest: "sha256:amd64", Architecture: utils.ArchitectureAmd64},
+			},
+		}),
+		&imagev1.Image{
+			ObjectMeta:          metav1.ObjectMeta{Name: testArm64Digest},
+			DockerImageMetadata: metadataOf(utils.ArchitectureArm64, nil),
+		},
+		imageStreamTag("test", "bundle:latest", imagev1.Image{
+			ObjectMeta: metav1.ObjectMeta{Name: testDigest},
+			DockerImageMetadata: metadataOf(utils.ArchitectureAmd64,
+				map[string]string{osdkBundleMetadataAnnotation: "metadata/"}),
+		}),
+		imageStreamTag("test", "imported:latest", imagev1.Image{
+			ObjectMeta:           metav1.ObjectMeta{Name: testDigest},
+			DockerImageReference: "quay.io/test/imported@" + testDigest,
+		}),
+		&imagev1.ImageStreamImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "single@" + testDigest},
+			Image: imagev1.Image{
+				ObjectMeta:          metav1.ObjectMeta{Name: testDigest},
+				DockerImageMetadata: metadataOf(utils.ArchitectureS390x, nil),
+			},
+		},
+	))
+	tests := []struct {
+		name           string
+		imageReference string
+		wantResolved   bool
+		wantPullSpec   string
+		wantPlatforms  sets.Set[Platform]
+	}{
+		{
+			name:           "single manifest image with metadata",
+			imageReference: "//image-registry.openshift-image-registry.svc:5000/test/single",
+			wantResolved:   true,
+			wantPullSpec:   "//quay.io/test/single@" + testDigest,
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitecturePpc64le)),
+		},
+		{
+			name:           "manifest list",
+			imageReference: "//image-registry.openshift-image-registry.svc.cluster.local:5000/test/list:v1",
+			wantResolved:   true,
+			wantPullSpec:   "//quay.io/test/list@" + testListDigest,
+			wantPlatforms: sets.New[Platform](
+				Platform{OS: "linux", Architecture: utils.ArchitectureArm64, Variant: "v8"},
+				Platform{OS: "linux", Architecture: utils.ArchitectureAmd64}),
+		},
+		{
+			name:           "operator bundle image",
+			imageReference: "//image-registry.openshift-image-registry.svc:5000/test/bundle:latest",
+			wantResolved:   true,
+			wantPullSpec:   "//image-registry.openshift-image-registry.svc:5000/test/bundle:latest",
+			wantPlatforms:  PlatformsOf(utils.AllSupportedArchitecturesSet()),
+		},
+		{
+			name:           "image without metadata",
+			imageReference: "//image-registry.openshift-image-registry.svc:5000/test/imported",
+			wantResolved:   true,
+			wantPullSpec:   "//quay.io/test/imported@" + testDigest,
+		},
+		{
+			name:           "image stream image",
+			imageReference: "//image-registry.openshift-image-registry.svc:5000/test/single@" + testDigest,
+			wantResolved:   true,
+			wantPullSpec:   "//image-registry.openshift-image-registry.svc:5000/test/single@" + testDigest,
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureS390x)),
+		},
+		{
+			name:           "missing image stream tag",
+			imageReference: "//image-registry.openshift-image-registry.svc:5000/test/missing:latest",
+		},
+		{
+			name:           "other registry",
+			imageReference: "//quay.io/test/single:latest",
+		},
+		{
+			name:           "nested repository",
+			imageReference: "//image-registry.openshift-image-registry.svc:5000/test/nested/single:latest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolution := s.resolve(context.Background(), tt.imageReference)
+			if !tt.wantResolved {
+				if resolution != nil {
+					t.Fatalf("expected the reference not to be resolved, got %+v", resolution)
+				}
+				return
+			}
+			if resolution == nil {
+				t.Fatal("expected the reference to be resolved")
+			}
+			if resolution.pullSpec != tt.wantPullSpec {
+				t.Errorf("expected the pull spec %q, got %q", tt.wantPullSpec, resolution.pullSpec)
+			}
+			if tt.wantPlatforms == nil && resolution.platforms != nil {
+				t.Errorf("expected the platforms to be unknown, got %v", resolution.platforms)
+			} else if tt.wantPlatforms != nil && !resolution.platforms.Equal(tt.wantPlatforms) {
+				t.Errorf("expected the platforms %v, got %v", tt.wantPlatforms, resolution.platforms)
+			}
+		})
+	}
+}
+
+func Test_imageStreams_resolve_disabled(t *testing.T) {
+	s := newImageStreams(newInspectionRules())
+	if resolution := s.resolve(context.Background(),
+		"//image-registry.openshift-image-registry.svc:5000/test/single:latest"); resolution != nil {
+		t.Errorf("expected no resolution without reader, got %+v", resolution)
+	}
+	if got := s.localReference(context.Background(), "test", "//single"); got != "//single" {
+		t.Errorf("expected the reference to be returned as is without reader, got %q", got)
+	}
+}
+
+func Test_imageStreams_localReference(t *testing.T) {
+	imageStream := func(name string, local bool) *imagev1.ImageStream {
+		return &imagev1.ImageStream{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name},
+			Spec:       imagev1.ImageStreamSpec{LookupPolicy: imagev1.ImageLookupPolicy{Local: local}},
+			Status: imagev1.ImageStreamStatus{Tags: []imagev1.NamedTagEventList{
+				{Tag: "latest", Items: []imagev1.TagEvent{{Image: testDigest}}},
+				{Tag: "empty"},
+			}},
+		}
+	}
+	s := newEnabledImageStreams(newFakeImageReader(imageStream("local", true), imageStream("remote", false)))
+	if !s.configureInternalRegistryHostnames("registry.internal:5000", []string{"registry.example.com"}) {
+		t.Fatal("expected the internal registry hostnames to change")
+	}
+	tests := []struct {
+		imageReference string
+		want           string
+	}{
+		{imageReference: "//local", want: "//registry.internal:5000/test/local:latest"},
+		{imageReference: "//local:latest", want: "//registry.internal:5000/test/local:latest"},
+		{imageReference: "//local@" + testDigest, want: "//registry.internal:5000/test/local@" + testDigest},
+		{imageReference: "//local@" + testListDigest, want: "//local@" + testListDigest},
+		{imageReference: "//local:empty", want: "//local:empty"},
+		{imageReference: "//local:missing", want: "//local:missing"},
+		{imageReference: "//remote:latest", want: "//remote:latest"},
+		{imageReference: "//missing:latest", want: "//missing:latest"},
+		{imageReference: "//quay.io/test/local:latest", want: "//quay.io/test/local:latest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.imageReference, func(t *testing.T) {
+			if got := s.localReference(context.Background(), "test", tt.imageReference); got != tt.want {
+				t.Errorf("localReference() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if s.configureInternalRegistryHostnames("registry.internal:5000", []string{"registry.example.com"}) {
+		t.Error("expected the internal registry hostnames not to change")
+	}
+	if s.resolve(context.Background(), "//registry.example.com/test/missing:latest") != nil ||
+		!s.internalRegistryHostnames.HasAll(append(defaultInternalRegistryHostnames,
+			"registry.internal:5000", "registry.example.com")...) {
+		t.Errorf("unexpected internal registry hostnames %v", sets.List(s.internalRegistryHostnames))
+	}
+}
+
+func Test_cacheProxy_GetCompatiblePlatformsSet_imageStreams(t *testing.T) {
+	metrics.InitCommonMetrics()
+	c := newCacheProxy()
+	c.state.imageStreams.enable(newFakeImageReader(
+		imageStreamTag("test", "single:latest", imagev1.Image{
+			ObjectMeta:          metav1.ObjectMeta{Name: testDigest},
+			DockerImageMetadata: metadataOf(utils.ArchitectureArm64, nil),
+		}),
+		imageStreamTag("test", "imported:latest", imagev1.Image{
+			ObjectMeta:           metav1.ObjectMeta{Name: testListDigest},
+			DockerImageReference: "quay.io/test/imported@" + testListDigest,
+		}),
+	))
+	inspector := &countingInspector{
+		digest:    digest.Digest(testListDigest),
+		platforms: PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+	}
+	c.registryInspector = inspector
+	platforms, err := c.GetCompatiblePlatformsSet(context.Background(),
+		"//image-registry.openshift-image-registry.svc:5000/test/single:latest", true, nil)
+	if err != nil || !platforms.Equal(PlatformsOf(sets.New[string](utils.ArchitectureArm64))) {
+		t.Fatalf("unexpected result: %v, %v", platforms, err)
+	}
+	if inspector.heads != 0 || inspector.inspections != 0 {
+		t.Errorf("expected the registry not to be accessed, got %d HEAD requests and %d inspections",
+			inspector.heads, inspector.inspections)
+	}
+	for range 2 {
+		platforms, err = c.GetCompatiblePlatformsSet(context.Background(),
+			"//image-registry.openshift-image-registry.svc:5000/test/imported:latest", false, nil)
+		if err != nil || !platforms.Equal(inspector.platforms) {
+			t.Fatalf("unexpected result: %v, %v", platforms, err)
+		}
+	}
+	if inspector.heads != 0 || inspector.inspections != 1 {
+		t.Errorf("expected the pull spec to be inspected once without HEAD requests, got %d HEAD requests and %d inspections",
+			inspector.heads, inspector.inspections)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+
+	"github.com/opencontainers/go-digest"
+)
+
+type inspectedDigestHandlerKey struct{}
+
+// WithInspectedDigestHandler returns a copy of ctx in which the lookups of the image platforms call handler with the
+// image reference and the digest of the manifest, or manifest list, whose platforms were returned, including when the
+// result is cached. The handler is not called when the digest is unknown, nor when the tag of the image may be resolved
+// by a mirror or a location other than its registry, as the digest may not be pullable by the nodes. It may be
+// called concurrently.
+func WithInspectedDigestHandler(ctx context.Context, handler func(imageReference string, d digest.Digest)) context.Context {
+	return context.WithValue(ctx, inspectedDigestHandlerKey{}, handler)
+}
+
+// reportInspectedDigest passes the given digest, inspected for the given image, to the handler of ctx, if any, unless
+// the tag of the image may be resolved elsewhere according to the given registries configuration.
+func reportInspectedDigest(ctx context.Context, registriesConfig *registriesConfig, imageReference string,
+	d digest.Digest) {
+	if d == "" {
+		return
+	}
+	handler, ok := ctx.Value(inspectedDigestHandlerKey{}).(func(string, digest.Digest))
+	if !ok || registriesConfig.resolvesTagsElsewhere(imageReference) {
+		return
+	}
+	handler(imageReference, d)
+}
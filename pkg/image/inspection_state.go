@@ -0,0 +1,97 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+)
+
+// inspectionRules is the configuration of the ClusterPodPlacementConfig the inspection results depend on.
+type inspectionRules struct {
+	architectures             *architectures
+	architectureAgnosticRules *architectureAgnosticRules
+	manifestListValidation    *manifestListValidation
+	binaryVerification        *binaryVerification
+	runtimePlatforms          *runtimePlatforms
+}
+
+func newInspectionRules() *inspectionRules {
+	return &inspectionRules{
+		architectures:             newArchitectures(),
+		architectureAgnosticRules: &architectureAgnosticRules{},
+		manifestListValidation:    &manifestListValidation{},
+		binaryVerification:        newBinaryVerification(),
+		runtimePlatforms:          &runtimePlatforms{},
+	}
+}
+
+// hash returns a hash of the configuration. It is recorded in the ImageArchitecture objects so that the results
+// inspected with a different configuration are not reused.
+func (r *inspectionRules) hash() string {
+	architectures := r.architectures.config.Load()
+	data, _ := json.Marshal(struct {
+		SupportedArchitectures     []string                                `json:"supportedArchitectures"`
+		Aliases                    map[string]Platform                     `json:"aliases"`
+		ArchitectureAgnosticRules  []v1beta1.ArchitectureAgnosticImageRule `json:"architectureAgnosticRules"`
+		DeepManifestListValidation bool                                    `json:"deepManifestListValidation"`
+		BinaryVerification         []string                                `json:"binaryVerification"`
+		RuntimeMediaTypes          map[string]Platform                     `json:"runtimeMediaTypes"`
+	}{
+		SupportedArchitectures:     sets.List(architectures.supportedArchitectures),
+		Aliases:                    architectures.aliases,
+		ArchitectureAgnosticRules:  r.architectureAgnosticRules.list(),
+		DeepManifestListValidation: r.manifestListValidation.enabled(),
+		BinaryVerification:         r.binaryVerification.imagePatterns(),
+		RuntimeMediaTypes:          r.runtimePlatforms.configuredMediaTypes(),
+	})
+	return computeHash(data)
+}
+
+// inspectionState is the configuration of the inspections applied from the ClusterPodPlacementConfig and the cluster,
+// and the state they share, e.g., the registry sessions and the circuit breakers. It is owned by the cacheProxy and
+// shared with its registryInspector: each Facade has its own.
+type inspectionState struct {
+	*inspectionRules
+	registriesConfig    *registriesConfig
+	registrySessions    *registrySessions
+	registryGuards      *registryGuards
+	credentialProviders *credentialProviders
+	imageStreams        *imageStreams
+	nodeImages          *nodeImages
+	offlineSources      *offlineSources
+}
+
+// newInspectionState returns the default state of the inspections: the features depending on the cluster, e.g., the
+// image streams, are disabled until they are enabled through the Facade.
+func newInspectionState() *inspectionState {
+	rules := newInspectionRules()
+	registriesConfig := newRegistriesConfig()
+	return &inspectionState{
+		inspectionRules:     rules,
+		registriesConfig:    registriesConfig,
+		registrySessions:    newRegistrySessions(registriesConfig),
+		registryGuards:      newRegistryGuards(),
+		credentialProviders: &credentialProviders{},
+		imageStreams:        newImageStreams(rules),
+		nodeImages:          &nodeImages{},
+		offlineSources:      newOfflineSources(),
+	}
+}
@@ -0,0 +1,70 @@
+package image
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+func Test_inspectionRules_hash(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(r *inspectionRules)
+	}{
+		{
+			name: "supported architectures",
+			configure: func(r *inspectionRules) {
+				r.architectures.configure(sets.New[string](utils.ArchitectureAmd64), nil)
+			},
+		},
+		{
+			name: "architecture aliases",
+			configure: func(r *inspectionRules) {
+				r.architectures.configure(nil, []v1beta1.ArchitectureAlias{{Name: "armv8l", Architecture: "arm",
+					Variant: "v8"}})
+			},
+		},
+		{
+			name: "architecture-agnostic rules",
+			configure: func(r *inspectionRules) {
+				r.architectureAgnosticRules.configure([]v1beta1.ArchitectureAgnosticImageRule{
+					{Name: "artifacts", MediaType: "application/vnd.example.artifact"}})
+			},
+		},
+		{
+			name: "deep manifest list validation",
+			configure: func(r *inspectionRules) {
+				r.manifestListValidation.configure(true)
+			},
+		},
+		{
+			name: "binary verification",
+			configure: func(r *inspectionRules) {
+				r.binaryVerification.configure(&v1beta1.BinaryVerificationConfig{ImagePatterns: []string{"quay.io/*"}})
+			},
+		},
+		{
+			name: "runtime platforms",
+			configure: func(r *inspectionRules) {
+				r.runtimePlatforms.configure([]v1beta1.RuntimeClassMapping{{Platform: "wasi/wasm",
+					MediaTypes: []string{wasmModuleLayerMediaType}, RuntimeClassName: "wasm"}})
+			},
+		},
+	}
+	defaultHash := newInspectionRules().hash()
+	if newInspectionRules().hash() != defaultHash {
+		t.Fatalf("expected the hash of the default configuration to be stable")
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newInspectionRules()
+			tt.configure(r)
+			if r.hash() == defaultHash {
+				t.Errorf("expected the hash to change with the %s", tt.name)
+			}
+		})
+	}
+}
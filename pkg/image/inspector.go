@@ -23,23 +23,28 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
 	"github.com/containers/image/v5/image"
 	"github.com/containers/image/v5/manifest"
 	"github.com/containers/image/v5/pkg/shortnames"
 	"github.com/containers/image/v5/pkg/sysregistriesv2"
 	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/transports"
 	"github.com/containers/image/v5/types"
 	"github.com/opencontainers/go-digest"
 	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"golang.org/x/sys/unix"
 
-	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
 )
 
 const (
@@ -63,35 +68,91 @@ type registryInspector struct {
 	globalPullSecret []byte
 	// mutex is used to protect the globalPullSecret field of the singletonImageFacade from concurrent write access
 	mutex sync.RWMutex
+	// state is the configuration of the inspections, shared with the cacheProxy.
+	state *inspectionState
+}
+
+// inspectionResult holds the outcome of the inspection of an image.
+type inspectionResult struct {
+	// digest is the digest of the manifest (or manifest list) the image reference resolved to.
+	digest digest.Digest
+	// platforms is the set of platforms supported by the image.
+	platforms sets.Set[Platform]
+	// mirror is the location of the mirror the image was inspected from. It is empty if the image was inspected
+	// from its source, or if the mirrors were tried by the containers/image library.
+	mirror string
+	// architectureAgnosticRule is the name of the architecture-agnostic image rule the image matched, if any.
+	architectureAgnosticRule string
+	// source is where the image was inspected. It is empty if the image was inspected in its registry.
+	source v1beta1.ImageArchitectureSource
+	// discrepancies are the entries of the manifest list dropped by the deep validation, reported to every caller.
+	discrepancies []ManifestListDiscrepancy
+}
+
+// sourceOrDefault returns where the image was inspected, defaulting to its registry.
+func (r *inspectionResult) sourceOrDefault() v1beta1.ImageArchitectureSource {
+	if r.source == "" {
+		return v1beta1.ImageArchitectureSourceRegistry
+	}
+	return r.source
+}
+
+// architectures returns the set of architectures supported by the image.
+func (r *inspectionResult) architectures() sets.Set[string] {
+	return ArchitecturesOf(r.platforms)
 }
 
 // GetCompatibleArchitecturesSet returns the set of compatibles architectures given an imageReference and a list of secrets.
 // It uses the containers/image library to get the manifest of the image and extract the architecture from it.
 // If the image is a manifest list, it will return the set of architectures supported by the manifest list.
 // If the image is a manifest, it will return the architecture set in the manifest's config.
+// If the image is a WebAssembly image or an artifact mapped to a RuntimeClass, it will return the architecture of
+// its runtime-specific platform, e.g., wasm. The other artifacts get an empty set.
 // If the image is an operator bundle image, it will return an empty set. This is because operator bundle images
 // are not tied to a specific architecture, and we should not set any constraints based on the architecture they report.
-func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, imageReference string, _ bool, secrets [][]byte) (supportedArchitectures sets.Set[string], err error) {
-	// Create the auth file
+func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, imageReference string, _ bool, secrets [][]byte) (sets.Set[string], error) {
+	result, err := i.inspect(ctx, imageReference, secrets)
+	if err != nil {
+		return nil, newInspectionError(err)
+	}
+	reportManifestListDiscrepancies(ctx, imageReference, result.discrepancies)
+	return result.architectures(), nil
+}
+
+// GetCompatiblePlatformsSet returns the set of platforms, including the CPU variants, supported by the given image.
+func (i *registryInspector) GetCompatiblePlatformsSet(ctx context.Context, imageReference string, _ bool, secrets [][]byte) (sets.Set[Platform], error) {
+	result, err := i.inspect(ctx, imageReference, secrets)
+	if err != nil {
+		return nil, newInspectionError(err)
+	}
+	reportManifestListDiscrepancies(ctx, imageReference, result.discrepancies)
+	return result.platforms, nil
+}
+
+// inspect implements GetCompatibleArchitecturesSet and also returns the digest of the inspected manifest.
+// The image is looked up in the offline source matching its repository first, if any. Otherwise, the inspection is
+// subject to the policy of the registry of the image.
+func (i *registryInspector) inspect(ctx context.Context, imageReference string, secrets [][]byte) (*inspectionResult, error) {
+	// The offline sources are not subject to the policies of the registries.
+	if result, ok, err := i.state.inspectOffline(ctx, imageReference); ok {
+		return result, err
+	}
+	ctx, lease, err := i.state.registryGuards.acquire(ctx, imageReference)
+	if err != nil {
+		return nil, err
+	}
+	result, err := i.inspectImage(ctx, imageReference, secrets)
+	lease.release(err)
+	return result, err
+}
+
+func (i *registryInspector) inspectImage(ctx context.Context, imageReference string, secrets [][]byte) (result *inspectionResult, err error) {
 	log := ctrllog.FromContext(ctx, "imageReference", imageReference)
-	i.mutex.RLock()
-	globalPullSecret := i.globalPullSecret
-	i.mutex.RUnlock()
-	authFile, err := i.createAuthFile(imageReference, append([][]byte{globalPullSecret}, secrets...)...)
+	sys, closeAuthFile, err := i.newSystemContext(ctx, imageReference, secrets)
 	if err != nil {
-		log.Error(err, "Couldn't write auth file")
 		return nil, err
-	} else {
-		defer func(f *os.File) {
-			if err := f.Close(); err != nil {
-				log.Error(err, "Failed to close auth file", "filename", f.Name())
-			}
-		}(authFile)
 	}
-	// Invalidate registry cache before calling image APIs to catch updates to registry configurations.
-	// TODO: watch ICSP/IDMS/ITMS for changes or alternatively invalidate only on MCP updates rather
-	// than do this everytime
-	sysregistriesv2.InvalidateCache()
+	defer closeAuthFile()
 
 	// check if image reference has both tag and digest
 	imageReference, err = parseImageReference(imageReference)
@@ -100,37 +161,61 @@ func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, i
 		return nil, err
 	}
 
-	sys := &types.SystemContext{
-		AuthFilePath:                authFile.Name(),
-		RegistriesDirPath:           RegistryCertsDir(),
-		SystemRegistriesConfPath:    RegistriesConfPath(),
-		SystemRegistriesConfDirPath: RegistriesConfDir(),
-		SignaturePolicyPath:         PolicyConfPath(),
-	}
-	// Only override DockerPerHostCertDirPath when explicitly configured via env var.
-	// When unset, the containers/image library fallback checks both
-	// /etc/containers/certs.d and /etc/docker/certs.d per-host.
-	if dockerCerts := DockerCertsDir(); dockerCerts != "" {
-		sys.DockerPerHostCertDirPath = dockerCerts
-	}
-
 	// Check if the image is a manifest list
-	src, err := resolveAndOpenImageSource(ctx, sys, imageReference)
+	now := time.Now()
+	src, mirror, session, err := i.state.resolveAndOpenImageSource(ctx, sys, imageReference)
 	if err != nil {
 		log.Error(err, "Error creating the image source")
 		return nil, err
 	}
+	defer func() {
+		if err == nil {
+			metrics.RegistryRequestDuration.WithLabelValues("inspection", session.label()).Observe(time.Since(now).Seconds())
+			if mirror != "" {
+				result.mirror = mirror
+				metrics.MirrorInspections.WithLabelValues(mirror).Inc()
+				log.V(1).Info("The image was inspected from a mirror", "mirror", mirror)
+			}
+		} else if classifyError(err) == common.ImageInspectionErrorAuthDenied {
+			// The token may have been revoked
+			session.invalidate()
+		}
+	}()
 	defer func(src types.ImageSource) {
 		err := src.Close()
 		if err != nil {
 			log.Error(err, "Error closing the image source for the image")
 		}
 	}(src)
+	return i.state.inspectSource(ctrllog.IntoContext(ctx, log), sys, src, imageReference)
+}
+
+// inspectSource returns the digest and the platforms of the image of the given source, after verifying that the
+// signature policy allows running it. The source may be a registry or an offline source. The binaries of the images
+// whose reference matches the patterns of the binary verification are verified.
+func (s *inspectionState) inspectSource(ctx context.Context, sys *types.SystemContext, src types.ImageSource,
+	imageReference string) (result *inspectionResult, err error) {
+	log := ctrllog.FromContext(ctx)
+	// The discrepancies are reported by the lookups of the result, or to the caller of the failed inspections, which
+	// are not cached.
+	var discrepancies []ManifestListDiscrepancy
+	defer func() {
+		if result != nil {
+			result.discrepancies = discrepancies
+		} else {
+			reportManifestListDiscrepancies(ctx, imageReference, discrepancies)
+		}
+	}()
 	rawManifest, _, err := src.GetManifest(ctx, nil)
 	if err != nil {
 		log.Error(err, "Error getting the image manifest: %v")
 		return nil, err
 	}
+	manifestDigest, err := manifest.Digest(rawManifest)
+	if err != nil {
+		log.Error(err, "Error computing the digest of the image manifest")
+		return nil, malformedManifestError(err)
+	}
 	policy, err := signature.DefaultPolicy(sys)
 	if err != nil {
 		log.Error(err, "Error loading the systemContext's policy")
@@ -142,13 +227,15 @@ func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, i
 		return nil, err
 	}
 
-	supportedArchitectures = sets.New[string]()
+	supportedPlatforms := sets.New[Platform]()
+	var instances []manifestInstance
 	var instanceDigest *digest.Digest = nil
+	deep, dropped := s.manifestListValidation.enabled(), 0
 	if manifest.MIMETypeIsMultiImage(manifest.GuessMIMEType(rawManifest)) {
 		index, err := manifest.OCI1IndexFromManifest(rawManifest)
 		if err != nil {
 			log.Error(err, "Error parsing the OCI index from the raw manifest of the image")
-			return nil, err
+			return nil, malformedManifestError(err)
 		}
 		for _, m := range index.Manifests {
 			// Skip manifests with Docker reference annotations - they are not runnable platform images
@@ -171,7 +258,28 @@ func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, i
 				log.V(3).Info("Skipping manifest with unknown platform", "architecture", m.Platform.Architecture, "os", m.Platform.OS, "digest", m.Digest)
 				continue
 			}
-			supportedArchitectures = sets.Insert(supportedArchitectures, m.Platform.Architecture)
+			platform := s.architectures.normalizePlatform(Platform{
+				OS:           osOrDefault(m.Platform.OS),
+				Architecture: m.Platform.Architecture,
+				Variant:      m.Platform.Variant,
+			})
+			// In the deep validation mode, the entries whose manifest or config is missing, or whose config
+			// does not match the platform declared in the index, are dropped.
+			if deep {
+				discrepancy, err := s.validateManifestListEntry(ctx, sys, src, m.Digest, platform)
+				if err != nil {
+					log.Error(err, "Error validating the entry of the manifest list")
+					return nil, err
+				}
+				if discrepancy != nil {
+					logManifestListDiscrepancy(ctx, *discrepancy)
+					discrepancies = append(discrepancies, *discrepancy)
+					dropped++
+					continue
+				}
+			}
+			supportedPlatforms.Insert(platform)
+			instances = append(instances, manifestInstance{digest: m.Digest, platform: platform})
 			// Store the first valid manifest digest for bundle image detection
 			if instanceDigest == nil {
 				instanceDigest = &m.Digest
@@ -191,7 +299,7 @@ func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, i
 		// IsRunningImageAllowed returns true iff the policy allows running the image.
 		// If it returns false, err must be non-nil, and should be an PolicyRequirementError if evaluation
 		// succeeded but the result was rejection.
-		var e *signature.PolicyRequirementError
+		var e signature.PolicyRequirementError
 		if errors.As(err, &e) {
 			// false and valid error
 			log.V(3).Info("The signature policy JSON file configuration does not allow inspecting this image",
@@ -201,6 +309,32 @@ func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, i
 		log.Error(err, "Unable to perform the signature validation")
 		return nil, err
 	}
+	if instanceDigest == nil && dropped > 0 {
+		// The result is not cached: the missing entries may be pushed later.
+		log.V(3).Info("The deep validation dropped all the entries of the manifest list")
+		return nil, malformedManifestError(errAllManifestListEntriesDropped)
+	}
+
+	// The images whose media types are mapped to a runtime-specific platform, e.g., the WebAssembly ones, run on
+	// that platform whatever their config reports. The other artifacts have no image config and cannot run.
+	rawInstanceManifest, _, err := unparsedImage.Manifest(ctx)
+	if err != nil {
+		log.Error(err, "Error getting the manifest of the image")
+		return nil, err
+	}
+	if platform, ok := s.runtimePlatforms.platformOf(rawInstanceManifest); ok {
+		log.V(3).Info("The image runs on a runtime-specific platform", "platform", platform.String())
+		return &inspectionResult{digest: manifestDigest, platforms: sets.New[Platform](platform)}, nil
+	}
+	if isArtifact(rawInstanceManifest) {
+		if rule, ok := s.architectureAgnosticRules.match(nil, rawManifest, rawInstanceManifest); ok {
+			log.V(3).Info("The artifact matches an architecture-agnostic image rule", "rule", rule)
+			return &inspectionResult{digest: manifestDigest, platforms: PlatformsOf(s.architectures.supported()),
+				architectureAgnosticRule: rule}, nil
+		}
+		log.V(3).Info("The image is an artifact that is not mapped to a runtime-specific platform")
+		return &inspectionResult{digest: manifestDigest, platforms: sets.New[Platform]()}, nil
+	}
 
 	parsedImage, err := image.FromUnparsedImage(ctx, sys, unparsedImage)
 	if err != nil {
@@ -221,14 +355,156 @@ func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, i
 		// We return the full set of supported architectures so that the intersection with the node architecture set
 		// does not change later.
 		// See https://issues.redhat.com/browse/OCPBUGS-38823 for more information.
-		return utils.AllSupportedArchitecturesSet(), nil
+		return &inspectionResult{digest: manifestDigest, platforms: PlatformsOf(s.architectures.supported())}, nil
+	}
+	// Like the operator bundle images, the images matching an architecture-agnostic image rule of the
+	// ClusterPodPlacementConfig support all the architectures.
+	if rule, ok := s.architectureAgnosticRules.match(config.Config.Labels, rawManifest,
+		rawInstanceManifest); ok {
+		log.V(3).Info("The image matches an architecture-agnostic image rule", "rule", rule)
+		return &inspectionResult{digest: manifestDigest, platforms: PlatformsOf(s.architectures.supported()),
+			architectureAgnosticRule: rule}, nil
 	}
 
 	if !manifest.MIMETypeIsMultiImage(manifest.GuessMIMEType(rawManifest)) {
 		log.V(3).Info("The image is not a manifest list... getting the supported architecture")
-		return sets.New[string](config.Architecture), nil
+		platform := s.architectures.normalizePlatform(Platform{
+			OS:           osOrDefault(config.OS),
+			Architecture: config.Architecture,
+			Variant:      config.Variant,
+		})
+		supportedPlatforms.Insert(platform)
+		instances = append(instances, manifestInstance{digest: manifestDigest, platform: platform})
+	}
+	if s.binaryVerification.enabledFor(imageReference) {
+		supportedPlatforms = s.binaryVerification.verify(ctx, sys, src, transports.ImageName(src.Reference()),
+			instances)
+	}
+	return &inspectionResult{digest: manifestDigest, platforms: supportedPlatforms}, nil
+}
+
+// headDigest returns the digest the image reference resolves to, using a HEAD request for the manifest.
+// It is used to revalidate the tag-to-digest entries of the cache without downloading the manifest and the config.
+// Note that the mirrors configuration is not considered by the containers/image library for HEAD requests:
+// callers should fall back to the full inspection if the HEAD request fails.
+func (i *registryInspector) headDigest(ctx context.Context, imageReference string, secrets [][]byte) (digest.Digest, error) {
+	if d, ok, err := i.state.offlineSources.headDigest(ctx, imageReference); ok {
+		return d, err
+	}
+	ctx, lease, err := i.state.registryGuards.acquire(ctx, imageReference)
+	if err != nil {
+		return "", err
+	}
+	d, err := i.headImageDigest(ctx, imageReference, secrets)
+	if err != nil {
+		// The failures of the HEAD requests are not accounted to the health of the registry, as they do not
+		// consider the mirrors: the full inspection will.
+		lease.abort()
+		return "", err
+	}
+	lease.release(nil)
+	return d, nil
+}
+
+func (i *registryInspector) headImageDigest(ctx context.Context, imageReference string, secrets [][]byte) (digest.Digest, error) {
+	log := ctrllog.FromContext(ctx, "imageReference", imageReference)
+	sys, closeAuthFile, err := i.newSystemContext(ctx, imageReference, secrets)
+	if err != nil {
+		return "", err
+	}
+	defer closeAuthFile()
+
+	imageReference, err = parseImageReference(imageReference)
+	if err != nil {
+		log.Error(err, "Couldn't parse image reference")
+		return "", err
+	}
+	resolved, err := shortnames.Resolve(sys, strings.TrimPrefix(imageReference, "//"))
+	if err != nil {
+		log.Error(err, "Failed to resolve image shortname")
+		return "", err
 	}
-	return supportedArchitectures, nil
+	var headErrs []error
+	for _, cand := range resolved.PullCandidates {
+		ref, err := docker.ParseReference(fmt.Sprintf("//%s", cand.Value.String()))
+		if err != nil {
+			headErrs = append(headErrs, err)
+			continue
+		}
+		now := time.Now()
+		headSys, err := i.state.registriesConfig.withProxy(sys, reference.Domain(cand.Value))
+		if err != nil {
+			headErrs = append(headErrs, err)
+			continue
+		}
+		session, err := i.state.registrySessions.open(ctx, headSys, cand.Value)
+		if err != nil {
+			log.V(3).Info("Unable to reuse a registry session", "fullName", cand.Value.String(), "error", err)
+		}
+		if session != nil {
+			d, err := session.headDigest(ctx)
+			if err == nil {
+				metrics.RegistryRequestDuration.WithLabelValues("revalidation", session.label()).Observe(time.Since(now).Seconds())
+				return d, nil
+			}
+			log.V(3).Info("The HEAD request on the registry session failed", "fullName", cand.Value.String(), "error", err)
+		}
+		d, err := docker.GetDigest(ctx, headSys, ref)
+		if err != nil {
+			headErrs = append(headErrs, err)
+			continue
+		}
+		metrics.RegistryRequestDuration.WithLabelValues("revalidation", "cold").Observe(time.Since(now).Seconds())
+		return d, nil
+	}
+	return "", resolved.FormatPullErrors(headErrs)
+}
+
+// newSystemContext builds the containers/image SystemContext to access the given image reference, using the
+// global pull secret and the given secrets. The returned function closes the in-memory auth file and must be called
+// once the SystemContext is no longer used.
+func (i *registryInspector) newSystemContext(ctx context.Context, imageReference string, secrets [][]byte) (*types.SystemContext, func(), error) {
+	log := ctrllog.FromContext(ctx, "imageReference", imageReference)
+	i.mutex.RLock()
+	globalPullSecret := i.globalPullSecret
+	i.mutex.RUnlock()
+	// Create the auth file
+	authFile, err := i.createAuthFile(ctx, imageReference, append([][]byte{globalPullSecret}, secrets...)...)
+	if err != nil {
+		log.Error(err, "Couldn't write auth file")
+		return nil, nil, err
+	}
+	closeAuthFile := func() {
+		if err := authFile.Close(); err != nil {
+			log.Error(err, "Failed to close auth file", "filename", authFile.Name())
+		}
+	}
+	registriesConfPath, inMemory, err := i.state.registriesConfig.confPath()
+	if err != nil {
+		log.Error(err, "Couldn't render the registries configuration")
+		closeAuthFile()
+		return nil, nil, err
+	}
+	if !inMemory {
+		// The mirrors are not configured: the registries configuration of the host is used, and the cache of the
+		// containers/image library is invalidated by the RegistriesConfigSyncer when it changes.
+		registriesConfPath = RegistriesConfPath()
+	}
+
+	sys := &types.SystemContext{
+		AuthFilePath:                authFile.Name(),
+		RegistriesDirPath:           RegistryCertsDir(),
+		SystemRegistriesConfPath:    registriesConfPath,
+		SystemRegistriesConfDirPath: RegistriesConfDir(),
+		SignaturePolicyPath:         PolicyConfPath(),
+	}
+	// Only override DockerPerHostCertDirPath when explicitly configured via env var.
+	// When unset, the containers/image library fallback checks both
+	// /etc/containers/certs.d and /etc/docker/certs.d per-host.
+	if dockerCerts := DockerCertsDir(); dockerCerts != "" {
+		sys.DockerPerHostCertDirPath = dockerCerts
+	}
+	return sys, closeAuthFile, nil
 }
 
 // parseImageReference normalizes an imageName into a reference suitable for use
@@ -296,8 +572,8 @@ func isBundleImage(image ociv1.ImageConfig) bool {
 	return false
 }
 
-func (i *registryInspector) createAuthFile(imageReference string, secrets ...[]byte) (*os.File, error) {
-	authJSON, err := marshaledImagePullSecrets(imageReference, secrets)
+func (i *registryInspector) createAuthFile(ctx context.Context, imageReference string, secrets ...[]byte) (*os.File, error) {
+	authJSON, err := i.state.marshaledImagePullSecrets(ctx, imageReference, secrets)
 	if err != nil {
 		return nil, err
 	}
@@ -310,12 +586,14 @@ func (i *registryInspector) createAuthFile(imageReference string, secrets ...[]b
 	return os.NewFile(uintptr(fd), fp), nil
 }
 
-func marshaledImagePullSecrets(imageReference string, secrets [][]byte) ([]byte, error) {
+// marshaledImagePullSecrets merges the credentials of the kubelet credential providers matching the image reference
+// and the given secrets, in order: the last ones take precedence for the same registry.
+func (s *inspectionState) marshaledImagePullSecrets(ctx context.Context, imageReference string, secrets [][]byte) ([]byte, error) {
 	log := ctrllog.Log.WithName("registryInspector")
 
 	// Create the auth file
 	authCfgContent := &authCfg{
-		Auths: make(map[string]authData),
+		Auths: s.credentialProviders.provide(ctx, imageReference),
 	}
 
 	for _, secret := range secrets {
@@ -332,7 +610,9 @@ func marshaledImagePullSecrets(imageReference string, secrets [][]byte) ([]byte,
 	return authJSON, nil
 }
 
-func resolveAndOpenImageSource(ctx context.Context, sys *types.SystemContext, imageReference string) (types.ImageSource, error) {
+// resolveAndOpenImageSource opens the image source of the first pull candidate of the given image reference that
+// can be accessed. It also returns the mirror and the registry session the image source was opened with, if any.
+func (s *inspectionState) resolveAndOpenImageSource(ctx context.Context, sys *types.SystemContext, imageReference string) (types.ImageSource, string, *registrySession, error) {
 	log := ctrllog.FromContext(ctx).WithValues("imageReference", imageReference)
 
 	// Ensure the image is a fully-qualified reference.
@@ -342,7 +622,7 @@ func resolveAndOpenImageSource(ctx context.Context, sys *types.SystemContext, im
 	resolved, err := shortnames.Resolve(sys, strings.TrimPrefix(imageReference, "//"))
 	if err != nil {
 		log.Error(err, "Failed to resolve image shortname")
-		return nil, err
+		return nil, "", nil, err
 	}
 
 	if desc := resolved.Description(); desc != "" {
@@ -351,28 +631,111 @@ func resolveAndOpenImageSource(ctx context.Context, sys *types.SystemContext, im
 
 	var pullErrs []error
 	for i, cand := range resolved.PullCandidates {
-		fqName := fmt.Sprintf("//%s", cand.Value.String())
-		log.V(1).Info("Trying candidate", "index", i, "fullName", fqName)
-
-		ref, err := docker.ParseReference(fqName)
+		log.V(1).Info("Trying candidate", "index", i, "fullName", cand.Value.String())
+		src, mirror, session, err := s.openImageSource(ctx, sys, reference.TagNameOnly(cand.Value))
 		if err != nil {
-			log.Error(err, "Failed to parse image reference")
+			log.Error(err, "Failed to create image source")
 			pullErrs = append(pullErrs, err)
 			continue
 		}
+		return src, mirror, session, nil
+	}
+
+	err = resolved.FormatPullErrors(pullErrs)
+	log.Error(err, "All image pull candidates failed")
+	return nil, "", nil, err
+}
 
-		src, err := ref.NewImageSource(ctx, sys)
+// openImageSource opens the image source of the given fully-qualified reference. When the registries configuration
+// is rendered in memory, the pull sources of the reference, i.e., its mirrors and then its source, are tried in
+// order, so that the mirror that answered is known. Otherwise, the containers/image library tries them on its own.
+func (s *inspectionState) openImageSource(ctx context.Context, sys *types.SystemContext, named reference.Named) (types.ImageSource, string, *registrySession, error) {
+	sourcesSys, ok := s.registriesConfig.sourcesSystemContext(sys)
+	if !ok {
+		src, session, err := s.openPullSource(ctx, sys, named)
+		return src, "", session, err
+	}
+	registry, err := sysregistriesv2.FindRegistry(sys, named.Name())
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("loading registries configuration: %w", err)
+	}
+	if registry == nil {
+		src, session, err := s.openPullSource(ctx, sourcesSys, named)
+		return src, "", session, err
+	}
+	pullSources, err := registry.PullSourcesFromReference(named)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	var extras []string
+	for i, pullSource := range pullSources {
+		ctrllog.FromContext(ctx).V(3).Info("Trying to access the pull source", "pullSource", pullSource.Reference.String())
+		src, session, err := s.openPullSource(ctx, sourcesSys, pullSource.Reference)
 		if err != nil {
-			log.Error(err, "Failed to create image source")
-			pullErrs = append(pullErrs, err)
+			if i == len(pullSources)-1 {
+				if len(extras) == 0 {
+					return nil, "", nil, err
+				}
+				// The same format as the containers/image library's, to preserve the classification of the errors
+				return nil, "", nil, fmt.Errorf("(Mirrors also failed: %s): %s: %w", strings.Join(extras, "\n"),
+					pullSource.Reference.String(), err)
+			}
+			extras = append(extras, fmt.Sprintf("[%s: %v]", pullSource.Reference.String(), err))
 			continue
 		}
-		return src, nil
+		mirror := ""
+		if i < len(pullSources)-1 {
+			mirror = pullSource.Endpoint.Location
+		}
+		if pullSource.Reference.String() != named.String() {
+			// The signature policy applies to the reference of the image, not to the one of the pull source
+			src = &pullSourceImageSource{ImageSource: src, named: named}
+		}
+		return src, mirror, session, nil
 	}
+	return nil, "", nil, errors.New("no pull source to access " + named.String())
+}
 
-	err = resolved.FormatPullErrors(pullErrs)
-	log.Error(err, "All image pull candidates failed")
-	return nil, err
+// openPullSource opens the image source of the given physical reference, reusing the registry session of its
+// registry, if possible.
+func (s *inspectionState) openPullSource(ctx context.Context, sys *types.SystemContext, named reference.Named) (types.ImageSource, *registrySession, error) {
+	log := ctrllog.FromContext(ctx).WithValues("fullName", named.String())
+	ref, err := docker.NewReference(named)
+	if err != nil {
+		log.Error(err, "Failed to parse image reference")
+		return nil, nil, err
+	}
+	sys, err = s.registriesConfig.withProxy(sys, reference.Domain(named))
+	if err != nil {
+		return nil, nil, err
+	}
+	sessionSys, session, err := s.registrySessions.systemContextFor(ctx, sys, named)
+	if err != nil {
+		log.V(3).Info("Unable to reuse a registry session", "error", err)
+	}
+	src, err := ref.NewImageSource(ctx, sessionSys)
+	if err != nil {
+		if classifyError(err) == common.ImageInspectionErrorAuthDenied {
+			session.invalidate()
+		}
+		return nil, nil, err
+	}
+	return src, session, nil
+}
+
+// pullSourceImageSource is the image source of a mirror or of a relocated registry. It reports the reference of
+// the image it is the pull source of, as the image sources of the containers/image library do.
+type pullSourceImageSource struct {
+	types.ImageSource
+	named reference.Named
+}
+
+func (s *pullSourceImageSource) Reference() types.ImageReference {
+	ref, err := docker.NewReference(s.named)
+	if err != nil {
+		return s.ImageSource.Reference()
+	}
+	return ref
 }
 
 // writeMemFile creates an in memory file based on memfd_create
@@ -413,9 +776,12 @@ func (i *registryInspector) storeGlobalPullSecret(pullSecret []byte) {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
 	i.globalPullSecret = pullSecret
+	// The bearer tokens may have been negotiated with the previous credentials
+	i.state.registrySessions.reset()
 }
 
-func newRegistryInspector() IRegistryInspector {
-	ri := &registryInspector{}
+func newRegistryInspector(state *inspectionState) IRegistryInspector {
+	metrics.InitCommonMetrics()
+	ri := &registryInspector{state: state}
 	return ri
 }
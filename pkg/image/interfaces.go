@@ -19,6 +19,8 @@ package image
 import (
 	"context"
 
+	"github.com/opencontainers/go-digest"
+
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -26,6 +28,9 @@ type ICache interface {
 	// GetCompatibleArchitecturesSet takes an image reference. a list of secrets and the client to the cluster and
 	// returns a set of architectures that are compatible with the image reference.
 	GetCompatibleArchitecturesSet(ctx context.Context, imageReference string, skipCache bool, secrets [][]byte) (sets.Set[string], error)
+	// GetCompatiblePlatformsSet behaves like GetCompatibleArchitecturesSet, but it returns the set of platforms,
+	// including the CPU variants the image is built for.
+	GetCompatiblePlatformsSet(ctx context.Context, imageReference string, skipCache bool, secrets [][]byte) (sets.Set[Platform], error)
 }
 
 type IRegistryInspector interface {
@@ -34,4 +39,17 @@ type IRegistryInspector interface {
 	// in charge of watching the global pull secret and to store it in the ImageFacade's relevant private field.
 	// Then, the ImageFacade will be responsible for consuming it during the inspection.
 	storeGlobalPullSecret(pullSecret []byte)
+	// inspect behaves like GetCompatibleArchitecturesSet, but it also returns the digest the image reference
+	// resolved to, so that the result can be stored in a digest-keyed cache.
+	inspect(ctx context.Context, imageReference string, secrets [][]byte) (*inspectionResult, error)
+	// headDigest returns the digest the image reference resolves to, without downloading the manifest.
+	headDigest(ctx context.Context, imageReference string, secrets [][]byte) (digest.Digest, error)
+}
+
+// IArchitectureStore is a persistent, digest-keyed store of the inspection results.
+type IArchitectureStore interface {
+	// get returns the inspection result recorded for the given digest, if any.
+	get(ctx context.Context, digest string) (*inspectionResult, bool)
+	// store records the result of the inspection of the given image reference.
+	store(ctx context.Context, imageReference string, result *inspectionResult)
 }
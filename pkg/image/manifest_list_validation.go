@@ -0,0 +1,173 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync/atomic"
+
+	"github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/transports"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+)
+
+const (
+	// DiscrepancyReasonUnresolvable is the reason of the entries whose manifest or config is missing from the
+	// registry.
+	DiscrepancyReasonUnresolvable = "Unresolvable"
+	// DiscrepancyReasonPlatformMismatch is the reason of the entries whose config reports a different operating
+	// system, architecture or variant than the manifest list.
+	DiscrepancyReasonPlatformMismatch = "PlatformMismatch"
+)
+
+// errAllManifestListEntriesDropped is returned when the deep validation drops all the entries of a manifest list.
+var errAllManifestListEntriesDropped = errors.New("the deep validation dropped all the entries of the manifest list")
+
+// manifestListValidation tells whether the manifest and the config of each entry of the manifest lists are verified
+// during the inspections.
+type manifestListValidation struct {
+	deep atomic.Bool
+}
+
+// configure enables or disables the deep validation of the manifest lists. It returns true if the configuration
+// changed.
+func (v *manifestListValidation) configure(deep bool) bool {
+	return v.deep.Swap(deep) != deep
+}
+
+// enabled returns true if the deep validation of the manifest lists is enabled.
+func (v *manifestListValidation) enabled() bool {
+	return v.deep.Load()
+}
+
+// ManifestListDiscrepancy describes an entry of a manifest list dropped by the deep validation.
+type ManifestListDiscrepancy struct {
+	// ImageReference is the reference of the manifest list, e.g., quay.io/openshift/origin-cli:latest.
+	ImageReference string
+	// Digest is the digest of the offending entry.
+	Digest digest.Digest
+	// Platform is the platform of the entry, as declared in the manifest list.
+	Platform Platform
+	// Reason is DiscrepancyReasonUnresolvable or DiscrepancyReasonPlatformMismatch.
+	Reason string
+	// Message details the discrepancy.
+	Message string
+}
+
+type manifestListDiscrepancyHandlerKey struct{}
+
+// WithManifestListDiscrepancyHandler returns a copy of ctx in which the lookups of the image platforms call handler
+// for each discrepancy found by the deep validation of the manifest lists, including when the result is cached.
+// The handler may be called concurrently.
+func WithManifestListDiscrepancyHandler(ctx context.Context, handler func(ManifestListDiscrepancy)) context.Context {
+	return context.WithValue(ctx, manifestListDiscrepancyHandlerKey{}, handler)
+}
+
+// logManifestListDiscrepancy logs and counts the given discrepancy, once per inspection.
+func logManifestListDiscrepancy(ctx context.Context, discrepancy ManifestListDiscrepancy) {
+	ctrllog.FromContext(ctx).Info("Dropping an entry of the manifest list", "digest", discrepancy.Digest,
+		"platform", discrepancy.Platform.String(), "reason", discrepancy.Reason, "message", discrepancy.Message)
+	metrics.ManifestListDiscrepancies.WithLabelValues(discrepancy.Reason, discrepancy.Digest.String()).Inc()
+}
+
+// reportManifestListDiscrepancies passes the given discrepancies, found in the manifest list of the given image, to
+// the handler of ctx, if any. The image reference of the lookup replaces the one of the inspection, as the cached
+// results are shared by the references resolving to the same digest.
+func reportManifestListDiscrepancies(ctx context.Context, imageReference string,
+	discrepancies []ManifestListDiscrepancy) {
+	handler, ok := ctx.Value(manifestListDiscrepancyHandlerKey{}).(func(ManifestListDiscrepancy))
+	if !ok {
+		return
+	}
+	for _, discrepancy := range discrepancies {
+		discrepancy.ImageReference = strings.TrimPrefix(imageReference, "//")
+		handler(discrepancy)
+	}
+}
+
+// validateManifestListEntry fetches the manifest and the config of the entry of the given digest of the manifest
+// list of src, and verifies that the config reports the platform declared in the manifest list. The variant is only
+// compared when the config reports one, as most of the images only declare it in the manifest list.
+// It returns nil if the entry is valid, and a discrepancy if the entry is missing from the registry or reports a
+// different platform. The other errors, e.g., the authentication errors, the rate limits or the cancellation of ctx,
+// tell nothing about the entry and are returned: the inspection fails rather than caching an incomplete result.
+func (s *inspectionState) validateManifestListEntry(ctx context.Context, sys *types.SystemContext, src types.ImageSource,
+	entryDigest digest.Digest, platform Platform) (*ManifestListDiscrepancy, error) {
+	discrepancy := &ManifestListDiscrepancy{
+		ImageReference: transports.ImageName(src.Reference()),
+		Digest:         entryDigest,
+		Platform:       platform,
+	}
+	unresolvable := func(what string, err error) (*ManifestListDiscrepancy, error) {
+		if !isMissing(err) {
+			return nil, fmt.Errorf("unable to fetch the %s of the entry %s of the manifest list: %w", what,
+				entryDigest, err)
+		}
+		discrepancy.Reason = DiscrepancyReasonUnresolvable
+		discrepancy.Message = fmt.Sprintf("unable to fetch the %s: %v", what, err)
+		return discrepancy, nil
+	}
+	// The digest of the manifest of an unparsed instance is verified when it is fetched.
+	parsedImage, err := image.FromUnparsedImage(ctx, sys, image.UnparsedInstance(src, &entryDigest))
+	if err != nil {
+		return unresolvable("manifest", err)
+	}
+	// The runtime-specific images have no image config: their platform is the one of their media types.
+	rawManifest, _, err := parsedImage.Manifest(ctx)
+	if err != nil {
+		return unresolvable("manifest", err)
+	}
+	if runtimePlatform, ok := s.runtimePlatforms.platformOf(rawManifest); ok {
+		if runtimePlatform.OS != platform.OS || runtimePlatform.Architecture != platform.Architecture {
+			discrepancy.Reason = DiscrepancyReasonPlatformMismatch
+			discrepancy.Message = fmt.Sprintf("the media types identify the %s platform", runtimePlatform)
+			return discrepancy, nil
+		}
+		return nil, nil
+	}
+	config, err := parsedImage.OCIConfig(ctx)
+	if err != nil {
+		return unresolvable("config", err)
+	}
+	actual := s.architectures.normalizePlatform(Platform{
+		OS:           osOrDefault(config.OS),
+		Architecture: config.Architecture,
+		Variant:      config.Variant,
+	})
+	if actual.OS != platform.OS || actual.Architecture != platform.Architecture ||
+		(actual.Variant != "" && actual.Variant != platform.Variant) {
+		discrepancy.Reason = DiscrepancyReasonPlatformMismatch
+		discrepancy.Message = fmt.Sprintf("the config reports the %s platform", actual)
+		return discrepancy, nil
+	}
+	return nil, nil
+}
+
+// isMissing returns true if the given error reports a manifest or a blob missing from the registry, or from the
+// offline source.
+func isMissing(err error) bool {
+	return classifyError(err) == common.ImageInspectionErrorNotFound || errors.Is(err, fs.ErrNotExist)
+}
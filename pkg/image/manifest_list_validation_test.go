@@ -0,0 +1,134 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+func Test_inspectSource_deepManifestListValidation(t *testing.T) {
+	metrics.InitCommonMetrics()
+	usePolicyConf(t, `{"default": [{"type": "insecureAcceptAnything"}]}`)
+	root := t.TempDir()
+
+	layout := newOCILayoutBuilder()
+	entry := func(descriptor ociv1.Descriptor, architecture string) ociv1.Descriptor {
+		descriptor.Platform = &ociv1.Platform{OS: "linux", Architecture: architecture}
+		return descriptor
+	}
+	dangling := ociv1.Descriptor{MediaType: ociv1.MediaTypeImageManifest, Digest: digest.FromString("dangling"), Size: 8}
+	lying := layout.addImage(t, utils.ArchitecturePpc64le, nil)
+	index := ociv1.Index{MediaType: ociv1.MediaTypeImageIndex, Manifests: []ociv1.Descriptor{
+		entry(layout.addImage(t, utils.ArchitectureAmd64, nil), utils.ArchitectureAmd64),
+		entry(dangling, utils.ArchitectureArm64),
+		entry(lying, utils.ArchitectureS390x),
+	}}
+	index.SchemaVersion = 2
+	layout.tag(layout.addBlob(t, ociv1.MediaTypeImageIndex, index), "latest")
+	broken := ociv1.Index{MediaType: ociv1.MediaTypeImageIndex, Manifests: []ociv1.Descriptor{
+		entry(dangling, utils.ArchitectureArm64),
+	}}
+	broken.SchemaVersion = 2
+	layout.tag(layout.addBlob(t, ociv1.MediaTypeImageIndex, broken), "broken")
+	// The manifest of the corrupted entry does not match its digest: fetching it fails, but it is not missing.
+	corrupted := ociv1.Descriptor{MediaType: ociv1.MediaTypeImageManifest, Digest: digest.FromString("corrupted"), Size: 8}
+	layout.files[filepath.Join(ociLayoutBlobsDir, corrupted.Digest.Algorithm().String(),
+		corrupted.Digest.Encoded())] = []byte("tampered")
+	corruptedIndex := ociv1.Index{MediaType: ociv1.MediaTypeImageIndex, Manifests: []ociv1.Descriptor{
+		entry(layout.addImage(t, utils.ArchitectureAmd64, nil), utils.ArchitectureAmd64),
+		entry(corrupted, utils.ArchitectureArm64),
+	}}
+	corruptedIndex.SchemaVersion = 2
+	layout.tag(layout.addBlob(t, ociv1.MediaTypeImageIndex, corruptedIndex), "corrupted")
+	layout.writeDir(t, filepath.Join(root, "origin"))
+
+	s := newInspectionState()
+	s.offlineSources.configure(root, &v1beta1.OfflineImageSourcesConfig{Sources: []v1beta1.OfflineImageSource{
+		{Prefix: "quay.io/openshift", Mode: v1beta1.OfflineImageSourceModeOfflineOnly},
+	}})
+
+	var mutex sync.Mutex
+	var discrepancies []ManifestListDiscrepancy
+	ctx := WithManifestListDiscrepancyHandler(context.Background(), func(d ManifestListDiscrepancy) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		discrepancies = append(discrepancies, d)
+	})
+
+	result, _, err := s.inspectOffline(ctx, "//quay.io/openshift/origin:latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := PlatformsOf(sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64,
+		utils.ArchitectureS390x)); !result.platforms.Equal(want) {
+		t.Errorf("expected the shallow validation to trust the index, got %v", result.platforms)
+	}
+	if len(result.discrepancies) != 0 {
+		t.Errorf("expected no discrepancy in the shallow validation, got %v", result.discrepancies)
+	}
+
+	s.manifestListValidation.configure(true)
+	result, _, err = s.inspectOffline(ctx, "//quay.io/openshift/origin:latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := PlatformsOf(sets.New[string](utils.ArchitectureAmd64)); !result.platforms.Equal(want) {
+		t.Errorf("expected the deep validation to drop the dangling and lying entries, got %v", result.platforms)
+	}
+	want := map[digest.Digest]string{
+		dangling.Digest: DiscrepancyReasonUnresolvable,
+		lying.Digest:    DiscrepancyReasonPlatformMismatch,
+	}
+	if len(result.discrepancies) != len(want) {
+		t.Fatalf("expected %d discrepancies, got %v", len(want), result.discrepancies)
+	}
+	for _, d := range result.discrepancies {
+		if d.Reason != want[d.Digest] || d.Message == "" || d.ImageReference == "" {
+			t.Errorf("unexpected discrepancy %+v", d)
+		}
+	}
+	// The discrepancies of the successful inspections are reported by the lookups, including the cached ones.
+	if len(discrepancies) != 0 {
+		t.Errorf("expected the inspection not to report the discrepancies of its result, got %v", discrepancies)
+	}
+
+	_, _, err = s.inspectOffline(ctx, "//quay.io/openshift/origin:broken")
+	if !errors.Is(err, errAllManifestListEntriesDropped) || ErrorClass(err) != common.ImageInspectionErrorMalformedManifest {
+		t.Errorf("expected the inspection to fail when all the entries are dropped, got %v", err)
+	}
+	if len(discrepancies) != 1 {
+		t.Errorf("expected one discrepancy, got %v", discrepancies)
+	}
+
+	discrepancies = nil
+	if _, _, err = s.inspectOffline(ctx, "//quay.io/openshift/origin:corrupted"); err == nil {
+		t.Errorf("expected the inspection to fail when an entry cannot be fetched")
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("expected no discrepancy when an entry cannot be fetched, got %v", discrepancies)
+	}
+}
+
+func Test_manifestListValidation_configure(t *testing.T) {
+	v := &manifestListValidation{}
+	if v.configure(false) || v.enabled() {
+		t.Error("expected the shallow validation to be the default")
+	}
+	if !v.configure(true) || !v.enabled() {
+		t.Error("expected enabling the deep validation to change the configuration")
+	}
+	if v.configure(true) {
+		t.Error("expected the configuration not to change")
+	}
+}
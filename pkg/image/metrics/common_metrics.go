@@ -13,8 +13,33 @@ var onceCommon sync.Once
 
 var (
 	InspectionGauge             prometheus.Gauge
+	TagCacheGauge               prometheus.Gauge
+	TagRevalidations            prometheus.Counter
+	CoalescedInspections        prometheus.Counter
 	TimeToInspectImageGivenHit  prometheus.Histogram
 	TimeToInspectImageGivenMiss prometheus.Histogram
+
+	ImageArchitectureStoreHits        prometheus.Counter
+	ImageArchitectureStoreMisses      prometheus.Counter
+	ImageArchitectureStoreWriteErrors prometheus.Counter
+
+	RegistryCircuitState      *prometheus.GaugeVec
+	RegistryCircuitRejections *prometheus.CounterVec
+
+	RegistryRequestDuration   *prometheus.HistogramVec
+	RegistryTokenNegotiations prometheus.Counter
+
+	MirrorInspections *prometheus.CounterVec
+
+	CredentialProviderExecutions *prometheus.CounterVec
+
+	ImageStreamResolutions *prometheus.CounterVec
+
+	OfflineInspections *prometheus.CounterVec
+
+	ManifestListDiscrepancies *prometheus.CounterVec
+
+	BinaryVerifications *prometheus.CounterVec
 )
 
 func InitCommonMetrics() {
@@ -22,9 +47,25 @@ func InitCommonMetrics() {
 		InspectionGauge = prometheus.NewGauge(
 			prometheus.GaugeOpts{
 				Name: "mto_inspection_cache_size",
-				Help: "Current size of the MTO inspection cache",
+				Help: "Current size of the digest-to-architectures level of the MTO inspection cache",
+			},
+		)
+		TagCacheGauge = prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "mto_inspection_tag_cache_size",
+				Help: "Current size of the tag-to-digest level of the MTO inspection cache",
 			},
 		)
+		TagRevalidations = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_tag_revalidations_total",
+				Help: "The counter of the manifest HEAD requests issued to revalidate the tag-to-digest cache entries",
+			})
+		CoalescedInspections = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_coalesced_waiters_total",
+				Help: "The counter of the image lookups that waited for and shared the result of an identical in-flight lookup",
+			})
 		TimeToInspectImageGivenHit = prometheus.NewHistogram(
 			prometheus.HistogramOpts{
 				Name:    "mto_cache_hit_processing_duration_seconds",
@@ -38,6 +79,84 @@ func InitCommonMetrics() {
 				Buckets: utils.Buckets(),
 			})
 
-		metrics2.Registry.MustRegister(InspectionGauge)
+		ImageArchitectureStoreHits = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "mto_image_architecture_store_hits_total",
+				Help: "The counter of the image digests found in the ImageArchitecture store",
+			})
+		ImageArchitectureStoreMisses = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "mto_image_architecture_store_misses_total",
+				Help: "The counter of the image digests not found in the ImageArchitecture store",
+			})
+		ImageArchitectureStoreWriteErrors = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "mto_image_architecture_store_write_errors_total",
+				Help: "The counter of the failures to persist an inspection result in the ImageArchitecture store",
+			})
+
+		RegistryCircuitState = prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "mto_inspection_registry_circuit_state",
+				Help: "The state of the circuit breaker of the registries (0: closed, 1: half-open, 2: open)",
+			}, []string{"registry"})
+		RegistryCircuitRejections = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_registry_circuit_rejections_total",
+				Help: "The counter of the registry requests failed fast because the circuit breaker of the registry was open",
+			}, []string{"registry"})
+
+		RegistryRequestDuration = prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "mto_inspection_registry_request_duration_seconds",
+				Help:    "Duration of the registry requests of the MTO inspection, by request type and by whether the bearer token and the connections were reused (warm) or not (cold)",
+				Buckets: utils.Buckets(),
+			}, []string{"request", "session"})
+		RegistryTokenNegotiations = prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_registry_token_negotiations_total",
+				Help: "The counter of the bearer token negotiations with the registries",
+			})
+
+		MirrorInspections = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_mirror_inspections_total",
+				Help: "The counter of the image inspections answered by a mirror of the registries configuration",
+			}, []string{"mirror"})
+
+		CredentialProviderExecutions = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_credential_provider_executions_total",
+				Help: "The counter of the executions of the kubelet credential providers, by provider and result",
+			}, []string{"provider", "result"})
+
+		ImageStreamResolutions = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_image_stream_resolutions_total",
+				Help: "The counter of the image references resolved through the OpenShift image API, by result",
+			}, []string{"result"})
+
+		OfflineInspections = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_offline_inspections_total",
+				Help: "The counter of the image inspections answered by an offline source, by prefix",
+			}, []string{"prefix"})
+
+		ManifestListDiscrepancies = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_manifest_list_discrepancies_total",
+				Help: "The counter of the entries of the manifest lists dropped by the deep validation, by reason and digest",
+			}, []string{"reason", "digest"})
+
+		BinaryVerifications = prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "mto_inspection_binary_verifications_total",
+				Help: "The counter of the platforms of the images whose ELF binaries were verified, by result",
+			}, []string{"result"})
+
+		metrics2.Registry.MustRegister(InspectionGauge, TagCacheGauge, TagRevalidations, CoalescedInspections, ImageArchitectureStoreHits, ImageArchitectureStoreMisses,
+			ImageArchitectureStoreWriteErrors, RegistryCircuitState, RegistryCircuitRejections, RegistryRequestDuration,
+			RegistryTokenNegotiations, MirrorInspections, CredentialProviderExecutions,
+			ImageStreamResolutions, OfflineInspections, ManifestListDiscrepancies, BinaryVerifications)
 	})
 }
@@ -0,0 +1,137 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/containers/image/v5/docker/reference"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// NodeImagesIndex is the name of the field index of the nodes by the normalized names of the images in their
+// .status.images.
+const NodeImagesIndex = "status.images.names"
+
+// nodeImages infers the platforms of the images from the nodes that already hold them, e.g., the images pre-loaded
+// on the nodes and used with the IfNotPresent or Never pull policies that no registry reachable from the controller
+// serves. The reader should be backed by a cache of the nodes indexed with IndexNodeImages.
+// The inventory is disabled until a reader is set.
+type nodeImages struct {
+	mutex  sync.RWMutex
+	reader client.Reader
+}
+
+// enable sets the reader of the nodes.
+func (n *nodeImages) enable(reader client.Reader) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.reader = reader
+}
+
+// platforms returns the platforms of the nodes holding the given image, according to their kubernetes.io/os and
+// kubernetes.io/arch labels. The nodes with no kubernetes.io/arch label are ignored.
+// It returns an empty set if no node holds the image or the inventory is disabled.
+func (n *nodeImages) platforms(ctx context.Context, imageReference string) (sets.Set[Platform], error) {
+	platforms := sets.New[Platform]()
+	n.mutex.RLock()
+	reader := n.reader
+	n.mutex.RUnlock()
+	name := normalizedNodeImageName(strings.TrimPrefix(imageReference, "//"))
+	if reader == nil || name == "" {
+		return platforms, nil
+	}
+	nodes := &corev1.NodeList{}
+	if err := reader.List(ctx, nodes, client.MatchingFields{NodeImagesIndex: name}); err != nil {
+		return nil, err
+	}
+	for _, node := range nodes.Items {
+		architecture, ok := node.Labels[utils.ArchLabel]
+		if !ok {
+			continue
+		}
+		os := node.Labels[utils.OSLabel]
+		if os == "" {
+			os = utils.OSLinux
+		}
+		platforms.Insert(Platform{OS: os, Architecture: architecture})
+	}
+	return platforms, nil
+}
+
+// normalizedNodeImageName returns the fully qualified name of the given image, e.g., docker.io/library/busybox:latest
+// for busybox, as reported in the .status.images of the nodes. The references pinned by digest are reduced to their
+// name and digest, which are reported regardless of the tag. It returns an empty string if the name is invalid.
+func normalizedNodeImageName(imageName string) string {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return ""
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		return named.Name() + "@" + digested.Digest().String()
+	}
+	return reference.TagNameOnly(named).String()
+}
+
+// IndexNodeImages indexes the nodes by the normalized names of the images in their .status.images, so that the nodes
+// holding an image can be listed through the NodeImagesIndex field.
+func IndexNodeImages(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &corev1.Node{}, NodeImagesIndex, nodeImagesIndexValues)
+}
+
+// nodeImagesIndexValues returns the NodeImagesIndex values of the given node.
+func nodeImagesIndexValues(obj client.Object) []string {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return nil
+	}
+	names := sets.New[string]()
+	for _, nodeImage := range node.Status.Images {
+		for _, imageName := range nodeImage.Names {
+			if name := normalizedNodeImageName(imageName); name != "" {
+				names.Insert(name)
+			}
+		}
+	}
+	return sets.List(names)
+}
+
+// StripNodeForImageInventory is a cache transform function dropping all the fields of the nodes but the ones needed
+// by the image inventory, i.e., their name, labels and .status.images.
+func StripNodeForImageInventory(obj interface{}) (interface{}, error) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return obj, nil
+	}
+	return &corev1.Node{
+		TypeMeta: node.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            node.Name,
+			UID:             node.UID,
+			ResourceVersion: node.ResourceVersion,
+			Labels:          node.Labels,
+		},
+		Status: corev1.NodeStatus{Images: node.Status.Images},
+	}, nil
+}
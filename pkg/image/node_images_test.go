@@ -0,0 +1,107 @@
+package image
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// fakeNodeReader lists the given nodes matching the NodeImagesIndex field selector.
+type fakeNodeReader struct {
+	nodes []corev1.Node
+}
+
+func (r *fakeNodeReader) Get(_ context.Context, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+	return nil
+}
+
+func (r *fakeNodeReader) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOptions := &client.ListOptions{}
+	listOptions.ApplyOptions(opts)
+	name, _ := listOptions.FieldSelector.RequiresExactMatch(NodeImagesIndex)
+	nodeList := list.(*corev1.NodeList)
+	for i := range r.nodes {
+		if slices.Contains(nodeImagesIndexValues(&r.nodes[i]), name) {
+			nodeList.Items = append(nodeList.Items, r.nodes[i])
+		}
+	}
+	return nil
+}
+
+func nodeWithImages(labels map[string]string, names ...string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Status:     corev1.NodeStatus{Images: []corev1.ContainerImage{{Names: names}}},
+	}
+}
+
+func Test_normalizedNodeImageName(t *testing.T) {
+	tests := []struct {
+		imageName string
+		want      string
+	}{
+		{imageName: "busybox", want: "docker.io/library/busybox:latest"},
+		{imageName: "quay.io/openshift/origin:v4", want: "quay.io/openshift/origin:v4"},
+		{imageName: "quay.io/openshift/origin:v4@" + testDigest, want: "quay.io/openshift/origin@" + testDigest},
+		{imageName: "Invalid:Name", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.imageName, func(t *testing.T) {
+			if got := normalizedNodeImageName(tt.imageName); got != tt.want {
+				t.Errorf("normalizedNodeImageName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nodeImages_platforms(t *testing.T) {
+	n := &nodeImages{}
+	platforms, err := n.platforms(context.Background(), "//busybox")
+	if err != nil || platforms.Len() != 0 {
+		t.Fatalf("expected no platform with the inventory disabled, got %v, %v", platforms, err)
+	}
+	n.enable(&fakeNodeReader{nodes: []corev1.Node{
+		nodeWithImages(map[string]string{utils.ArchLabel: utils.ArchitectureArm64},
+			"docker.io/library/busybox:latest", "docker.io/library/busybox@"+testDigest),
+		nodeWithImages(map[string]string{utils.ArchLabel: utils.ArchitectureS390x, utils.OSLabel: utils.OSLinux},
+			"docker.io/library/busybox:latest"),
+		nodeWithImages(map[string]string{utils.ArchLabel: utils.ArchitectureAmd64},
+			"quay.io/openshift/origin:v4"),
+		nodeWithImages(nil, "docker.io/library/busybox:latest"),
+	}})
+	tests := []struct {
+		imageReference string
+		want           sets.Set[Platform]
+	}{
+		{
+			imageReference: "//busybox",
+			want:           PlatformsOf(sets.New[string](utils.ArchitectureArm64, utils.ArchitectureS390x)),
+		},
+		{
+			imageReference: "//docker.io/library/busybox:1.36@" + testDigest,
+			want:           PlatformsOf(sets.New[string](utils.ArchitectureArm64)),
+		},
+		{
+			imageReference: "//quay.io/openshift/origin:v5",
+			want:           sets.New[Platform](),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.imageReference, func(t *testing.T) {
+			got, err := n.platforms(context.Background(), tt.imageReference)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("platforms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
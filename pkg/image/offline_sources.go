@@ -0,0 +1,581 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/types"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+)
+
+const (
+	ociLayoutIndexFile  = "index.json"
+	ociLayoutBlobsDir   = "blobs"
+	ociArchiveExtension = ".tar"
+	// maxOfflineBlobSize bounds the size of the blobs read from the offline sources: only the manifests and the
+	// configs of the images are read, never their layers.
+	maxOfflineBlobSize = 4 << 20
+	// maxCachedOfflineArchives bounds the number of oci-archive files whose manifests and configs are cached.
+	maxCachedOfflineArchives = 64
+
+	ociLayoutTransportName  = "oci"
+	ociArchiveTransportName = "oci-archive"
+)
+
+// errOfflineImageNotFound is returned when an image is missing from the offline source matching its repository.
+var errOfflineImageNotFound = errors.New("the image is not in the offline source")
+
+// offlineSource is a directory of OCI image layouts and oci-archive files serving the images of a repository prefix.
+type offlineSource struct {
+	prefix string
+	path   string
+	// only is true if the registries must not be accessed for the images missing from the source.
+	only bool
+}
+
+// offlineSources looks up the images in the offline sources before, or instead of, the registries.
+type offlineSources struct {
+	mutex sync.RWMutex
+	// sources are sorted by descending length of their prefixes, for the longest matching prefix to apply.
+	sources []offlineSource
+	// archives caches the manifests and configs of the least recently used oci-archive files, by path.
+	archives *expirable.LRU[string, *ociArchive]
+	// onChange is called with the prefixes of the sources whose configuration changed.
+	onChange func(prefixes []string)
+}
+
+func newOfflineSources() *offlineSources {
+	return &offlineSources{archives: newOCIArchiveCache()}
+}
+
+// newOCIArchiveCache returns a cache of maxCachedOfflineArchives oci-archive files, whose entries never expire: they
+// are reloaded when their file changes.
+func newOCIArchiveCache() *expirable.LRU[string, *ociArchive] {
+	return expirable.NewLRU[string, *ociArchive](maxCachedOfflineArchives, nil, 0)
+}
+
+// configure replaces the offline sources with the ones of the given configuration, whose paths are relative to root.
+// It returns the prefixes of the sources that changed.
+func (s *offlineSources) configure(root string, config *v1beta1.OfflineImageSourcesConfig) []string {
+	var sources []offlineSource
+	if config != nil {
+		for _, source := range config.Sources {
+			sources = append(sources, offlineSource{
+				prefix: source.Prefix,
+				path:   filepath.Join(root, source.Path),
+				only:   source.Mode == v1beta1.OfflineImageSourceModeOfflineOnly,
+			})
+		}
+	}
+	sort.SliceStable(sources, func(i, j int) bool { return len(sources[i].prefix) > len(sources[j].prefix) })
+	s.mutex.Lock()
+	changed := changedOfflineSourcePrefixes(s.sources, sources)
+	if len(changed) > 0 {
+		s.sources = sources
+		s.archives = newOCIArchiveCache()
+	}
+	onChange := s.onChange
+	s.mutex.Unlock()
+	if len(changed) > 0 && onChange != nil {
+		onChange(changed)
+	}
+	return changed
+}
+
+// inspectOffline inspects the image in the offline source matching its repository. It returns false if no source
+// matches, or if the image is missing from a source that lets the registries be accessed.
+func (s *inspectionState) inspectOffline(ctx context.Context, imageReference string) (*inspectionResult, bool, error) {
+	src, ok, err := s.offlineSources.open(ctx, imageReference)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+	// The offline sources are read-only and do not hold signatures: only the policy of their transport applies.
+	sys := &types.SystemContext{SignaturePolicyPath: PolicyConfPath()}
+	result, err := s.inspectSource(ctx, sys, src, imageReference)
+	if err != nil {
+		return nil, true, err
+	}
+	metrics.OfflineInspections.WithLabelValues(src.prefix).Inc()
+	result.source = v1beta1.ImageArchitectureSourceOffline
+	return result, true, nil
+}
+
+// headDigest returns the digest the image reference resolves to in the offline source matching its repository, with
+// the same semantics as inspectOffline.
+func (s *offlineSources) headDigest(ctx context.Context, imageReference string) (digest.Digest, bool, error) {
+	src, ok, err := s.open(ctx, imageReference)
+	if !ok || err != nil {
+		return "", ok, err
+	}
+	return src.descriptor.Digest, true, nil
+}
+
+// open opens the image in the offline source matching its repository, with the same semantics as inspectOffline.
+func (s *offlineSources) open(ctx context.Context, imageReference string) (*offlineImageSource, bool, error) {
+	s.mutex.RLock()
+	sources := s.sources
+	s.mutex.RUnlock()
+	if len(sources) == 0 {
+		return nil, false, nil
+	}
+	parsedReference, err := parseImageReference(imageReference)
+	if err != nil {
+		return nil, false, nil
+	}
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(parsedReference, "//"))
+	if err != nil {
+		return nil, false, nil
+	}
+	i := slices.IndexFunc(sources, func(source offlineSource) bool {
+		return named.Name() == source.prefix || strings.HasPrefix(named.Name(), source.prefix+"/")
+	})
+	if i < 0 {
+		return nil, false, nil
+	}
+	source := sources[i]
+	src, err := s.openImage(source, named)
+	if err != nil {
+		if source.only {
+			return nil, true, err
+		}
+		ctrllog.FromContext(ctx).V(3).Info("Unable to read the image from the offline source, inspecting it in the registry",
+			"imageReference", imageReference, "prefix", source.prefix, "error", err.Error())
+		return nil, false, nil
+	}
+	return src, true, nil
+}
+
+// openImage looks up the image <prefix>/<repository> in the OCI image layout directory <path>/<repository>, or in
+// the oci-archive file <path>/<repository>.tar. The images whose repository is the prefix are in <path>.
+func (s *offlineSources) openImage(source offlineSource, named reference.Named) (*offlineImageSource, error) {
+	repository := strings.TrimPrefix(strings.TrimPrefix(named.Name(), source.prefix), "/")
+	candidates := []string{source.path}
+	if repository != "" {
+		// The components of a repository cannot start with a dot: the candidates stay in the source directory.
+		candidates = []string{filepath.Join(source.path, repository), filepath.Join(source.path, repository) + ociArchiveExtension}
+	}
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var layout ociLayout
+		transport := ociLayoutTransportName
+		if info.IsDir() {
+			layout = ociLayoutDir(candidate)
+		} else {
+			transport = ociArchiveTransportName
+			if layout, err = s.archive(candidate, info); err != nil {
+				return nil, err
+			}
+		}
+		descriptor, err := findManifest(layout, named)
+		if err != nil {
+			return nil, err
+		}
+		return &offlineImageSource{
+			ref: offlineImageReference{
+				transport: offlineTransport(transport),
+				path:      candidate,
+				image:     descriptor.Digest.String(),
+			},
+			prefix:     source.prefix,
+			layout:     layout,
+			descriptor: descriptor,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", errOfflineImageNotFound, named.String())
+}
+
+// archive returns the manifests and configs of the oci-archive at the given path, loading them again if the file
+// changed.
+func (s *offlineSources) archive(path string, info os.FileInfo) (*ociArchive, error) {
+	s.mutex.RLock()
+	archives := s.archives
+	s.mutex.RUnlock()
+	archive, ok := archives.Get(path)
+	if ok && archive.modTime.Equal(info.ModTime()) && archive.size == info.Size() {
+		return archive, nil
+	}
+	archive, err := loadOCIArchive(path, info)
+	if err != nil {
+		return nil, err
+	}
+	archives.Add(path, archive)
+	return archive, nil
+}
+
+// changedOfflineSourcePrefixes returns the prefixes of the sources added, removed or modified.
+func changedOfflineSourcePrefixes(previous, current []offlineSource) []string {
+	changed := make(map[string]struct{})
+	byPrefix := make(map[string]offlineSource, len(previous))
+	for _, source := range previous {
+		byPrefix[source.prefix] = source
+	}
+	for _, source := range current {
+		if previousSource, ok := byPrefix[source.prefix]; !ok || previousSource != source {
+			changed[source.prefix] = struct{}{}
+		}
+		delete(byPrefix, source.prefix)
+	}
+	for prefix := range byPrefix {
+		changed[prefix] = struct{}{}
+	}
+	prefixes := make([]string, 0, len(changed))
+	for prefix := range changed {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// ociLayout gives access to the index and the blobs of an OCI image layout.
+type ociLayout interface {
+	index() (*ociv1.Index, error)
+	blob(d digest.Digest) ([]byte, error)
+}
+
+// ociLayoutDir is an OCI image layout directory.
+type ociLayoutDir string
+
+func (l ociLayoutDir) index() (*ociv1.Index, error) {
+	data, err := os.ReadFile(filepath.Join(string(l), ociLayoutIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	return parseOCIIndex(data)
+}
+
+func (l ociLayoutDir) blob(d digest.Digest) ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(string(l), ociLayoutBlobsDir, d.Algorithm().String(), d.Encoded()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(io.LimitReader(f, maxOfflineBlobSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxOfflineBlobSize {
+		return nil, fmt.Errorf("the blob %s exceeds %d bytes", d, maxOfflineBlobSize)
+	}
+	if d.Algorithm().FromBytes(data) != d {
+		return nil, fmt.Errorf("the content of the blob %s does not match its digest", d)
+	}
+	return data, nil
+}
+
+// ociArchive holds the index, the manifests and the configs of an oci-archive file.
+type ociArchive struct {
+	modTime time.Time
+	size    int64
+	idx     *ociv1.Index
+	blobs   map[digest.Digest][]byte
+}
+
+func (a *ociArchive) index() (*ociv1.Index, error) {
+	return a.idx, nil
+}
+
+func (a *ociArchive) blob(d digest.Digest) ([]byte, error) {
+	data, ok := a.blobs[d]
+	if !ok {
+		return nil, fmt.Errorf("%w: the blob %s is not in the archive", errOfflineImageNotFound, d)
+	}
+	return data, nil
+}
+
+// loadOCIArchive reads the index, the manifests and the configs of the oci-archive at the given path. As the index can
+// be anywhere in the archive, the JSON blobs smaller than maxOfflineBlobSize are read first, and the ones that are not
+// reachable from the index as a manifest list, a manifest or a config are dropped: the layers are never kept.
+func loadOCIArchive(archivePath string, info os.FileInfo) (*ociArchive, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	archive := &ociArchive{modTime: info.ModTime(), size: info.Size(), blobs: map[digest.Digest][]byte{}}
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read the oci-archive %s: %w", archivePath, err)
+		}
+		if header.Typeflag != tar.TypeReg || header.Size > maxOfflineBlobSize {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		var d digest.Digest
+		if name != ociLayoutIndexFile {
+			algorithm, encoded, ok := strings.Cut(strings.TrimPrefix(name, ociLayoutBlobsDir+"/"), "/")
+			if !ok || !strings.HasPrefix(name, ociLayoutBlobsDir+"/") {
+				continue
+			}
+			if d = digest.NewDigestFromEncoded(digest.Algorithm(algorithm), encoded); d.Validate() != nil {
+				continue
+			}
+		}
+		// The manifests, the manifest lists and the configs are JSON documents, unlike the layers.
+		data, err := io.ReadAll(io.LimitReader(reader, 512))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s in the oci-archive %s: %w", name, archivePath, err)
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+			continue
+		}
+		rest, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s in the oci-archive %s: %w", name, archivePath, err)
+		}
+		data = append(data, rest...)
+		if d == "" {
+			if archive.idx, err = parseOCIIndex(data); err != nil {
+				return nil, fmt.Errorf("unable to parse the index of the oci-archive %s: %w", archivePath, err)
+			}
+		} else if d.Algorithm().FromBytes(data) == d {
+			archive.blobs[d] = data
+		}
+	}
+	if archive.idx == nil {
+		return nil, fmt.Errorf("the oci-archive %s has no %s", archivePath, ociLayoutIndexFile)
+	}
+	archive.blobs = metadataBlobs(archive.idx, archive.blobs)
+	return archive, nil
+}
+
+// metadataBlobs returns the given blobs that are reachable from the given index as a manifest list, a manifest or a
+// config, i.e., the blobs findManifest and the inspection walk.
+func metadataBlobs(index *ociv1.Index, blobs map[digest.Digest][]byte) map[digest.Digest][]byte {
+	kept := map[digest.Digest][]byte{}
+	pending := slices.Clone(index.Manifests)
+	for len(pending) > 0 {
+		descriptor := pending[0]
+		pending = pending[1:]
+		data, ok := blobs[descriptor.Digest]
+		if _, seen := kept[descriptor.Digest]; !ok || seen {
+			continue
+		}
+		kept[descriptor.Digest] = data
+		// The manifest lists reference manifests, and the manifests reference their config.
+		var document struct {
+			Manifests []ociv1.Descriptor `json:"manifests"`
+			Config    *ociv1.Descriptor  `json:"config"`
+		}
+		if err := json.Unmarshal(data, &document); err != nil {
+			continue
+		}
+		pending = append(pending, document.Manifests...)
+		if document.Config != nil {
+			if config, ok := blobs[document.Config.Digest]; ok {
+				kept[document.Config.Digest] = config
+			}
+		}
+	}
+	return kept
+}
+
+func parseOCIIndex(data []byte) (*ociv1.Index, error) {
+	index := &ociv1.Index{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, malformedManifestError(err)
+	}
+	return index, nil
+}
+
+// findManifest returns the descriptor of the manifest, or manifest list, of the image in the index of the layout.
+// The images referenced by tag are looked up by the org.opencontainers.image.ref.name annotation, holding either the
+// tag or the full reference. The only untagged manifest of an index is the latest one. The images referenced by
+// digest are either in the index or in a manifest list of the layout.
+func findManifest(layout ociLayout, named reference.Named) (ociv1.Descriptor, error) {
+	index, err := layout.index()
+	if err != nil {
+		return ociv1.Descriptor{}, err
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		for _, m := range index.Manifests {
+			if m.Digest == digested.Digest() {
+				return m, nil
+			}
+		}
+		if _, err = layout.blob(digested.Digest()); err != nil {
+			return ociv1.Descriptor{}, fmt.Errorf("%w: %s", errOfflineImageNotFound, named.String())
+		}
+		return ociv1.Descriptor{Digest: digested.Digest()}, nil
+	}
+	tag := defaultTag
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+	for _, m := range index.Manifests {
+		if refName := m.Annotations[ociv1.AnnotationRefName]; refName == tag || refName == named.Name()+":"+tag {
+			return m, nil
+		}
+	}
+	if len(index.Manifests) == 1 && index.Manifests[0].Annotations[ociv1.AnnotationRefName] == "" && tag == defaultTag {
+		return index.Manifests[0], nil
+	}
+	return ociv1.Descriptor{}, fmt.Errorf("%w: %s", errOfflineImageNotFound, named.String())
+}
+
+// offlineImageSource is a read-only types.ImageSource of an image in an offline source.
+type offlineImageSource struct {
+	ref        offlineImageReference
+	prefix     string
+	layout     ociLayout
+	descriptor ociv1.Descriptor
+}
+
+func (s *offlineImageSource) Reference() types.ImageReference {
+	return s.ref
+}
+
+func (s *offlineImageSource) Close() error {
+	return nil
+}
+
+func (s *offlineImageSource) GetManifest(_ context.Context, instanceDigest *digest.Digest) ([]byte, string, error) {
+	d, mediaType := s.descriptor.Digest, s.descriptor.MediaType
+	if instanceDigest != nil {
+		d, mediaType = *instanceDigest, ""
+	}
+	data, err := s.layout.blob(d)
+	if err != nil {
+		return nil, "", err
+	}
+	if mediaType == "" {
+		mediaType = manifest.GuessMIMEType(data)
+	}
+	return data, mediaType, nil
+}
+
+func (s *offlineImageSource) GetBlob(_ context.Context, info types.BlobInfo, _ types.BlobInfoCache) (io.ReadCloser, int64, error) {
+	data, err := s.layout.blob(info.Digest)
+	if err != nil {
+		return nil, 0, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
+func (s *offlineImageSource) HasThreadSafeGetBlob() bool {
+	return false
+}
+
+func (s *offlineImageSource) GetSignatures(context.Context, *digest.Digest) ([][]byte, error) {
+	return nil, nil
+}
+
+func (s *offlineImageSource) LayerInfosForCopy(context.Context, *digest.Digest) ([]types.BlobInfo, error) {
+	return nil, nil
+}
+
+// offlineImageReference is the reference of an image in an offline source. Like the references of the oci and
+// oci-archive transports, its policy identity is the path of the layout or archive.
+type offlineImageReference struct {
+	transport offlineTransport
+	path      string
+	image     string
+}
+
+func (r offlineImageReference) Transport() types.ImageTransport {
+	return r.transport
+}
+
+func (r offlineImageReference) StringWithinTransport() string {
+	return r.path + ":" + r.image
+}
+
+func (r offlineImageReference) DockerReference() reference.Named {
+	return nil
+}
+
+func (r offlineImageReference) PolicyConfigurationIdentity() string {
+	return r.path
+}
+
+func (r offlineImageReference) PolicyConfigurationNamespaces() []string {
+	var namespaces []string
+	for dir := filepath.Dir(r.path); dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+		namespaces = append(namespaces, dir)
+	}
+	return namespaces
+}
+
+func (r offlineImageReference) NewImage(context.Context, *types.SystemContext) (types.ImageCloser, error) {
+	return nil, r.transport.unsupported()
+}
+
+func (r offlineImageReference) NewImageSource(context.Context, *types.SystemContext) (types.ImageSource, error) {
+	return nil, r.transport.unsupported()
+}
+
+func (r offlineImageReference) NewImageDestination(context.Context, *types.SystemContext) (types.ImageDestination, error) {
+	return nil, r.transport.unsupported()
+}
+
+func (r offlineImageReference) DeleteImage(context.Context, *types.SystemContext) error {
+	return r.transport.unsupported()
+}
+
+// offlineTransport names the transport of the offline images for the signature policy. It is not registered in the
+// containers/image library: the offline images are only opened by the offline sources.
+type offlineTransport string
+
+func (t offlineTransport) Name() string {
+	return string(t)
+}
+
+func (t offlineTransport) ParseReference(string) (types.ImageReference, error) {
+	return nil, t.unsupported()
+}
+
+func (t offlineTransport) ValidatePolicyConfigurationScope(string) error {
+	return nil
+}
+
+func (t offlineTransport) unsupported() error {
+	return fmt.Errorf("the %s offline sources only support the image inspections", string(t))
+}
@@ -0,0 +1,369 @@
+package image
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// ociLayoutBuilder writes the blobs of an OCI image layout in memory, without the layers of the images.
+type ociLayoutBuilder struct {
+	files map[string][]byte
+	index ociv1.Index
+}
+
+func newOCILayoutBuilder() *ociLayoutBuilder {
+	return &ociLayoutBuilder{files: map[string][]byte{}, index: ociv1.Index{MediaType: ociv1.MediaTypeImageIndex}}
+}
+
+func (b *ociLayoutBuilder) addBlob(t *testing.T, mediaType string, content any) ociv1.Descriptor {
+	data, err := json.Marshal(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := digest.FromBytes(data)
+	b.files[filepath.Join(ociLayoutBlobsDir, d.Algorithm().String(), d.Encoded())] = data
+	return ociv1.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(data))}
+}
+
+func (b *ociLayoutBuilder) addImage(t *testing.T, architecture string, labels map[string]string) ociv1.Descriptor {
+	config := b.addBlob(t, ociv1.MediaTypeImageConfig, ociv1.Image{
+		Platform: ociv1.Platform{OS: "linux", Architecture: architecture},
+		Config:   ociv1.ImageConfig{Labels: labels},
+	})
+	m := ociv1.Manifest{MediaType: ociv1.MediaTypeImageManifest, Config: config, Layers: []ociv1.Descriptor{}}
+	m.SchemaVersion = 2
+	return b.addBlob(t, ociv1.MediaTypeImageManifest, m)
+}
+
+func (b *ociLayoutBuilder) addIndex(t *testing.T, architectures ...string) ociv1.Descriptor {
+	index := ociv1.Index{MediaType: ociv1.MediaTypeImageIndex}
+	index.SchemaVersion = 2
+	for _, architecture := range architectures {
+		descriptor := b.addImage(t, architecture, nil)
+		descriptor.Platform = &ociv1.Platform{OS: "linux", Architecture: architecture}
+		index.Manifests = append(index.Manifests, descriptor)
+	}
+	return b.addBlob(t, ociv1.MediaTypeImageIndex, index)
+}
+
+func (b *ociLayoutBuilder) tag(descriptor ociv1.Descriptor, refName string) {
+	if refName != "" {
+		descriptor.Annotations = map[string]string{ociv1.AnnotationRefName: refName}
+	}
+	b.index.Manifests = append(b.index.Manifests, descriptor)
+}
+
+func (b *ociLayoutBuilder) contents(t *testing.T) map[string][]byte {
+	b.index.SchemaVersion = 2
+	index, err := json.Marshal(b.index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{ociLayoutIndexFile: index, "oci-layout": []byte(`{"imageLayoutVersion":"1.0.0"}`)}
+	for name, data := range b.files {
+		files[name] = data
+	}
+	return files
+}
+
+func (b *ociLayoutBuilder) writeDir(t *testing.T, dir string) {
+	for name, data := range b.contents(t) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (b *ociLayoutBuilder) writeArchive(t *testing.T, path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	writer := tar.NewWriter(f)
+	for name, data := range b.contents(t) {
+		if err = writer.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = writer.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// usePolicyConf points the signature policy to a file with the given content.
+func usePolicyConf(t *testing.T, content string) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rwMutex.Lock()
+	previous := policyConfPath
+	policyConfPath = path
+	rwMutex.Unlock()
+	t.Cleanup(func() {
+		rwMutex.Lock()
+		policyConfPath = previous
+		rwMutex.Unlock()
+	})
+}
+
+func Test_offlineSources_inspect(t *testing.T) {
+	metrics.InitCommonMetrics()
+	usePolicyConf(t, `{"default": [{"type": "insecureAcceptAnything"}]}`)
+	root := t.TempDir()
+
+	layout := newOCILayoutBuilder()
+	list := layout.addIndex(t, utils.ArchitectureAmd64, utils.ArchitectureArm64)
+	layout.tag(list, "v1")
+	single := layout.addImage(t, utils.ArchitectureS390x, nil)
+	layout.tag(single, "quay.io/openshift/origin:single")
+	layout.writeDir(t, filepath.Join(root, "openshift", "origin"))
+
+	archive := newOCILayoutBuilder()
+	bundle := archive.addImage(t, utils.ArchitectureAmd64, map[string]string{osdkBundleMetadataAnnotation: "metadata/"})
+	archive.tag(bundle, "")
+	archive.writeArchive(t, filepath.Join(root, "openshift", "bundle.tar"))
+
+	ppc64le := newOCILayoutBuilder()
+	ppc64le.tag(ppc64le.addImage(t, utils.ArchitecturePpc64le, nil), "latest")
+	ppc64le.writeDir(t, filepath.Join(root, "example"))
+
+	state := newInspectionState()
+	s := state.offlineSources
+	var purged []string
+	s.onChange = func(prefixes []string) { purged = prefixes }
+	changed := s.configure(root, &v1beta1.OfflineImageSourcesConfig{Sources: []v1beta1.OfflineImageSource{
+		{Prefix: "quay.io", Path: "quay", Mode: v1beta1.OfflineImageSourceModePreferOffline},
+		{Prefix: "quay.io/openshift", Path: "openshift", Mode: v1beta1.OfflineImageSourceModeOfflineOnly},
+		{Prefix: "registry.example.com:5000/app", Path: "example", Mode: v1beta1.OfflineImageSourceModeOfflineOnly},
+	}})
+	if want := []string{"quay.io", "quay.io/openshift", "registry.example.com:5000/app"}; !slices.Equal(changed, want) ||
+		!slices.Equal(purged, want) {
+		t.Errorf("unexpected changed prefixes %v, purged %v", changed, purged)
+	}
+
+	tests := []struct {
+		name           string
+		imageReference string
+		wantHandled    bool
+		wantDigest     digest.Digest
+		wantPlatforms  sets.Set[Platform]
+		wantErrClass   common.ImageInspectionErrorClass
+	}{
+		{
+			name:           "manifest list in a layout",
+			imageReference: "//quay.io/openshift/origin:v1",
+			wantHandled:    true,
+			wantDigest:     list.Digest,
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64)),
+		},
+		{
+			name:           "manifest tagged with the full reference",
+			imageReference: "//quay.io/openshift/origin:single",
+			wantHandled:    true,
+			wantDigest:     single.Digest,
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureS390x)),
+		},
+		{
+			name:           "manifest of a manifest list by digest",
+			imageReference: "//quay.io/openshift/origin:v1@" + single.Digest.String(),
+			wantHandled:    true,
+			wantDigest:     single.Digest,
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitectureS390x)),
+		},
+		{
+			name:           "untagged bundle image in an archive",
+			imageReference: "//quay.io/openshift/bundle",
+			wantHandled:    true,
+			wantDigest:     bundle.Digest,
+			wantPlatforms:  PlatformsOf(utils.AllSupportedArchitecturesSet()),
+		},
+		{
+			name:           "image of the prefix repository",
+			imageReference: "//registry.example.com:5000/app",
+			wantHandled:    true,
+			wantPlatforms:  PlatformsOf(sets.New[string](utils.ArchitecturePpc64le)),
+		},
+		{
+			name:           "missing tag in an offline only source",
+			imageReference: "//quay.io/openshift/origin:v2",
+			wantHandled:    true,
+			wantErrClass:   common.ImageInspectionErrorNotFound,
+		},
+		{
+			name:           "missing repository in an offline only source",
+			imageReference: "//quay.io/openshift/missing:latest",
+			wantHandled:    true,
+			wantErrClass:   common.ImageInspectionErrorNotFound,
+		},
+		{
+			name:           "missing image in a prefer offline source",
+			imageReference: "//quay.io/other/image:latest",
+		},
+		{
+			name:           "no matching source",
+			imageReference: "//registry.example.com:5000/application:latest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, handled, err := state.inspectOffline(context.Background(), tt.imageReference)
+			if handled != tt.wantHandled {
+				t.Fatalf("expected handled to be %v, got %v (%v)", tt.wantHandled, handled, err)
+			}
+			if tt.wantErrClass != "" {
+				if err == nil || classifyError(err) != tt.wantErrClass {
+					t.Fatalf("expected an error of class %s, got %v", tt.wantErrClass, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantHandled {
+				return
+			}
+			if tt.wantDigest != "" && result.digest != tt.wantDigest {
+				t.Errorf("expected the digest %s, got %s", tt.wantDigest, result.digest)
+			}
+			if !result.platforms.Equal(tt.wantPlatforms) {
+				t.Errorf("expected the platforms %v, got %v", tt.wantPlatforms, result.platforms)
+			}
+			if d, ok, err := s.headDigest(context.Background(), tt.imageReference); !ok || err != nil || d != result.digest {
+				t.Errorf("expected the HEAD digest to be %s, got %s, %v, %v", result.digest, d, ok, err)
+			}
+		})
+	}
+
+	if changed = s.configure(root, &v1beta1.OfflineImageSourcesConfig{Sources: []v1beta1.OfflineImageSource{
+		{Prefix: "quay.io/openshift", Path: "openshift", Mode: v1beta1.OfflineImageSourceModeOfflineOnly},
+		{Prefix: "registry.example.com:5000/app", Path: "example"},
+		{Prefix: "quay.io", Path: "quay", Mode: v1beta1.OfflineImageSourceModePreferOffline},
+	}}); !slices.Equal(changed, []string{"registry.example.com:5000/app"}) {
+		t.Errorf("expected only the modified source to change, got %v", changed)
+	}
+	if changed = s.configure(root, nil); len(changed) != 3 {
+		t.Errorf("expected all the sources to be removed, got %v", changed)
+	}
+	if _, handled, _ := state.inspectOffline(context.Background(), "//quay.io/openshift/origin:v1"); handled {
+		t.Error("expected no offline source to be configured")
+	}
+}
+
+func Test_offlineSources_inspect_policy(t *testing.T) {
+	metrics.InitCommonMetrics()
+	root := t.TempDir()
+	layout := newOCILayoutBuilder()
+	layout.tag(layout.addImage(t, utils.ArchitectureArm64, nil), "latest")
+	layout.writeDir(t, filepath.Join(root, "origin"))
+	usePolicyConf(t, `{"default": [{"type": "insecureAcceptAnything"}], "transports": {"oci": {"`+
+		filepath.Join(root, "origin")+`": [{"type": "reject"}]}}}`)
+	s := newInspectionState()
+	s.offlineSources.configure(root, &v1beta1.OfflineImageSourcesConfig{Sources: []v1beta1.OfflineImageSource{
+		{Prefix: "quay.io/openshift"},
+	}})
+	_, handled, err := s.inspectOffline(context.Background(), "//quay.io/openshift/origin:latest")
+	if !handled || classifyError(err) != common.ImageInspectionErrorPolicyRejected {
+		t.Errorf("expected the signature policy of the oci transport to reject the image, got %v, %v", handled, err)
+	}
+}
+
+func Test_ociLayoutDir_blob(t *testing.T) {
+	dir := t.TempDir()
+	layout := newOCILayoutBuilder()
+	descriptor := layout.addImage(t, utils.ArchitectureAmd64, nil)
+	layout.writeDir(t, dir)
+	if _, err := ociLayoutDir(dir).blob(descriptor.Digest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ociLayoutBlobsDir, descriptor.Digest.Algorithm().String(),
+		descriptor.Digest.Encoded()), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ociLayoutDir(dir).blob(descriptor.Digest); err == nil {
+		t.Error("expected the blob not matching its digest to be rejected")
+	}
+	if _, err := ociLayoutDir(dir).blob("sha256:../../index.json"); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the invalid digest to be rejected, got %v", err)
+	}
+}
+
+func Test_loadOCIArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+	layout := newOCILayoutBuilder()
+	index := layout.addIndex(t, utils.ArchitectureAmd64, utils.ArchitectureArm64)
+	layout.tag(index, "latest")
+	layer := []byte("\x1f\x8b\x08 a compressed layer")
+	layerDigest := digest.FromBytes(layer)
+	layout.files[filepath.Join(ociLayoutBlobsDir, layerDigest.Algorithm().String(), layerDigest.Encoded())] = layer
+	unreferenced := layout.addBlob(t, ociv1.MediaTypeImageConfig, ociv1.Image{})
+	layout.writeArchive(t, path)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := loadOCIArchive(path, info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The index, the 2 manifests and their 2 configs.
+	if len(archive.blobs) != 5 {
+		t.Errorf("expected only the manifest list, the manifests and the configs to be kept, got %d blobs",
+			len(archive.blobs))
+	}
+	if _, err = archive.blob(index.Digest); err != nil {
+		t.Errorf("expected the manifest list to be kept, got %v", err)
+	}
+	for _, d := range []digest.Digest{layerDigest, unreferenced.Digest} {
+		if _, err = archive.blob(d); err == nil {
+			t.Errorf("expected the blob %s not to be kept", d)
+		}
+	}
+}
+
+func Test_offlineSources_archive(t *testing.T) {
+	s := newOfflineSources()
+	dir := t.TempDir()
+	layout := newOCILayoutBuilder()
+	layout.tag(layout.addImage(t, utils.ArchitectureAmd64, nil), "latest")
+	for i := 0; i <= maxCachedOfflineArchives; i++ {
+		path := filepath.Join(dir, strconv.Itoa(i)+".tar")
+		layout.writeArchive(t, path)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.archive(path, info); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if s.archives.Len() != maxCachedOfflineArchives {
+		t.Errorf("expected %d cached archives, got %d", maxCachedOfflineArchives, s.archives.Len())
+	}
+}
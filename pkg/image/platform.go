@@ -0,0 +1,138 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// Platform is a platform supported by an image, as declared in the manifest list entries or in the image config.
+type Platform struct {
+	// OS is the operating system, e.g., linux or windows.
+	OS string
+	// Architecture is the CPU architecture, e.g., amd64 or arm64.
+	Architecture string
+	// Variant is the CPU variant (microarchitecture level), e.g., v3 for amd64 or v7 for arm.
+	// It is empty when the image does not target a specific variant.
+	Variant string
+}
+
+// String returns the platform in the [os/]architecture[/variant] form.
+func (p Platform) String() string {
+	s := p.Architecture
+	if p.OS != "" {
+		s = p.OS + "/" + s
+	}
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// ArchitecturesOf returns the set of architectures of the given platforms.
+func ArchitecturesOf(platforms sets.Set[Platform]) sets.Set[string] {
+	architectures := sets.New[string]()
+	for p := range platforms {
+		architectures.Insert(p.Architecture)
+	}
+	return architectures
+}
+
+// SortedPlatforms returns the given platforms sorted by operating system, architecture and variant.
+func SortedPlatforms(platforms sets.Set[Platform]) []Platform {
+	sorted := platforms.UnsortedList()
+	slices.SortFunc(sorted, func(a, b Platform) int {
+		return cmp.Or(strings.Compare(a.OS, b.OS), strings.Compare(a.Architecture, b.Architecture),
+			CompareVariants(a.Variant, b.Variant))
+	})
+	return sorted
+}
+
+// OperatingSystemsOf returns the set of operating systems of the given platforms.
+func OperatingSystemsOf(platforms sets.Set[Platform]) sets.Set[string] {
+	operatingSystems := sets.New[string]()
+	for p := range platforms {
+		operatingSystems.Insert(p.OS)
+	}
+	return operatingSystems
+}
+
+// PlatformsOf returns the set of Linux platforms, with no variant, of the given architectures.
+func PlatformsOf(architectures sets.Set[string]) sets.Set[Platform] {
+	platforms := sets.New[Platform]()
+	for architecture := range architectures {
+		platforms.Insert(Platform{OS: utils.OSLinux, Architecture: architecture})
+	}
+	return platforms
+}
+
+// osOrDefault returns the given operating system or Linux, if empty.
+func osOrDefault(os string) string {
+	if os == "" {
+		return utils.OSLinux
+	}
+	return os
+}
+
+// CompareVariants compares two CPU variants of the same architecture, e.g., v2 and v3 or v8 and v8.2. The empty
+// variant is the baseline of the architecture and precedes all the others. The result is 0 if a == b,
+// -1 if a < b, and +1 if a > b. Variants that do not follow the v<major>[.<minor>] form are compared as strings.
+func CompareVariants(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+	aMajor, aMinor, aOk := parseVariant(a)
+	bMajor, bMinor, bOk := parseVariant(b)
+	if !aOk || !bOk {
+		return strings.Compare(a, b)
+	}
+	if c := cmp.Compare(aMajor, bMajor); c != 0 {
+		return c
+	}
+	return cmp.Compare(aMinor, bMinor)
+}
+
+func parseVariant(variant string) (major, minor int, ok bool) {
+	version, found := strings.CutPrefix(variant, "v")
+	if !found {
+		return 0, 0, false
+	}
+	majorStr, minorStr, hasMinor := strings.Cut(version, ".")
+	major, err := strconv.Atoi(majorStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	if hasMinor {
+		if minor, err = strconv.Atoi(minorStr); err != nil {
+			return 0, 0, false
+		}
+	}
+	return major, minor, true
+}
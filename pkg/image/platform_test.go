@@ -0,0 +1,29 @@
+package image
+
+import (
+	"testing"
+)
+
+func TestCompareVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected int
+	}{
+		{name: "equal variants", a: "v3", b: "v3", expected: 0},
+		{name: "the baseline precedes any variant", a: "", b: "v2", expected: -1},
+		{name: "any variant follows the baseline", a: "v8", b: "", expected: 1},
+		{name: "major versions are compared numerically", a: "v10", b: "v9", expected: 1},
+		{name: "minor versions are compared numerically", a: "v8.2", b: "v8.10", expected: -1},
+		{name: "a missing minor version is zero", a: "v8", b: "v8.0", expected: 0},
+		{name: "unknown forms are compared as strings", a: "cortex-a53", b: "cortex-a72", expected: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareVariants(tt.a, tt.b); got != tt.expected {
+				t.Errorf("CompareVariants(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,490 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/types"
+)
+
+const (
+	// PullFromMirrorDigestOnly restricts the use of a mirror to the references by digest.
+	PullFromMirrorDigestOnly = sysregistriesv2.MirrorByDigestOnly
+	// PullFromMirrorTagOnly restricts the use of a mirror to the references by tag.
+	PullFromMirrorTagOnly = sysregistriesv2.MirrorByTagOnly
+
+	// renderedConfGracePeriod is the time the previously rendered registries configurations are kept open, so that
+	// the in-flight inspections using them can complete.
+	renderedConfGracePeriod = 5 * time.Minute
+)
+
+// RegistryMirror is a mirror of the images of a source.
+type RegistryMirror struct {
+	// Location is the registry, and optionally the repository, of the mirror.
+	Location string
+	// PullFromMirror restricts the references the mirror is used for: PullFromMirrorDigestOnly or
+	// PullFromMirrorTagOnly. Empty means all the references.
+	PullFromMirror string
+}
+
+// RegistryMirrors are the mirrors of the images whose reference matches Source, e.g., as configured by the
+// ImageDigestMirrorSets, ImageTagMirrorSets and ImageContentSourcePolicies.
+type RegistryMirrors struct {
+	// Source is the registry, and optionally the repository, of the mirrored images. It can be a wildcard
+	// registry, e.g., *.example.com.
+	Source string
+	// Mirrors are the mirrors of the source, in order of preference.
+	Mirrors []RegistryMirror
+	// Blocked prevents the source from being contacted: the images are only pulled from the mirrors.
+	Blocked bool
+}
+
+// ProxyConfig is the configuration of the proxy used to access the registries, e.g., as set by the cluster Proxy.
+type ProxyConfig struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	// NoProxy is a comma-separated list of hostnames, domains, IPs and CIDRs for which the proxy is not used.
+	NoProxy string
+}
+
+// renderedConf is a registries configuration rendered in memory.
+type renderedConf struct {
+	// file holds the configuration with the mirrors.
+	file *os.File
+	// sourcesFile holds the same configuration without the mirrors. It is used to access each pull source of an
+	// image directly, so that the containers/image library does not try the mirrors on its own.
+	sourcesFile *os.File
+}
+
+// hostConfState identifies the version of the registries.conf file of the host.
+type hostConfState struct {
+	modTime time.Time
+	size    int64
+}
+
+// registriesConfig renders the registries configuration used by the inspections in memory. The registries.conf of
+// the host is the base of the configuration: its mirrors are replaced by the ones configured in the cluster, so that
+// the changes to the mirrors are applied without waiting for the host files to be updated, and the
+// containers/image library's cache of the registries configuration is only invalidated when it changes.
+// Until the mirrors are configured, the host files are used as they are.
+type registriesConfig struct {
+	mutex sync.RWMutex
+	// mirrors is nil until the mirrors are configured.
+	mirrors []RegistryMirrors
+	// registries are the registries of the rendered configuration, by prefix.
+	registries map[string]sysregistriesv2.Registry
+	hostConf   hostConfState
+	current    *renderedConf
+	// sourcesConfPaths maps the paths of the rendered configurations to the ones of their sourcesFile, until they
+	// are closed.
+	sourcesConfPaths map[string]string
+	proxy            ProxyConfig
+	// onChange is called with the prefixes of the registries whose configuration changed.
+	onChange func(prefixes []string)
+}
+
+func newRegistriesConfig() *registriesConfig {
+	return &registriesConfig{
+		sourcesConfPaths: make(map[string]string),
+	}
+}
+
+// configureMirrors replaces the mirrors of the registries configuration with the given ones. It returns the prefixes
+// of the registries whose configuration changed.
+func (c *registriesConfig) configureMirrors(mirrors []RegistryMirrors) ([]string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.mirrors = make([]RegistryMirrors, 0, len(mirrors))
+	for _, m := range mirrors {
+		c.mirrors = append(c.mirrors, RegistryMirrors{
+			Source:  m.Source,
+			Mirrors: slices.Clone(m.Mirrors),
+			Blocked: m.Blocked,
+		})
+	}
+	return c.render()
+}
+
+// configureProxy sets the proxy used to access the registries. It returns true if the proxy changed.
+func (c *registriesConfig) configureProxy(proxy ProxyConfig) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.proxy == proxy {
+		return false
+	}
+	c.proxy = proxy
+	return true
+}
+
+// confPath returns the path of the registries configuration, and false if it is not rendered in memory. The
+// configuration is rendered again if the registries.conf of the host changed.
+func (c *registriesConfig) confPath() (string, bool, error) {
+	c.mutex.RLock()
+	if c.mirrors == nil {
+		c.mutex.RUnlock()
+		return "", false, nil
+	}
+	if c.hostConf == readHostConfState() {
+		defer c.mutex.RUnlock()
+		return c.current.file.Name(), true, nil
+	}
+	c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.hostConf != readHostConfState() {
+		if _, err := c.render(); err != nil {
+			return "", true, err
+		}
+	}
+	return c.current.file.Name(), true, nil
+}
+
+// reloadHostConf invalidates the containers/image library's cache of the registries configuration if the
+// registries.conf of the host changed since the last call. It returns true if the cache was invalidated. It is a no-op
+// once the mirrors are configured: the configuration rendered in memory is rendered again by confPath when the host
+// file changes.
+func (c *registriesConfig) reloadHostConf() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.mirrors != nil {
+		return false
+	}
+	hostConf := readHostConfState()
+	if c.hostConf == hostConf {
+		return false
+	}
+	c.hostConf = hostConf
+	sysregistriesv2.InvalidateCache()
+	return true
+}
+
+// sourcesSystemContext returns a copy of the given SystemContext using the registries configuration without the
+// mirrors, and false if the SystemContext does not use a configuration rendered in memory.
+func (c *registriesConfig) sourcesSystemContext(sys *types.SystemContext) (*types.SystemContext, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	sourcesConfPath, ok := c.sourcesConfPaths[sys.SystemRegistriesConfPath]
+	if !ok {
+		return sys, false
+	}
+	sourcesSys := *sys
+	sourcesSys.SystemRegistriesConfPath = sourcesConfPath
+	return &sourcesSys, true
+}
+
+// render writes the registries configuration in memory. It must be called with the mutex locked.
+func (c *registriesConfig) render() ([]string, error) {
+	hostConf := readHostConfState()
+	conf := &sysregistriesv2.V2RegistriesConf{}
+	if _, err := toml.DecodeFile(RegistriesConfPath(), conf); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("unable to load %s: %w", RegistriesConfPath(), err)
+	}
+	previous := c.registries
+	if previous == nil {
+		// The host files were used so far
+		previous = registriesByPrefix(conf.Registries)
+	}
+	registries := make(map[string]sysregistriesv2.Registry, len(conf.Registries))
+	for prefix, registry := range registriesByPrefix(conf.Registries) {
+		if len(registry.Mirrors) > 0 {
+			// The mirrors of the host are configured by the cluster, and so is blocking their source.
+			registry.Blocked = false
+		}
+		registry.Mirrors = nil
+		registry.MirrorByDigestOnly = false
+		registries[prefix] = registry
+	}
+	for _, m := range c.mirrors {
+		registry, ok := registries[m.Source]
+		if !ok {
+			registry = sysregistriesv2.Registry{Prefix: m.Source}
+			if !strings.HasPrefix(m.Source, "*.") {
+				registry.Location = m.Source
+			}
+		}
+		for _, mirror := range m.Mirrors {
+			endpoint := sysregistriesv2.Endpoint{Location: mirror.Location, PullFromMirror: mirror.PullFromMirror}
+			if !slices.Contains(registry.Mirrors, endpoint) {
+				registry.Mirrors = append(registry.Mirrors, endpoint)
+			}
+		}
+		registry.Blocked = registry.Blocked || m.Blocked
+		registries[m.Source] = registry
+	}
+
+	conf.Registries = make([]sysregistriesv2.Registry, 0, len(registries))
+	for _, registry := range registries {
+		conf.Registries = append(conf.Registries, registry)
+	}
+	sort.Slice(conf.Registries, func(i, j int) bool {
+		return conf.Registries[i].Prefix < conf.Registries[j].Prefix
+	})
+	file, err := writeRegistriesConf("mto_ppc_registries_conf", conf)
+	if err != nil {
+		return nil, err
+	}
+	for i := range conf.Registries {
+		conf.Registries[i].Mirrors = nil
+	}
+	sourcesFile, err := writeRegistriesConf("mto_ppc_registries_sources_conf", conf)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if old := c.current; old != nil {
+		time.AfterFunc(renderedConfGracePeriod, func() {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			delete(c.sourcesConfPaths, old.file.Name())
+			_ = old.file.Close()
+			_ = old.sourcesFile.Close()
+		})
+	}
+	c.current = &renderedConf{file: file, sourcesFile: sourcesFile}
+	c.sourcesConfPaths[file.Name()] = sourcesFile.Name()
+	c.hostConf = hostConf
+	c.registries = registries
+	// The paths of the closed configurations are reused by the new ones.
+	sysregistriesv2.InvalidateCache()
+
+	var changed []string
+	for prefix, registry := range registries {
+		if !reflect.DeepEqual(previous[prefix], registry) {
+			changed = append(changed, prefix)
+		}
+	}
+	for prefix := range previous {
+		if _, ok := registries[prefix]; !ok {
+			changed = append(changed, prefix)
+		}
+	}
+	sort.Strings(changed)
+	if len(changed) > 0 && c.onChange != nil {
+		c.onChange(changed)
+	}
+	return changed, nil
+}
+
+// proxyFor returns the proxy to use to access the given URL, and false if no proxy is configured: the proxy of the
+// environment is then used.
+func (c *registriesConfig) proxyFor(requestURL *url.URL) (*url.URL, bool, error) {
+	c.mutex.RLock()
+	proxy := c.proxy
+	c.mutex.RUnlock()
+	if proxy == (ProxyConfig{}) {
+		return nil, false, nil
+	}
+	proxyURL := proxy.HTTPSProxy
+	if requestURL.Scheme == "http" {
+		proxyURL = proxy.HTTPProxy
+	}
+	if proxyURL == "" || matchesNoProxy(requestURL, proxy.NoProxy) {
+		return nil, true, nil
+	}
+	if !strings.Contains(proxyURL, "://") {
+		proxyURL = "http://" + proxyURL
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid proxy address %q: %w", proxyURL, err)
+	}
+	return u, true, nil
+}
+
+// proxyForRequest implements the Proxy function of the transports of the registry sessions.
+func (c *registriesConfig) proxyForRequest(req *http.Request) (*url.URL, error) {
+	if u, ok, err := c.proxyFor(req.URL); ok {
+		return u, err
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// withProxy returns a copy of the given SystemContext using the configured proxy to access the given registry.
+func (c *registriesConfig) withProxy(sys *types.SystemContext, registry string) (*types.SystemContext, error) {
+	if registry == dockerHostname {
+		registry = dockerEndpoint
+	}
+	u, ok, err := c.proxyFor(&url.URL{Scheme: "https", Host: registry})
+	if err != nil || !ok || u == nil {
+		return sys, err
+	}
+	proxySys := *sys
+	proxySys.DockerProxyURL = u
+	return &proxySys, nil
+}
+
+// matchesNoProxy returns true if the host of the given URL matches the given comma-separated list of hostnames,
+// domains, IPs and CIDRs, like the NO_PROXY environment variable.
+func matchesNoProxy(requestURL *url.URL, noProxy string) bool {
+	host, port := requestURL.Hostname(), requestURL.Port()
+	ip := net.ParseIP(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		entryHost, entryPort, err := net.SplitHostPort(entry)
+		if err != nil {
+			entryHost, entryPort = entry, ""
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		host := strings.ToLower(host)
+		if strings.HasPrefix(entryHost, ".") || strings.HasPrefix(entryHost, "*.") {
+			// .example.com matches the subdomains of example.com only
+			if strings.HasSuffix(host, strings.TrimPrefix(entryHost, "*")) {
+				return true
+			}
+			continue
+		}
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvesTagsElsewhere returns true if the given image reference by tag may be resolved by a mirror or a location
+// other than its registry, e.g., by the mirrors of the ImageTagMirrorSets. The nodes only pull the references by
+// digest from their registry and the digest mirrors, so the digest a tag resolves to through these mirrors may not be
+// pullable. It returns true if the registries configuration cannot be parsed.
+func (c *registriesConfig) resolvesTagsElsewhere(imageReference string) bool {
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(imageReference, "//"))
+	if err != nil {
+		return true
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return false
+	}
+	registriesConfPath, inMemory, err := c.confPath()
+	if err != nil {
+		return true
+	}
+	if !inMemory {
+		registriesConfPath = RegistriesConfPath()
+	}
+	registry, err := sysregistriesv2.FindRegistry(&types.SystemContext{
+		SystemRegistriesConfPath:    registriesConfPath,
+		SystemRegistriesConfDirPath: RegistriesConfDir(),
+	}, named.Name())
+	if errors.Is(err, fs.ErrNotExist) {
+		// A missing registries.conf of the host configures no mirror.
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	if registry == nil {
+		return false
+	}
+	if !strings.HasPrefix(registry.Prefix, "*.") && registry.Location != registry.Prefix {
+		return true
+	}
+	if registry.MirrorByDigestOnly {
+		return false
+	}
+	for _, mirror := range registry.Mirrors {
+		if mirror.PullFromMirror != PullFromMirrorDigestOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// referenceMatchesPrefix returns true if the given image reference matches the prefix of a registries
+// configuration entry. Short names are considered matching, as the registries they resolve to are unknown.
+func referenceMatchesPrefix(imageReference, prefix string) bool {
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(imageReference, "//"))
+	if err != nil {
+		return true
+	}
+	if strings.HasPrefix(prefix, "*.") {
+		return strings.HasSuffix(reference.Domain(named), prefix[1:])
+	}
+	name := named.String()
+	return strings.HasPrefix(name, prefix) &&
+		(len(name) == len(prefix) || strings.ContainsRune("/:@", rune(name[len(prefix)])))
+}
+
+// registriesByPrefix returns the given registries by prefix, defaulting the prefix to the location as
+// the containers/image library does.
+func registriesByPrefix(registries []sysregistriesv2.Registry) map[string]sysregistriesv2.Registry {
+	byPrefix := make(map[string]sysregistriesv2.Registry, len(registries))
+	for _, registry := range registries {
+		if registry.Prefix == "" {
+			registry.Prefix = registry.Location
+		}
+		byPrefix[registry.Prefix] = registry
+	}
+	return byPrefix
+}
+
+// writeRegistriesConf writes the given registries configuration in an in-memory file.
+func writeRegistriesConf(name string, conf *sysregistriesv2.V2RegistriesConf) (*os.File, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(conf); err != nil {
+		return nil, fmt.Errorf("unable to encode the registries configuration: %w", err)
+	}
+	fd, err := writeMemFile(name, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), fmt.Sprintf("/proc/self/fd/%d", fd)), nil
+}
+
+// readHostConfState returns the version of the registries.conf file of the host, zero if it does not exist.
+func readHostConfState() hostConfState {
+	info, err := os.Stat(RegistriesConfPath())
+	if err != nil {
+		return hostConfState{}
+	}
+	return hostConfState{modTime: info.ModTime(), size: info.Size()}
+}
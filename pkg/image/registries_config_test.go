@@ -0,0 +1,300 @@
+package image
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/types"
+)
+
+// useHostRegistriesConf points the registries.conf of the host to a file with the given content.
+func useHostRegistriesConf(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "registries.conf")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rwMutex.Lock()
+	previous := registriesConfPath
+	registriesConfPath = path
+	rwMutex.Unlock()
+	t.Cleanup(func() {
+		rwMutex.Lock()
+		registriesConfPath = previous
+		rwMutex.Unlock()
+	})
+	return path
+}
+
+func pullSourcesOf(t *testing.T, sys *types.SystemContext, imageReference string) []string {
+	named, err := reference.ParseNormalizedNamed(imageReference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := sysregistriesv2.FindRegistry(sys, named.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registry == nil {
+		return nil
+	}
+	sources, err := registry.PullSourcesFromReference(named)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var references []string
+	for _, source := range sources {
+		references = append(references, source.Reference.String())
+	}
+	return references
+}
+
+func Test_registriesConfig_configureMirrors(t *testing.T) {
+	useHostRegistriesConf(t, `unqualified-search-registries = ["docker.io"]
+[[registry]]
+location = "quay.io/openshift"
+blocked = true
+[[registry.mirror]]
+location = "old-mirror.local/openshift"
+[[registry]]
+location = "insecure.local"
+insecure = true
+`)
+	c := newRegistriesConfig()
+	var purged []string
+	c.onChange = func(prefixes []string) { purged = prefixes }
+	if path, inMemory, err := c.confPath(); err != nil || inMemory || path != "" {
+		t.Fatalf("expected the host files to be used until the mirrors are configured, got %q, %v, %v", path, inMemory, err)
+	}
+
+	changed, err := c.configureMirrors([]RegistryMirrors{
+		{
+			Source:  "registry.redhat.io/ubi9",
+			Mirrors: []RegistryMirror{{Location: "mirror.local/ubi9", PullFromMirror: PullFromMirrorDigestOnly}},
+			Blocked: true,
+		},
+		{
+			Source:  "registry.redhat.io/ubi9",
+			Mirrors: []RegistryMirror{{Location: "tags.local/ubi9", PullFromMirror: PullFromMirrorTagOnly}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(changed, []string{"quay.io/openshift", "registry.redhat.io/ubi9"}) || !slices.Equal(purged, changed) {
+		t.Errorf("unexpected changed registries %v, purged %v", changed, purged)
+	}
+	path, inMemory, err := c.confPath()
+	if err != nil || !inMemory {
+		t.Fatalf("expected the configuration to be rendered in memory, got %v, %v", inMemory, err)
+	}
+	sys := &types.SystemContext{SystemRegistriesConfPath: path, SystemRegistriesConfDirPath: t.TempDir()}
+	const digested = "registry.redhat.io/ubi9/ubi@sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+	if got := pullSourcesOf(t, sys, digested); !slices.Equal(got, []string{
+		"mirror.local/ubi9/ubi@sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b", digested}) {
+		t.Errorf("unexpected pull sources of the digested reference: %v", got)
+	}
+	if got := pullSourcesOf(t, sys, "registry.redhat.io/ubi9/ubi:9.4"); !slices.Equal(got, []string{
+		"tags.local/ubi9/ubi:9.4", "registry.redhat.io/ubi9/ubi:9.4"}) {
+		t.Errorf("unexpected pull sources of the tagged reference: %v", got)
+	}
+	// The mirrors of the host are replaced, and the blocking of their source with them
+	if got := pullSourcesOf(t, sys, "quay.io/openshift/origin:latest"); !slices.Equal(got, []string{
+		"quay.io/openshift/origin:latest"}) {
+		t.Errorf("unexpected pull sources of the host registry: %v", got)
+	}
+	if registry, err := sysregistriesv2.FindRegistry(sys, "quay.io/openshift/origin"); err != nil || registry.Blocked {
+		t.Errorf("expected the host registry not to be blocked, got %v, %v", registry, err)
+	}
+	if registry, err := sysregistriesv2.FindRegistry(sys, "insecure.local/app"); err != nil || !registry.Insecure {
+		t.Errorf("expected the other host registries to be kept, got %v, %v", registry, err)
+	}
+	if registries, err := sysregistriesv2.UnqualifiedSearchRegistries(sys); err != nil || !slices.Equal(registries, []string{"docker.io"}) {
+		t.Errorf("expected the unqualified search registries to be kept, got %v, %v", registries, err)
+	}
+
+	sourcesSys, ok := c.sourcesSystemContext(sys)
+	if !ok {
+		t.Fatal("expected the SystemContext to use a configuration rendered in memory")
+	}
+	if got := pullSourcesOf(t, sourcesSys, digested); !slices.Equal(got, []string{digested}) {
+		t.Errorf("expected the sources configuration not to have mirrors, got %v", got)
+	}
+	if registry, err := sysregistriesv2.FindRegistry(sourcesSys, digested); err != nil || !registry.Blocked {
+		t.Errorf("expected the source to be blocked in the sources configuration, got %v, %v", registry, err)
+	}
+
+	purged = nil
+	if changed, err = c.configureMirrors([]RegistryMirrors{
+		{
+			Source:  "registry.redhat.io/ubi9",
+			Mirrors: []RegistryMirror{{Location: "mirror.local/ubi9", PullFromMirror: PullFromMirrorDigestOnly}},
+			Blocked: true,
+		},
+		{
+			Source:  "registry.redhat.io/ubi9",
+			Mirrors: []RegistryMirror{{Location: "tags.local/ubi9", PullFromMirror: PullFromMirrorTagOnly}},
+		},
+	}); err != nil || len(changed) != 0 || purged != nil {
+		t.Errorf("expected no change, got %v, %v, purged %v", changed, err, purged)
+	}
+	if changed, err = c.configureMirrors(nil); err != nil || !slices.Equal(changed, []string{"registry.redhat.io/ubi9"}) {
+		t.Errorf("expected the removed mirrors to be reported, got %v, %v", changed, err)
+	}
+}
+
+func Test_registriesConfig_confPath_hostChanges(t *testing.T) {
+	hostConf := useHostRegistriesConf(t, "")
+	c := newRegistriesConfig()
+	var purged []string
+	c.onChange = func(prefixes []string) { purged = prefixes }
+	if _, err := c.configureMirrors([]RegistryMirrors{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path, _, _ := c.confPath()
+	if err := os.WriteFile(hostConf, []byte("[[registry]]\nlocation = \"blocked.local\"\nblocked = true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	renderedPath, inMemory, err := c.confPath()
+	if err != nil || !inMemory || renderedPath == path {
+		t.Fatalf("expected the configuration to be rendered again, got %q, %v, %v", renderedPath, inMemory, err)
+	}
+	if !slices.Equal(purged, []string{"blocked.local"}) {
+		t.Errorf("unexpected purged registries %v", purged)
+	}
+}
+
+func Test_registriesConfig_reloadHostConf(t *testing.T) {
+	hostConf := useHostRegistriesConf(t, "")
+	c := newRegistriesConfig()
+	if !c.reloadHostConf() {
+		t.Errorf("expected the host configuration to be loaded")
+	}
+	if c.reloadHostConf() {
+		t.Errorf("expected the unchanged host configuration not to be reloaded")
+	}
+	if err := os.WriteFile(hostConf, []byte("[[registry]]\nlocation = \"blocked.local\"\nblocked = true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if !c.reloadHostConf() {
+		t.Errorf("expected the changed host configuration to be reloaded")
+	}
+	if _, err := c.configureMirrors([]RegistryMirrors{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(hostConf, []byte(""), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if c.reloadHostConf() {
+		t.Errorf("expected the host configuration not to be reloaded once the mirrors are configured")
+	}
+}
+
+func Test_registriesConfig_proxyFor(t *testing.T) {
+	c := newRegistriesConfig()
+	if _, ok, _ := c.proxyFor(&url.URL{Scheme: "https", Host: "quay.io"}); ok {
+		t.Fatal("expected the proxy of the environment to be used when no proxy is configured")
+	}
+	c.configureProxy(ProxyConfig{
+		HTTPProxy:  "http://proxy.local:3128",
+		HTTPSProxy: "proxy.local:3129",
+		NoProxy:    ".cluster.local,10.0.0.0/16,internal.example.com,registry.local:5000",
+	})
+	tests := []struct {
+		url    string
+		expect string
+	}{
+		{url: "https://quay.io/v2/", expect: "http://proxy.local:3129"},
+		{url: "http://quay.io/v2/", expect: "http://proxy.local:3128"},
+		{url: "https://image-registry.openshift-image-registry.svc.cluster.local:5000/v2/"},
+		{url: "https://10.0.12.1/v2/"},
+		{url: "https://10.1.12.1/v2/", expect: "http://proxy.local:3129"},
+		{url: "https://internal.example.com/v2/"},
+		{url: "https://mirror.internal.example.com/v2/"},
+		{url: "https://notinternal.example.com/v2/", expect: "http://proxy.local:3129"},
+		{url: "https://registry.local:5000/v2/"},
+		{url: "https://registry.local/v2/", expect: "http://proxy.local:3129"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			proxy, ok, err := c.proxyFor(u)
+			if err != nil || !ok {
+				t.Fatalf("unexpected result: %v, %v", ok, err)
+			}
+			if got := ""; proxy != nil {
+				got = proxy.String()
+				if got != tt.expect {
+					t.Errorf("expected the proxy %q, got %q", tt.expect, got)
+				}
+			} else if tt.expect != "" {
+				t.Errorf("expected the proxy %q, got none", tt.expect)
+			}
+		})
+	}
+}
+
+func Test_referenceMatchesPrefix(t *testing.T) {
+	tests := []struct {
+		imageReference string
+		prefix         string
+		want           bool
+	}{
+		{imageReference: "//quay.io/openshift/origin:latest", prefix: "quay.io/openshift", want: true},
+		{imageReference: "quay.io/openshift/origin@sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b",
+			prefix: "quay.io/openshift/origin", want: true},
+		{imageReference: "//quay.io/openshift-release/origin:latest", prefix: "quay.io/openshift", want: false},
+		{imageReference: "//nginx:latest", prefix: "docker.io/library", want: true},
+		{imageReference: "//registry.example.com/app", prefix: "*.example.com", want: true},
+		{imageReference: "//registry.example.org/app", prefix: "*.example.com", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.imageReference+" "+tt.prefix, func(t *testing.T) {
+			if got := referenceMatchesPrefix(tt.imageReference, tt.prefix); got != tt.want {
+				t.Errorf("referenceMatchesPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_registriesConfig_resolvesTagsElsewhere(t *testing.T) {
+	useHostRegistriesConf(t, `[[registry]]
+prefix = "rewritten.local/app"
+location = "other.local/app"
+`)
+	c := newRegistriesConfig()
+	if _, err := c.configureMirrors([]RegistryMirrors{
+		{
+			Source:  "quay.io/digest",
+			Mirrors: []RegistryMirror{{Location: "mirror.local/digest", PullFromMirror: PullFromMirrorDigestOnly}},
+		},
+		{
+			Source:  "quay.io/tag",
+			Mirrors: []RegistryMirror{{Location: "mirror.local/tag", PullFromMirror: PullFromMirrorTagOnly}},
+		},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		imageReference string
+		want           bool
+	}{
+		{imageReference: "//quay.io/other/app:latest", want: false},
+		{imageReference: "//quay.io/digest/app:latest", want: false},
+		{imageReference: "//quay.io/tag/app:latest", want: true},
+		{imageReference: "//rewritten.local/app:latest", want: true},
+		{imageReference: "//quay.io/tag/app@sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b",
+			want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.imageReference, func(t *testing.T) {
+			if got := c.resolvesTagsElsewhere(tt.imageReference); got != tt.want {
+				t.Errorf("resolvesTagsElsewhere() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
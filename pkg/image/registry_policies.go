@@ -0,0 +1,349 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containers/image/v5/docker/reference"
+	"golang.org/x/time/rate"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+)
+
+const (
+	defaultCircuitBreakerFailureThreshold = 5
+	defaultCircuitBreakerOpenDuration     = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned, wrapped in an InspectionError of class Unreachable, when the inspection of an image
+// fails fast because the circuit breaker of its registry is open.
+var ErrCircuitOpen = errors.New("the circuit breaker of the registry is open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+// circuitBreaker tracks the consecutive failures of the requests to a registry. It opens after failureThreshold
+// consecutive failures and, once openDuration elapses, lets a single probe request through to decide whether to
+// close again.
+type circuitBreaker struct {
+	mutex            sync.Mutex
+	registry         string
+	failureThreshold int
+	openDuration     time.Duration
+	state            circuitState
+	failures         int
+	openedAt         time.Time
+	// now is the clock of the circuit breaker. It is defined here to facilitate testing.
+	now func() time.Time
+}
+
+func newCircuitBreaker(registry string, config *v1beta1.CircuitBreakerConfig) *circuitBreaker {
+	b := &circuitBreaker{
+		registry:         registry,
+		failureThreshold: defaultCircuitBreakerFailureThreshold,
+		openDuration:     defaultCircuitBreakerOpenDuration,
+		now:              time.Now,
+	}
+	if config != nil {
+		if config.FailureThreshold != nil {
+			b.failureThreshold = int(*config.FailureThreshold)
+		}
+		if config.OpenDuration != nil && config.OpenDuration.Duration > 0 {
+			b.openDuration = config.OpenDuration.Duration
+		}
+	}
+	return b
+}
+
+// allow returns true if a request to the registry can be issued. When the circuit is open and openDuration
+// elapsed, it moves to half-open and lets the caller through as the probe.
+func (b *circuitBreaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.openDuration {
+			return false
+		}
+		b.setState(circuitHalfOpen)
+		return true
+	case circuitHalfOpen:
+		// The probe is in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the circuit with the outcome of a request let through by allow.
+func (b *circuitBreaker) record(success bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if success {
+		b.failures = 0
+		b.setState(circuitClosed)
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || (b.failureThreshold > 0 && b.failures >= b.failureThreshold) {
+		b.openedAt = b.now()
+		b.setState(circuitOpen)
+	}
+}
+
+// abort releases a request let through by allow whose outcome does not tell anything about the health of the
+// registry, e.g., because the caller gave up. A half-open circuit is open again, ready to let another probe through.
+func (b *circuitBreaker) abort() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.state == circuitHalfOpen {
+		b.setState(circuitOpen)
+	}
+}
+
+func (b *circuitBreaker) isOpen() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state != circuitClosed
+}
+
+// setState must be called with the mutex held.
+func (b *circuitBreaker) setState(state circuitState) {
+	b.state = state
+	metrics.InitCommonMetrics()
+	metrics.RegistryCircuitState.WithLabelValues(b.registry).Set(float64(state))
+}
+
+// registryGuard enforces the policy of a registry on the requests to it.
+type registryGuard struct {
+	registry string
+	// semaphore limits the concurrent requests. It is nil if they are not limited.
+	semaphore chan struct{}
+	// limiter limits the rate of the requests. It is nil if they are not rate limited.
+	limiter *rate.Limiter
+	timeout time.Duration
+	breaker *circuitBreaker
+}
+
+func newRegistryGuard(registry string, policy *v1beta1.RegistryPolicy) *registryGuard {
+	if policy == nil {
+		return &registryGuard{
+			registry: registry,
+			breaker:  newCircuitBreaker(registry, nil),
+		}
+	}
+	g := &registryGuard{
+		registry: registry,
+		breaker:  newCircuitBreaker(registry, policy.CircuitBreaker),
+	}
+	if policy.MaxConcurrentInspections > 0 {
+		g.semaphore = make(chan struct{}, policy.MaxConcurrentInspections)
+	}
+	if policy.RequestsPerSecond > 0 {
+		burst := int(policy.Burst)
+		if burst == 0 {
+			burst = int(policy.RequestsPerSecond)
+		}
+		g.limiter = rate.NewLimiter(rate.Limit(policy.RequestsPerSecond), burst)
+	}
+	if policy.Timeout != nil {
+		g.timeout = policy.Timeout.Duration
+	}
+	return g
+}
+
+// registryLease is a request to a registry admitted by its guard.
+type registryLease struct {
+	guard  *registryGuard
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// acquire waits for the circuit breaker, the rate and the concurrency limits of the registry to admit a request.
+// It returns the context, bound by the timeout of the registry, to issue the request with and the lease to release
+// once the request completes.
+func (g *registryGuard) acquire(ctx context.Context) (context.Context, *registryLease, error) {
+	if !g.breaker.allow() {
+		metrics.InitCommonMetrics()
+		metrics.RegistryCircuitRejections.WithLabelValues(g.registry).Inc()
+		return nil, nil, &InspectionError{
+			Class: common.ImageInspectionErrorUnreachable,
+			Err:   fmt.Errorf("%s: %w", g.registry, ErrCircuitOpen),
+		}
+	}
+	lease := &registryLease{guard: g, parent: ctx, cancel: func() {}}
+	requestCtx := ctx
+	if g.timeout > 0 {
+		requestCtx, lease.cancel = context.WithTimeout(ctx, g.timeout)
+	}
+	if g.limiter != nil {
+		if err := g.limiter.Wait(requestCtx); err != nil {
+			lease.cancel()
+			g.breaker.abort()
+			return nil, nil, err
+		}
+	}
+	if g.semaphore != nil {
+		select {
+		case g.semaphore <- struct{}{}:
+		case <-requestCtx.Done():
+			lease.cancel()
+			g.breaker.abort()
+			return nil, nil, requestCtx.Err()
+		}
+	}
+	return requestCtx, lease, nil
+}
+
+// release records the outcome of the request in the circuit breaker of the registry and releases its slot.
+// Only the errors showing that the registry is unreachable or throttling, including the requests exceeding the
+// timeout of the registry, count as failures.
+func (l *registryLease) release(err error) {
+	if l == nil {
+		return
+	}
+	defer l.cancel()
+	if l.guard.semaphore != nil {
+		<-l.guard.semaphore
+	}
+	switch {
+	case err == nil:
+		l.guard.breaker.record(true)
+	case l.parent.Err() != nil:
+		// The caller gave up: the outcome does not tell anything about the registry
+		l.guard.breaker.abort()
+	default:
+		class := classifyError(err)
+		l.guard.breaker.record(class != common.ImageInspectionErrorUnreachable &&
+			class != common.ImageInspectionErrorRateLimited)
+	}
+}
+
+// abort releases the slot of the request without recording its outcome in the circuit breaker.
+func (l *registryLease) abort() {
+	if l == nil {
+		return
+	}
+	defer l.cancel()
+	if l.guard.semaphore != nil {
+		<-l.guard.semaphore
+	}
+	l.guard.breaker.abort()
+}
+
+// registryGuards holds the guards of the registries, built from the registry policies of the
+// ClusterPodPlacementConfig.
+type registryGuards struct {
+	mutex    sync.Mutex
+	policies []v1beta1.RegistryPolicy
+	guards   map[string]*registryGuard
+}
+
+func newRegistryGuards() *registryGuards {
+	return &registryGuards{
+		guards: make(map[string]*registryGuard),
+	}
+}
+
+// configure applies the given registry policies. It returns true if they changed: the guards, and the state of the
+// circuit breakers, are reset.
+func (r *registryGuards) configure(policies []v1beta1.RegistryPolicy) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if reflect.DeepEqual(r.policies, policies) || (len(r.policies) == 0 && len(policies) == 0) {
+		return false
+	}
+	r.policies = slices.Clone(policies)
+	r.guards = make(map[string]*registryGuard)
+	metrics.InitCommonMetrics()
+	metrics.RegistryCircuitState.Reset()
+	return true
+}
+
+// guardFor returns the guard of the registry of the given image reference, or nil if the reference cannot be parsed.
+func (r *registryGuards) guardFor(imageReference string) *registryGuard {
+	registry := registryOf(imageReference)
+	if registry == "" {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if guard, ok := r.guards[registry]; ok {
+		return guard
+	}
+	guard := newRegistryGuard(registry, matchRegistryPolicy(r.policies, registry))
+	r.guards[registry] = guard
+	return guard
+}
+
+// acquire admits a request to the registry of the given image reference. See registryGuard.acquire.
+func (r *registryGuards) acquire(ctx context.Context, imageReference string) (context.Context, *registryLease, error) {
+	guard := r.guardFor(imageReference)
+	if guard == nil {
+		return ctx, nil, nil
+	}
+	return guard.acquire(ctx)
+}
+
+// unhealthyRegistries returns the sorted list of the registries whose circuit breaker is not closed.
+func (r *registryGuards) unhealthyRegistries() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	registries := make([]string, 0)
+	for registry, guard := range r.guards {
+		if guard.breaker.isOpen() {
+			registries = append(registries, registry)
+		}
+	}
+	slices.Sort(registries)
+	return registries
+}
+
+// matchRegistryPolicy returns the first policy whose host matches the given registry, or nil if none matches.
+func matchRegistryPolicy(policies []v1beta1.RegistryPolicy, registry string) *v1beta1.RegistryPolicy {
+	for i := range policies {
+		if matched, err := URLsMatchStr(policies[i].Host, registry); err == nil && matched {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// registryOf returns the registry host of the given image reference, or an empty string if it cannot be parsed.
+// Short names are accounted to docker.io.
+func registryOf(imageReference string) string {
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(imageReference, "//"))
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
@@ -0,0 +1,198 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/containers/image/v5/docker"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/multiarch-tuning-operator/api/common"
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+func Test_circuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker("quay.io", &v1beta1.CircuitBreakerConfig{
+		FailureThreshold: utils.NewPtr(int32(2)),
+		OpenDuration:     &metav1.Duration{Duration: time.Minute},
+	})
+	b.now = func() time.Time { return now }
+
+	steps := []struct {
+		name          string
+		elapsed       time.Duration
+		expectAllowed bool
+		success       bool
+		expectOpen    bool
+	}{
+		{name: "first failure keeps the circuit closed", expectAllowed: true, success: false, expectOpen: false},
+		{name: "a success resets the failures", expectAllowed: true, success: true, expectOpen: false},
+		{name: "failure after the reset", expectAllowed: true, success: false, expectOpen: false},
+		{name: "second consecutive failure opens the circuit", expectAllowed: true, success: false, expectOpen: true},
+		{name: "open circuit fails fast", elapsed: 30 * time.Second, expectAllowed: false, expectOpen: true},
+		{name: "failed probe opens the circuit again", elapsed: time.Minute, expectAllowed: true, success: false, expectOpen: true},
+		{name: "successful probe closes the circuit", elapsed: time.Minute, expectAllowed: true, success: true, expectOpen: false},
+	}
+	for _, step := range steps {
+		now = now.Add(step.elapsed)
+		allowed := b.allow()
+		if allowed != step.expectAllowed {
+			t.Fatalf("%s: expected allow() = %v, got %v", step.name, step.expectAllowed, allowed)
+		}
+		if allowed {
+			b.record(step.success)
+		}
+		if b.isOpen() != step.expectOpen {
+			t.Fatalf("%s: expected isOpen() = %v, got %v", step.name, step.expectOpen, b.isOpen())
+		}
+	}
+}
+
+func Test_circuitBreaker_halfOpenAllowsASingleProbe(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker("quay.io", &v1beta1.CircuitBreakerConfig{FailureThreshold: utils.NewPtr(int32(1))})
+	b.now = func() time.Time { return now }
+	b.record(false)
+	now = now.Add(defaultCircuitBreakerOpenDuration)
+	if !b.allow() {
+		t.Fatal("expected the probe to be allowed")
+	}
+	if b.allow() {
+		t.Fatal("expected the requests to fail fast while the probe is in flight")
+	}
+	b.abort()
+	if !b.allow() {
+		t.Fatal("expected another probe to be allowed after the previous one was aborted")
+	}
+}
+
+func Test_registryLease_release(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		cancelled  bool
+		expectOpen bool
+	}{
+		{
+			name:       "unreachable registry counts as a failure",
+			err:        context.DeadlineExceeded,
+			expectOpen: true,
+		},
+		{
+			name:       "rate limited registry counts as a failure",
+			err:        docker.ErrTooManyRequests,
+			expectOpen: true,
+		},
+		{
+			name:       "image not found does not count as a failure",
+			err:        docker.UnexpectedHTTPStatusError{StatusCode: http.StatusNotFound},
+			expectOpen: false,
+		},
+		{
+			name:       "cancelled callers do not count as a failure",
+			err:        errors.New("context canceled"),
+			cancelled:  true,
+			expectOpen: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := newRegistryGuard("quay.io", &v1beta1.RegistryPolicy{
+				Host:                     "quay.io",
+				MaxConcurrentInspections: 1,
+				CircuitBreaker:           &v1beta1.CircuitBreakerConfig{FailureThreshold: utils.NewPtr(int32(1))},
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_, lease, err := guard.acquire(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.cancelled {
+				cancel()
+			}
+			lease.release(tt.err)
+			if guard.breaker.isOpen() != tt.expectOpen {
+				t.Errorf("expected isOpen() = %v, got %v", tt.expectOpen, guard.breaker.isOpen())
+			}
+			if len(guard.semaphore) != 0 {
+				t.Errorf("expected the concurrency slot to be released")
+			}
+		})
+	}
+}
+
+func Test_registryGuard_acquire(t *testing.T) {
+	guard := newRegistryGuard("quay.io", &v1beta1.RegistryPolicy{
+		Host:                     "quay.io",
+		MaxConcurrentInspections: 1,
+		Timeout:                  &metav1.Duration{Duration: 50 * time.Millisecond},
+		CircuitBreaker:           &v1beta1.CircuitBreakerConfig{FailureThreshold: utils.NewPtr(int32(1))},
+	})
+	_, lease, err := guard.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The concurrency slot is held by the first request: the second one exceeds the timeout waiting for it.
+	if _, _, err = guard.acquire(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the request to exceed the timeout, got %v", err)
+	}
+	lease.release(context.DeadlineExceeded)
+	_, _, err = guard.acquire(context.Background())
+	if !errors.Is(err, ErrCircuitOpen) || ErrorClass(err) != common.ImageInspectionErrorUnreachable {
+		t.Fatalf("expected the request to fail fast with an Unreachable error, got %v", err)
+	}
+}
+
+func Test_registryGuards(t *testing.T) {
+	guards := newRegistryGuards()
+	policies := []v1beta1.RegistryPolicy{
+		{Host: "*.docker.io", MaxConcurrentInspections: 2},
+		{Host: "quay.io", RequestsPerSecond: 10},
+	}
+	if !guards.configure(policies) {
+		t.Fatal("expected the configuration to change")
+	}
+	if guards.configure(policies) {
+		t.Fatal("expected the configuration not to change")
+	}
+	tests := []struct {
+		imageReference   string
+		expectedRegistry string
+		expectSemaphore  bool
+		expectLimiter    bool
+	}{
+		{imageReference: "//registry-1.docker.io/library/busybox:latest", expectedRegistry: "registry-1.docker.io", expectSemaphore: true},
+		{imageReference: "//quay.io/foo/bar@" + testDigest, expectedRegistry: "quay.io", expectLimiter: true},
+		{imageReference: "//busybox", expectedRegistry: "docker.io"},
+		{imageReference: "//registry.example.com:5000/foo/bar:latest", expectedRegistry: "registry.example.com:5000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.imageReference, func(t *testing.T) {
+			guard := guards.guardFor(tt.imageReference)
+			if guard == nil {
+				t.Fatal("expected a guard")
+			}
+			if guard.registry != tt.expectedRegistry {
+				t.Errorf("expected registry %s, got %s", tt.expectedRegistry, guard.registry)
+			}
+			if (guard.semaphore != nil) != tt.expectSemaphore {
+				t.Errorf("expected semaphore %v, got %v", tt.expectSemaphore, guard.semaphore != nil)
+			}
+			if (guard.limiter != nil) != tt.expectLimiter {
+				t.Errorf("expected limiter %v, got %v", tt.expectLimiter, guard.limiter != nil)
+			}
+		})
+	}
+	for range defaultCircuitBreakerFailureThreshold {
+		guards.guardFor("//quay.io/foo/bar:latest").breaker.record(false)
+	}
+	if unhealthy := guards.unhealthyRegistries(); len(unhealthy) != 1 || unhealthy[0] != "quay.io" {
+		t.Errorf("expected quay.io to be unhealthy, got %v", unhealthy)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/json"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/containers/image/v5/manifest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+)
+
+const (
+	// wasmConfigMediaType is the config media type of the WebAssembly OCI artifacts.
+	wasmConfigMediaType = "application/vnd.wasm.config.v0+json"
+	// wasmToOCIConfigMediaType is the config media type of the artifacts pushed by wasm-to-oci.
+	wasmToOCIConfigMediaType = "application/vnd.wasm.config.v1+json"
+	// wasmLayerMediaType is the media type of the WebAssembly modules and components.
+	wasmLayerMediaType = "application/vnd.wasm.content.layer.v1+wasm"
+	// wasmModuleLayerMediaType is the media type of the WebAssembly modules of the crun and containerd shims.
+	wasmModuleLayerMediaType = "application/vnd.module.wasm.content.layer.v1+wasm"
+)
+
+// WasmPlatform is the platform of the WebAssembly images.
+var WasmPlatform = Platform{OS: "wasi", Architecture: "wasm"}
+
+// builtinRuntimeMediaTypes maps the media types that are always recognized to their platform.
+var builtinRuntimeMediaTypes = map[string]Platform{
+	wasmConfigMediaType:      WasmPlatform,
+	wasmToOCIConfigMediaType: WasmPlatform,
+	wasmLayerMediaType:       WasmPlatform,
+	wasmModuleLayerMediaType: WasmPlatform,
+}
+
+// runtimePlatforms maps the media types of the images that do not run as native containers to their platform.
+type runtimePlatforms struct {
+	mutex sync.RWMutex
+	// mediaTypes holds the media types of the RuntimeClass mappings of the ClusterPodPlacementConfig.
+	mediaTypes map[string]Platform
+}
+
+// configure sets the media types of the given RuntimeClass mappings. It returns true if they changed.
+func (r *runtimePlatforms) configure(mappings []v1beta1.RuntimeClassMapping) bool {
+	mediaTypes := map[string]Platform{}
+	for _, mapping := range mappings {
+		os, architecture, _ := strings.Cut(mapping.Platform, "/")
+		for _, mediaType := range mapping.MediaTypes {
+			mediaTypes[mediaType] = Platform{OS: os, Architecture: architecture}
+		}
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if maps.Equal(r.mediaTypes, mediaTypes) {
+		return false
+	}
+	r.mediaTypes = mediaTypes
+	return true
+}
+
+// configuredMediaTypes returns the media types of the RuntimeClass mappings and their platform.
+func (r *runtimePlatforms) configuredMediaTypes() map[string]Platform {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.mediaTypes
+}
+
+// runtimeManifest holds the fields of an image manifest identifying the artifacts and the runtime-specific images.
+type runtimeManifest struct {
+	ArtifactType string             `json:"artifactType,omitempty"`
+	Config       ociv1.Descriptor   `json:"config"`
+	Layers       []ociv1.Descriptor `json:"layers"`
+}
+
+// platformOf returns the platform mapped to the artifact type, the config media type or, in order, the layer media
+// types of the given image manifest. The configured mappings of a media type take precedence over the built-in
+// ones.
+// It returns false if none of them is mapped.
+func (r *runtimePlatforms) platformOf(rawManifest []byte) (Platform, bool) {
+	var m runtimeManifest
+	if err := json.Unmarshal(rawManifest, &m); err != nil {
+		return Platform{}, false
+	}
+	mediaTypes := []string{m.ArtifactType, m.Config.MediaType}
+	for _, layer := range m.Layers {
+		mediaTypes = append(mediaTypes, layer.MediaType)
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, mediaType := range mediaTypes {
+		if platform, ok := r.mediaTypes[mediaType]; ok {
+			return platform, true
+		}
+		if platform, ok := builtinRuntimeMediaTypes[mediaType]; ok {
+			return platform, true
+		}
+	}
+	return Platform{}, false
+}
+
+// isArtifact returns true if the given image manifest is an OCI artifact rather than a container image, i.e., if it
+// declares an artifact type or a config that is not an image config.
+func isArtifact(rawManifest []byte) bool {
+	var m runtimeManifest
+	if err := json.Unmarshal(rawManifest, &m); err != nil {
+		return false
+	}
+	if m.ArtifactType != "" {
+		return true
+	}
+	// The Docker schema 1 manifests have no config
+	return m.Config.MediaType != "" && m.Config.MediaType != ociv1.MediaTypeImageConfig &&
+		m.Config.MediaType != manifest.DockerV2Schema2ConfigMediaType
+}
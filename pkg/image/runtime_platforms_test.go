@@ -0,0 +1,128 @@
+package image
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+)
+
+const unikernelArtifactType = "application/vnd.example.unikernel.v1"
+
+// addArtifact adds an OCI 1.1 artifact of the given artifact type, config media type and layer media type.
+func (b *ociLayoutBuilder) addArtifact(t *testing.T, artifactType, configMediaType, layerMediaType string) ociv1.Descriptor {
+	artifact := ociv1.Manifest{
+		MediaType:    ociv1.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Config:       b.addBlob(t, configMediaType, map[string]string{}),
+		Layers:       []ociv1.Descriptor{b.addBlob(t, layerMediaType, "module")},
+	}
+	artifact.SchemaVersion = 2
+	return b.addBlob(t, ociv1.MediaTypeImageManifest, artifact)
+}
+
+func Test_inspectSource_runtimePlatforms(t *testing.T) {
+	metrics.InitCommonMetrics()
+	usePolicyConf(t, `{"default": [{"type": "insecureAcceptAnything"}]}`)
+	root := t.TempDir()
+
+	layout := newOCILayoutBuilder()
+	wasm := layout.addArtifact(t, "", wasmConfigMediaType, wasmLayerMediaType)
+	layout.tag(wasm, "wasm")
+	layout.tag(layout.addArtifact(t, "", ociv1.MediaTypeImageConfig, wasmModuleLayerMediaType), "wasm-layers")
+	wasmEntry := wasm
+	wasmEntry.Platform = &ociv1.Platform{OS: "wasi", Architecture: "wasm"}
+	index := ociv1.Index{MediaType: ociv1.MediaTypeImageIndex, Manifests: []ociv1.Descriptor{wasmEntry}}
+	index.SchemaVersion = 2
+	layout.tag(layout.addBlob(t, ociv1.MediaTypeImageIndex, index), "wasm-index")
+	layout.tag(layout.addArtifact(t, unikernelArtifactType, ociv1.MediaTypeEmptyJSON, "application/octet-stream"),
+		"unikernel")
+	layout.writeDir(t, filepath.Join(root, "origin"))
+
+	s := newInspectionState()
+	s.offlineSources.configure(root, &v1beta1.OfflineImageSourcesConfig{Sources: []v1beta1.OfflineImageSource{
+		{Prefix: "quay.io/openshift", Mode: v1beta1.OfflineImageSourceModeOfflineOnly},
+	}})
+
+	wasmPlatforms := sets.New[Platform](WasmPlatform)
+	for _, tag := range []string{"wasm", "wasm-layers", "wasm-index"} {
+		result, _, err := s.inspectOffline(context.Background(), "//quay.io/openshift/origin:"+tag)
+		if err != nil {
+			t.Fatalf("unexpected error inspecting %s: %v", tag, err)
+		}
+		if !result.platforms.Equal(wasmPlatforms) {
+			t.Errorf("expected %s to run on %v, got %v", tag, wasmPlatforms, result.platforms)
+		}
+	}
+	s.manifestListValidation.configure(true)
+	result, _, err := s.inspectOffline(context.Background(), "//quay.io/openshift/origin:wasm-index")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.platforms.Equal(wasmPlatforms) {
+		t.Errorf("expected the deep validation to accept the wasm entry, got %v", result.platforms)
+	}
+
+	result, _, err = s.inspectOffline(context.Background(), "//quay.io/openshift/origin:unikernel")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.platforms.Len() != 0 {
+		t.Errorf("expected no platform for an unmapped artifact, got %v", result.platforms)
+	}
+	s.runtimePlatforms.configure([]v1beta1.RuntimeClassMapping{{
+		Platform:         "unikernel/amd64",
+		RuntimeClassName: "unikraft",
+		MediaTypes:       []string{unikernelArtifactType},
+	}})
+	result, _, err = s.inspectOffline(context.Background(), "//quay.io/openshift/origin:unikernel")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := sets.New[Platform](Platform{OS: "unikernel", Architecture: "amd64"}); !result.platforms.Equal(want) {
+		t.Errorf("expected the mapped artifact to run on %v, got %v", want, result.platforms)
+	}
+}
+
+func Test_isArtifact(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     bool
+	}{
+		{
+			name:     "OCI image",
+			manifest: `{"config": {"mediaType": "application/vnd.oci.image.config.v1+json"}}`,
+		},
+		{
+			name:     "Docker image",
+			manifest: `{"config": {"mediaType": "application/vnd.docker.container.image.v1+json"}}`,
+		},
+		{
+			name:     "Docker schema 1 image",
+			manifest: `{"schemaVersion": 1, "architecture": "amd64"}`,
+		},
+		{
+			name:     "artifact type",
+			manifest: `{"artifactType": "application/vnd.example+type", "config": {"mediaType": "application/vnd.oci.empty.v1+json"}}`,
+			want:     true,
+		},
+		{
+			name:     "non-image config",
+			manifest: `{"config": {"mediaType": "application/vnd.cncf.helm.config.v1+json"}}`,
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isArtifact([]byte(tt.manifest)); got != tt.want {
+				t.Errorf("isArtifact() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,461 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/docker/config"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/pkg/tlsclientconfig"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+)
+
+const (
+	// defaultBearerTokenTTL is the lifetime of the bearer tokens whose response does not set expires_in, as per the
+	// distribution token authentication specification.
+	defaultBearerTokenTTL = 60 * time.Second
+	// bearerTokenExpiryMargin is subtracted from the lifetime of the bearer tokens, so that they do not expire
+	// during the inspection they are used for.
+	bearerTokenExpiryMargin = 10 * time.Second
+	// unauthenticatedSessionTTL is the time after which the registries that did not require a bearer token are
+	// probed again.
+	unauthenticatedSessionTTL = 10 * time.Minute
+
+	// dockerHostname is the registry of the images normalized to docker.io, served by dockerEndpoint.
+	dockerHostname = "docker.io"
+	dockerEndpoint = "registry-1.docker.io"
+)
+
+// perHostCertDirs are the directories looked up for the certificates of a registry when the SystemContext does not
+// set DockerPerHostCertDirPath. They match the ones of the containers/image library.
+var perHostCertDirs = []string{"/etc/containers/certs.d", "/etc/docker/certs.d"}
+
+// sessionKey identifies the bearer tokens: they are scoped to a repository and bound to the credentials they were
+// negotiated with.
+type sessionKey struct {
+	registry   string
+	repository string
+	// credentials is the hash of the credentials the token was negotiated with.
+	credentials string
+}
+
+// bearerToken is a token issued by the authorization server of a registry. An empty token means that the registry
+// did not require one.
+type bearerToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// registryTransport is the long-lived HTTP client used to access a registry.
+type registryTransport struct {
+	client *http.Client
+	// certsFingerprint identifies the content of the certificates directory of the registry the client was
+	// configured with.
+	certsFingerprint string
+}
+
+// registrySessions reuses the HTTP connections to the registries and the bearer tokens they issue across the
+// inspections. Without it, the containers/image library negotiates a new token over a new connection for each
+// inspection. The tokens are injected in the SystemContext of the inspections, while the manifest HEAD requests
+// revalidating the tag-to-digest cache entries are issued on the pooled connections.
+// The sessions are not used for the registries with mirrors or location rewrites, as the tokens are only valid for
+// the primary registry, nor for the insecure registries and the credentials relying on identity tokens.
+type registrySessions struct {
+	mutex      sync.Mutex
+	transports map[string]*registryTransport
+	tokens     map[sessionKey]bearerToken
+	// now is the clock of the sessions. It is defined here to facilitate testing.
+	now func() time.Time
+	// registriesConfig provides the proxy of the transports.
+	registriesConfig *registriesConfig
+}
+
+func newRegistrySessions(registriesConfig *registriesConfig) *registrySessions {
+	return &registrySessions{
+		transports:       make(map[string]*registryTransport),
+		tokens:           make(map[sessionKey]bearerToken),
+		now:              time.Now,
+		registriesConfig: registriesConfig,
+	}
+}
+
+// registrySession is the access to a repository of a registry with a given set of credentials.
+type registrySession struct {
+	sessions  *registrySessions
+	key       sessionKey
+	reference reference.Named
+	// endpoint is the host the requests to the registry are sent to.
+	endpoint string
+	client   *http.Client
+	auth     types.DockerAuthConfig
+	token    bearerToken
+	// warm is true if the token was negotiated by a previous inspection.
+	warm bool
+}
+
+// reset drops the pooled connections and the bearer tokens. It is called when the global pull secret changes.
+func (s *registrySessions) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, transport := range s.transports {
+		transport.client.CloseIdleConnections()
+	}
+	s.transports = make(map[string]*registryTransport)
+	s.tokens = make(map[sessionKey]bearerToken)
+}
+
+// open returns the session to access the given reference, negotiating a bearer token if none is cached.
+// It returns nil if the sessions cannot be used for the reference.
+func (s *registrySessions) open(ctx context.Context, sys *types.SystemContext, named reference.Named) (*registrySession, error) {
+	registry := reference.Domain(named)
+	reg, err := sysregistriesv2.FindRegistry(sys, named.Name())
+	if err != nil {
+		return nil, err
+	}
+	if reg != nil {
+		if reg.Blocked {
+			return nil, nil
+		}
+		sources, err := reg.PullSourcesFromReference(named)
+		if err != nil {
+			return nil, err
+		}
+		if len(sources) != 1 || sources[0].Endpoint.Insecure || sources[0].Reference.Name() != named.Name() {
+			return nil, nil
+		}
+	}
+	auth, err := config.GetCredentialsForRef(sys, named)
+	if err != nil {
+		return nil, err
+	}
+	if auth.IdentityToken != "" {
+		return nil, nil
+	}
+	client, err := s.clientFor(sys, registry)
+	if err != nil {
+		return nil, err
+	}
+	session := &registrySession{
+		sessions: s,
+		key: sessionKey{
+			registry:    registry,
+			repository:  reference.Path(named),
+			credentials: credentialsHash(auth),
+		},
+		reference: named,
+		endpoint:  registry,
+		client:    client,
+		auth:      auth,
+	}
+	if registry == dockerHostname {
+		session.endpoint = dockerEndpoint
+	}
+	s.mutex.Lock()
+	token, ok := s.tokens[session.key]
+	s.mutex.Unlock()
+	if ok && s.now().Before(token.expiresAt) {
+		session.token = token
+		session.warm = true
+		return session, nil
+	}
+	if session.token, err = session.negotiate(ctx); err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	s.tokens[session.key] = session.token
+	s.mutex.Unlock()
+	return session, nil
+}
+
+// clientFor returns the HTTP client of the registry. The client is rebuilt, and the tokens of the registry dropped,
+// when the certificates of the registry change.
+func (s *registrySessions) clientFor(sys *types.SystemContext, registry string) (*http.Client, error) {
+	certsDir := certsDirFor(sys, registry)
+	fingerprint := certsFingerprint(certsDir)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if transport, ok := s.transports[registry]; ok {
+		if transport.certsFingerprint == fingerprint {
+			return transport.client, nil
+		}
+		transport.client.CloseIdleConnections()
+		for key := range s.tokens {
+			if key.registry == registry {
+				delete(s.tokens, key)
+			}
+		}
+	}
+	tlsClientConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if err := tlsclientconfig.SetupCertificates(certsDir, tlsClientConfig); err != nil {
+		return nil, err
+	}
+	tr := tlsclientconfig.NewTransport()
+	tr.TLSClientConfig = tlsClientConfig
+	tr.Proxy = s.registriesConfig.proxyForRequest
+	tr.MaxIdleConnsPerHost = 10
+	transport := &registryTransport{
+		client:           &http.Client{Transport: tr},
+		certsFingerprint: fingerprint,
+	}
+	s.transports[registry] = transport
+	return transport.client, nil
+}
+
+// systemContextFor returns the SystemContext to access the given reference, carrying the bearer token of its
+// session, and the session itself. Failing to open the session is not fatal: the given SystemContext and a nil
+// session are returned, and the containers/image library negotiates the access on its own.
+func (s *registrySessions) systemContextFor(ctx context.Context, sys *types.SystemContext,
+	named reference.Named) (*types.SystemContext, *registrySession, error) {
+	session, err := s.open(ctx, sys, named)
+	if err != nil || session == nil || session.token.token == "" {
+		return sys, session, err
+	}
+	sessionSys := *sys
+	sessionSys.DockerBearerRegistryToken = session.token.token
+	return &sessionSys, session, nil
+}
+
+// negotiate pings the registry and, if it requires a bearer token, requests one for the pull scope of the repository.
+func (r *registrySession) negotiate(ctx context.Context) (bearerToken, error) {
+	metrics.InitCommonMetrics()
+	metrics.RegistryTokenNegotiations.Inc()
+	res, err := r.do(ctx, http.MethodGet, fmt.Sprintf("https://%s/v2/", r.endpoint), nil)
+	if err != nil {
+		return bearerToken{}, err
+	}
+	_ = res.Body.Close()
+	now := r.sessions.now()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return bearerToken{expiresAt: now.Add(unauthenticatedSessionTTL)}, nil
+	case http.StatusUnauthorized:
+	default:
+		return bearerToken{}, docker.UnexpectedHTTPStatusError{StatusCode: res.StatusCode}
+	}
+	scheme, params := parseChallenge(res.Header.Get("WWW-Authenticate"))
+	if scheme != "bearer" || params["realm"] == "" {
+		// Basic authentication does not need a token
+		return bearerToken{expiresAt: now.Add(unauthenticatedSessionTTL)}, nil
+	}
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil {
+		return bearerToken{}, err
+	}
+	query := tokenURL.Query()
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", r.key.repository))
+	tokenURL.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return bearerToken{}, err
+	}
+	if r.auth.Username != "" {
+		req.SetBasicAuth(r.auth.Username, r.auth.Password)
+	}
+	res, err = r.client.Do(req)
+	if err != nil {
+		return bearerToken{}, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode != http.StatusOK {
+		return bearerToken{}, docker.UnexpectedHTTPStatusError{StatusCode: res.StatusCode}
+	}
+	var body struct {
+		Token       string    `json:"token"`
+		AccessToken string    `json:"access_token"`
+		ExpiresIn   int       `json:"expires_in"`
+		IssuedAt    time.Time `json:"issued_at"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return bearerToken{}, fmt.Errorf("unable to decode the token of %s: %w", r.key.registry, err)
+	}
+	token := bearerToken{token: body.Token}
+	if token.token == "" {
+		token.token = body.AccessToken
+	}
+	if token.token == "" {
+		return bearerToken{}, errors.New("the authorization server of " + r.key.registry + " returned an empty token")
+	}
+	ttl := defaultBearerTokenTTL
+	if body.ExpiresIn > 0 {
+		ttl = time.Duration(body.ExpiresIn) * time.Second
+	}
+	issuedAt := now
+	if !body.IssuedAt.IsZero() && body.IssuedAt.Before(now) {
+		issuedAt = body.IssuedAt
+	}
+	token.expiresAt = issuedAt.Add(ttl - bearerTokenExpiryMargin)
+	return token, nil
+}
+
+// headDigest returns the digest the reference of the session resolves to, using a manifest HEAD request.
+func (r *registrySession) headDigest(ctx context.Context) (digest.Digest, error) {
+	if canonical, ok := r.reference.(reference.Canonical); ok {
+		return canonical.Digest(), nil
+	}
+	tagged, ok := reference.TagNameOnly(r.reference).(reference.NamedTagged)
+	if !ok {
+		return "", fmt.Errorf("unable to determine the tag of %s", r.reference.String())
+	}
+	res, err := r.do(ctx, http.MethodHead,
+		fmt.Sprintf("https://%s/v2/%s/manifests/%s", r.endpoint, r.key.repository, tagged.Tag()),
+		map[string]string{"Accept": strings.Join(manifest.DefaultRequestedManifestMIMETypes, ", ")})
+	if err != nil {
+		return "", err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		if res.StatusCode == http.StatusUnauthorized {
+			r.invalidate()
+		}
+		return "", docker.UnexpectedHTTPStatusError{StatusCode: res.StatusCode}
+	}
+	return digest.Parse(res.Header.Get("Docker-Content-Digest"))
+}
+
+// do issues a request to the registry, authorized by the token of the session or by the credentials if the
+// registry uses the basic authentication.
+func (r *registrySession) do(ctx context.Context, method, requestURL string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for header, value := range headers {
+		req.Header.Set(header, value)
+	}
+	switch {
+	case r.token.token != "":
+		req.Header.Set("Authorization", "Bearer "+r.token.token)
+	case r.auth.Username != "":
+		req.SetBasicAuth(r.auth.Username, r.auth.Password)
+	}
+	return r.client.Do(req)
+}
+
+// invalidate drops the token of the session, e.g., because the registry rejected it.
+func (r *registrySession) invalidate() {
+	if r == nil {
+		return
+	}
+	r.sessions.mutex.Lock()
+	defer r.sessions.mutex.Unlock()
+	if token, ok := r.sessions.tokens[r.key]; ok && token == r.token {
+		delete(r.sessions.tokens, r.key)
+	}
+}
+
+// label returns the value of the session label of the inspection duration metric.
+func (r *registrySession) label() string {
+	if r != nil && r.warm {
+		return "warm"
+	}
+	return "cold"
+}
+
+// parseChallenge parses the scheme, lowercased, and the parameters of a WWW-Authenticate header value,
+// e.g., Bearer realm="https://auth.docker.io/token",service="registry.docker.io".
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+		var key string
+		key, rest, _ = strings.Cut(rest, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			// Quoted values can contain commas, e.g., in the scope parameter
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[key] = strings.TrimSpace(value)
+	}
+	return strings.ToLower(scheme), params
+}
+
+// credentialsHash returns a hash identifying the given credentials, or an empty string for anonymous access.
+func credentialsHash(auth types.DockerAuthConfig) string {
+	if auth.Username == "" && auth.Password == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(auth.Username + "\x00" + auth.Password))
+	return hex.EncodeToString(sum[:])
+}
+
+// certsDirFor returns the directory holding the certificates of the given registry.
+func certsDirFor(sys *types.SystemContext, registry string) string {
+	if sys != nil && sys.DockerPerHostCertDirPath != "" {
+		return filepath.Join(sys.DockerPerHostCertDirPath, registry)
+	}
+	for _, dir := range perHostCertDirs {
+		if _, err := os.Stat(filepath.Join(dir, registry)); err == nil {
+			return filepath.Join(dir, registry)
+		}
+	}
+	return ""
+}
+
+// certsFingerprint returns a fingerprint of the names, sizes and modification times of the files in the given
+// directory, or an empty string if the directory cannot be read.
+func certsFingerprint(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.New()
+	for _, entry := range entries {
+		// Stat follows the symlinks of the mounted ConfigMaps and Secrets
+		info, err := os.Stat(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(hash, "%s:%d:%d;", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,184 @@
+package image
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/types"
+)
+
+const testManifestDigest = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+
+// newTestRegistry starts a registry requiring a bearer token, issued by its /token endpoint to the user:password
+// credentials. It counts the issued tokens.
+func newTestRegistry(t *testing.T, tokens *atomic.Int32) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:ns/app:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			n := tokens.Add(1)
+			_, _ = fmt.Fprintf(w, `{"token": "token-%d", "expires_in": 300}`, n)
+		case r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", tokens.Load()):
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/ns/app/manifests/latest":
+			w.Header().Set("Docker-Content-Digest", testManifestDigest)
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestSessions(t *testing.T, srv *httptest.Server) (*registrySessions, *types.SystemContext, reference.Named) {
+	registry := strings.TrimPrefix(srv.URL, "https://")
+	dir := t.TempDir()
+	authFile := filepath.Join(dir, "auth.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("user:password"))
+	if err := os.WriteFile(authFile, []byte(fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, registry, auth)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "registries.conf"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	sys := &types.SystemContext{
+		AuthFilePath:                authFile,
+		SystemRegistriesConfPath:    filepath.Join(dir, "registries.conf"),
+		SystemRegistriesConfDirPath: filepath.Join(dir, "registries.conf.d"),
+		DockerPerHostCertDirPath:    filepath.Join(dir, "certs.d"),
+	}
+	named, err := reference.ParseNormalizedNamed(registry + "/ns/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newRegistrySessions(newRegistriesConfig())
+	s.transports[registry] = &registryTransport{client: srv.Client()}
+	return s, sys, named
+}
+
+func Test_registrySessions_open(t *testing.T) {
+	var tokens atomic.Int32
+	srv := newTestRegistry(t, &tokens)
+	s, sys, named := newTestSessions(t, srv)
+	ctx := context.Background()
+
+	session, err := s.open(ctx, sys, named)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if session.warm || session.token.token != "token-1" {
+		t.Fatalf("expected a cold session with a new token, got warm=%v token=%q", session.warm, session.token.token)
+	}
+	d, err := session.headDigest(ctx)
+	if err != nil || d.String() != testManifestDigest {
+		t.Fatalf("unexpected HEAD result: %v, %v", d, err)
+	}
+
+	session, err = s.open(ctx, sys, named)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !session.warm || session.token.token != "token-1" || tokens.Load() != 1 {
+		t.Errorf("expected the token to be reused, got warm=%v token=%q issued=%d", session.warm,
+			session.token.token, tokens.Load())
+	}
+
+	s.now = func() time.Time { return time.Now().Add(5 * time.Minute) }
+	if session, err = s.open(ctx, sys, named); err != nil || session.warm || session.token.token != "token-2" {
+		t.Errorf("expected the expired token to be renewed, got warm=%v token=%q err=%v", session.warm,
+			session.token.token, err)
+	}
+	s.now = time.Now
+
+	s.reset()
+	if len(s.tokens) != 0 || len(s.transports) != 0 {
+		t.Errorf("expected the sessions to be reset")
+	}
+}
+
+func Test_registrySessions_clientFor(t *testing.T) {
+	s := newRegistrySessions(newRegistriesConfig())
+	certsDir := t.TempDir()
+	sys := &types.SystemContext{DockerPerHostCertDirPath: certsDir}
+	client, err := s.clientFor(sys, "quay.io")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.tokens[sessionKey{registry: "quay.io", repository: "ns/app"}] = bearerToken{token: "token"}
+	s.tokens[sessionKey{registry: "docker.io", repository: "ns/app"}] = bearerToken{token: "token"}
+	if reused, _ := s.clientFor(sys, "quay.io"); reused != client {
+		t.Errorf("expected the client to be reused")
+	}
+
+	if err := os.MkdirAll(filepath.Join(certsDir, "quay.io"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(certsDir, "quay.io", "ca.pem"), []byte("ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if renewed, _ := s.clientFor(sys, "quay.io"); renewed == client {
+		t.Errorf("expected the client to be rebuilt when the certificates change")
+	}
+	if len(s.tokens) != 1 {
+		t.Errorf("expected the tokens of the registry to be dropped when the certificates change, got %v", s.tokens)
+	}
+}
+
+func Test_parseChallenge(t *testing.T) {
+	tests := []struct {
+		header       string
+		expectScheme string
+		expectParams map[string]string
+	}{
+		{
+			header:       `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`,
+			expectScheme: "bearer",
+			expectParams: map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io"},
+		},
+		{
+			header:       `Bearer realm="https://quay.io/v2/auth", service=quay.io, scope="repository:a/b:pull,push"`,
+			expectScheme: "bearer",
+			expectParams: map[string]string{"realm": "https://quay.io/v2/auth", "service": "quay.io",
+				"scope": "repository:a/b:pull,push"},
+		},
+		{
+			header:       `Basic realm="Registry"`,
+			expectScheme: "basic",
+			expectParams: map[string]string{"realm": "Registry"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			scheme, params := parseChallenge(tt.header)
+			if scheme != tt.expectScheme {
+				t.Errorf("expected scheme %q, got %q", tt.expectScheme, scheme)
+			}
+			if fmt.Sprint(params) != fmt.Sprint(tt.expectParams) {
+				t.Errorf("expected params %v, got %v", tt.expectParams, params)
+			}
+		})
+	}
+}
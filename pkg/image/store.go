@@ -0,0 +1,234 @@
+/*
+Copyright 2025 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/image/metrics"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+// imageArchitectureStore persists the inspection results as cluster-scoped ImageArchitecture objects, so that
+// they are shared by all the replicas of the pod placement controller and survive their restarts.
+type imageArchitectureStore struct {
+	client client.Client
+	// rules is the configuration the inspection results depend on.
+	rules *inspectionRules
+}
+
+func (s *imageArchitectureStore) get(ctx context.Context, d string) (*inspectionResult, bool) {
+	log := ctrllog.FromContext(ctx).WithValues("digest", d)
+	imageArchitecture := &v1beta1.ImageArchitecture{}
+	err := s.client.Get(ctx, client.ObjectKey{Name: v1beta1.ImageArchitectureNameForDigest(d)}, imageArchitecture)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Error(err, "Unable to get the ImageArchitecture object")
+		}
+		metrics.ImageArchitectureStoreMisses.Inc()
+		return nil, false
+	}
+	if imageArchitecture.Spec.Digest != d {
+		// This can only happen if the object was created by someone else than the pod placement controller.
+		log.V(1).Info("Ignoring the ImageArchitecture object as it records a different digest",
+			"recordedDigest", imageArchitecture.Spec.Digest)
+		metrics.ImageArchitectureStoreMisses.Inc()
+		return nil, false
+	}
+	if hash := imageArchitecture.Annotations[utils.InspectionConfigHashAnnotation]; hash != s.rules.hash() {
+		// The image was inspected with a different configuration, e.g., before the architecture-agnostic rules
+		// changed: it is inspected again and the object is overwritten.
+		log.V(1).Info("Ignoring the ImageArchitecture object as it was inspected with a different configuration")
+		metrics.ImageArchitectureStoreMisses.Inc()
+		return nil, false
+	}
+	metrics.ImageArchitectureStoreHits.Inc()
+	return &inspectionResult{
+		digest:                   digest.Digest(d),
+		platforms:                s.rules.architectures.normalizePlatforms(platformsOf(imageArchitecture)),
+		architectureAgnosticRule: imageArchitecture.Spec.ArchitectureAgnosticRule,
+		source:                   imageArchitecture.Spec.Source,
+		mirror:                   imageArchitecture.Spec.Mirror,
+		discrepancies:            s.discrepanciesOf(imageArchitecture),
+	}, true
+}
+
+// store persists the given inspection result. The existing object recording the digest is overwritten, as it was
+// inspected with a different configuration or it would have been read through.
+func (s *imageArchitectureStore) store(ctx context.Context, imageReference string, result *inspectionResult) {
+	log := ctrllog.FromContext(ctx).WithValues("digest", result.digest)
+	imageArchitecture := newImageArchitecture(imageReference, result, s.rules.hash())
+	err := s.client.Create(ctx, imageArchitecture)
+	if apierrors.IsAlreadyExists(err) {
+		err = s.update(ctx, imageArchitecture)
+	}
+	if err != nil {
+		// Failing to persist the result is not fatal: the image will be inspected again by the replicas that need it.
+		log.Error(err, "Unable to store the ImageArchitecture object")
+		metrics.ImageArchitectureStoreWriteErrors.Inc()
+		return
+	}
+	log.V(3).Info("Stored the ImageArchitecture object", "name", imageArchitecture.Name)
+}
+
+// update overwrites the existing ImageArchitecture object with the given one, unless it already records the same
+// configuration, e.g., when another replica stored the result concurrently.
+func (s *imageArchitectureStore) update(ctx context.Context, imageArchitecture *v1beta1.ImageArchitecture) error {
+	existing := &v1beta1.ImageArchitecture{}
+	if err := s.client.Get(ctx, client.ObjectKeyFromObject(imageArchitecture), existing); err != nil {
+		return err
+	}
+	if existing.Spec.Digest == imageArchitecture.Spec.Digest &&
+		existing.Annotations[utils.InspectionConfigHashAnnotation] ==
+			imageArchitecture.Annotations[utils.InspectionConfigHashAnnotation] {
+		return nil
+	}
+	existing.Labels = imageArchitecture.Labels
+	existing.Annotations = imageArchitecture.Annotations
+	existing.Spec = imageArchitecture.Spec
+	if err := s.client.Update(ctx, existing); err != nil && !apierrors.IsConflict(err) {
+		return err
+	}
+	// On conflict, another replica updated the object concurrently.
+	return nil
+}
+
+// newImageArchitecture builds the ImageArchitecture object recording the given inspection result, stamped with the
+// hash of the configuration it was inspected with. The single-arch and multi-arch labels are set so that users can list, for example, the single-architecture
+// images in the cluster with `oc get imagearchitectures -l multiarch.openshift.io/single-arch`.
+func newImageArchitecture(imageReference string, result *inspectionResult, configHash string) *v1beta1.ImageArchitecture {
+	labels := map[string]string{}
+	switch result.architectures().Len() {
+	case 0:
+		labels[utils.NoSupportedArchLabel] = ""
+	case 1:
+		labels[utils.SingleArchLabel] = ""
+	default:
+		labels[utils.MultiArchLabel] = ""
+	}
+	return &v1beta1.ImageArchitecture{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        v1beta1.ImageArchitectureNameForDigest(result.digest.String()),
+			Labels:      labels,
+			Annotations: map[string]string{utils.InspectionConfigHashAnnotation: configHash},
+		},
+		Spec: v1beta1.ImageArchitectureSpec{
+			Digest:         result.digest.String(),
+			ImageReference: strings.TrimPrefix(imageReference, "//"),
+			Architectures:  sets.List(result.architectures()),
+			Platforms:      imagePlatforms(result.platforms),
+			InspectionTime: metav1.Now(),
+			Source:         result.sourceOrDefault(),
+			Mirror:         result.mirror,
+			// The rule and the discrepancies are recorded so that the replicas reading the object can report them to
+			// the pods.
+			ArchitectureAgnosticRule:  result.architectureAgnosticRule,
+			ManifestListDiscrepancies: manifestListDiscrepancies(result.discrepancies),
+		},
+	}
+}
+
+// manifestListDiscrepancies returns the API representation of the given discrepancies.
+func manifestListDiscrepancies(discrepancies []ManifestListDiscrepancy) []v1beta1.ManifestListDiscrepancy {
+	var apiDiscrepancies []v1beta1.ManifestListDiscrepancy
+	for _, d := range discrepancies {
+		apiDiscrepancies = append(apiDiscrepancies, v1beta1.ManifestListDiscrepancy{
+			Digest: d.Digest.String(),
+			Platform: v1beta1.ImagePlatform{
+				OS: d.Platform.OS, Architecture: d.Platform.Architecture, Variant: d.Platform.Variant,
+			},
+			Reason:  d.Reason,
+			Message: d.Message,
+		})
+	}
+	return apiDiscrepancies
+}
+
+// discrepanciesOf returns the manifest list discrepancies recorded in the given ImageArchitecture object.
+func (s *imageArchitectureStore) discrepanciesOf(imageArchitecture *v1beta1.ImageArchitecture) []ManifestListDiscrepancy {
+	var discrepancies []ManifestListDiscrepancy
+	for _, d := range imageArchitecture.Spec.ManifestListDiscrepancies {
+		discrepancies = append(discrepancies, ManifestListDiscrepancy{
+			ImageReference: imageArchitecture.Spec.ImageReference,
+			Digest:         digest.Digest(d.Digest),
+			Platform: s.rules.architectures.normalizePlatform(Platform{
+				OS: osOrDefault(d.Platform.OS), Architecture: d.Platform.Architecture, Variant: d.Platform.Variant,
+			}),
+			Reason:  d.Reason,
+			Message: d.Message,
+		})
+	}
+	return discrepancies
+}
+
+// platformsOf returns the platforms recorded in the given ImageArchitecture object. Objects that do not record the
+// platforms are considered to support the baseline variant of their architectures on Linux.
+func platformsOf(imageArchitecture *v1beta1.ImageArchitecture) sets.Set[Platform] {
+	if len(imageArchitecture.Spec.Platforms) == 0 {
+		return PlatformsOf(sets.New[string](imageArchitecture.Spec.Architectures...))
+	}
+	platforms := sets.New[Platform]()
+	for _, p := range imageArchitecture.Spec.Platforms {
+		platforms.Insert(Platform{OS: osOrDefault(p.OS), Architecture: p.Architecture, Variant: p.Variant})
+	}
+	return platforms
+}
+
+// imagePlatforms returns the sorted list of the API representation of the given platforms.
+func imagePlatforms(platforms sets.Set[Platform]) []v1beta1.ImagePlatform {
+	imagePlatforms := make([]v1beta1.ImagePlatform, 0, platforms.Len())
+	for p := range platforms {
+		imagePlatforms = append(imagePlatforms, v1beta1.ImagePlatform{OS: p.OS, Architecture: p.Architecture, Variant: p.Variant})
+	}
+	slices.SortFunc(imagePlatforms, func(a, b v1beta1.ImagePlatform) int {
+		return cmp.Or(strings.Compare(a.OS, b.OS), strings.Compare(a.Architecture, b.Architecture),
+			strings.Compare(a.Variant, b.Variant))
+	})
+	return imagePlatforms
+}
+
+// digestFromReference returns the digest of a digest-pinned image reference.
+// It returns false if the reference is not pinned to a valid digest.
+func digestFromReference(imageReference string) (string, bool) {
+	idx := strings.LastIndex(imageReference, "@")
+	if idx == -1 {
+		return "", false
+	}
+	d, err := digest.Parse(imageReference[idx+1:])
+	if err != nil {
+		return "", false
+	}
+	return d.String(), true
+}
+
+func newImageArchitectureStore(c client.Client, rules *inspectionRules) *imageArchitectureStore {
+	return &imageArchitectureStore{
+		client: c,
+		rules:  rules,
+	}
+}
@@ -0,0 +1,167 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/api/v1beta1"
+	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func Test_digestFromReference(t *testing.T) {
+	tests := []struct {
+		name           string
+		imageReference string
+		expectedDigest string
+		expectedOk     bool
+	}{
+		{
+			name:           "tagged reference",
+			imageReference: "//quay.io/foo/bar:latest",
+			expectedOk:     false,
+		},
+		{
+			name:           "digest-pinned reference",
+			imageReference: "//quay.io/foo/bar@" + testDigest,
+			expectedDigest: testDigest,
+			expectedOk:     true,
+		},
+		{
+			name:           "tagged and digest-pinned reference",
+			imageReference: "//quay.io/foo/bar:latest@" + testDigest,
+			expectedDigest: testDigest,
+			expectedOk:     true,
+		},
+		{
+			name:           "invalid digest",
+			imageReference: "//quay.io/foo/bar@sha256:invalid",
+			expectedOk:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := digestFromReference(tt.imageReference)
+			if ok != tt.expectedOk {
+				t.Errorf("digestFromReference() ok = %v, expected %v", ok, tt.expectedOk)
+			}
+			if d != tt.expectedDigest {
+				t.Errorf("digestFromReference() digest = %v, expected %v", d, tt.expectedDigest)
+			}
+		})
+	}
+}
+
+func Test_newImageArchitecture(t *testing.T) {
+	tests := []struct {
+		name           string
+		architectures  sets.Set[string]
+		source         v1beta1.ImageArchitectureSource
+		mirror         string
+		expectedLabel  string
+		expectedSource v1beta1.ImageArchitectureSource
+	}{
+		{
+			name:           "single-arch image",
+			architectures:  sets.New[string](utils.ArchitectureAmd64),
+			expectedLabel:  utils.SingleArchLabel,
+			expectedSource: v1beta1.ImageArchitectureSourceRegistry,
+		},
+		{
+			name:           "image inspected in an offline source",
+			architectures:  sets.New[string](utils.ArchitectureAmd64),
+			source:         v1beta1.ImageArchitectureSourceOffline,
+			expectedLabel:  utils.SingleArchLabel,
+			expectedSource: v1beta1.ImageArchitectureSourceOffline,
+		},
+		{
+			name:           "image inspected from a mirror",
+			architectures:  sets.New[string](utils.ArchitectureAmd64),
+			mirror:         "mirror.example.com/foo",
+			expectedLabel:  utils.SingleArchLabel,
+			expectedSource: v1beta1.ImageArchitectureSourceRegistry,
+		},
+		{
+			name:           "multi-arch image",
+			architectures:  sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64),
+			expectedLabel:  utils.MultiArchLabel,
+			expectedSource: v1beta1.ImageArchitectureSourceRegistry,
+		},
+		{
+			name:           "image with no supported architectures",
+			architectures:  sets.New[string](),
+			expectedLabel:  utils.NoSupportedArchLabel,
+			expectedSource: v1beta1.ImageArchitectureSourceRegistry,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ia := newImageArchitecture("//quay.io/foo/bar:latest", &inspectionResult{
+				digest:    digest.Digest(testDigest),
+				platforms: PlatformsOf(tt.architectures),
+				source:    tt.source,
+				mirror:    tt.mirror,
+			}, "hash")
+			if ia.Name != "sha256-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" {
+				t.Errorf("unexpected name %s", ia.Name)
+			}
+			if ia.Spec.ImageReference != "quay.io/foo/bar:latest" {
+				t.Errorf("unexpected image reference %s", ia.Spec.ImageReference)
+			}
+			if _, ok := ia.Labels[tt.expectedLabel]; !ok || len(ia.Labels) != 1 {
+				t.Errorf("expected only the %s label, got %v", tt.expectedLabel, ia.Labels)
+			}
+			if got := ia.Annotations[utils.InspectionConfigHashAnnotation]; got != "hash" {
+				t.Errorf("unexpected configuration hash %q", got)
+			}
+			if !sets.New[string](ia.Spec.Architectures...).Equal(tt.architectures) {
+				t.Errorf("unexpected architectures %v", ia.Spec.Architectures)
+			}
+			if !platformsOf(ia).Equal(PlatformsOf(tt.architectures)) {
+				t.Errorf("unexpected platforms %v", ia.Spec.Platforms)
+			}
+			if ia.Spec.Source != tt.expectedSource {
+				t.Errorf("unexpected source %q", ia.Spec.Source)
+			}
+			if ia.Spec.Mirror != tt.mirror {
+				t.Errorf("unexpected mirror %q", ia.Spec.Mirror)
+			}
+		})
+	}
+}
+
+func Test_imageArchitectureStore_discrepanciesOf(t *testing.T) {
+	discrepancies := []ManifestListDiscrepancy{
+		{
+			Digest:   digest.FromString("dangling"),
+			Platform: Platform{OS: "linux", Architecture: utils.ArchitectureArm64},
+			Reason:   DiscrepancyReasonUnresolvable,
+			Message:  "unable to fetch the manifest",
+		},
+		{
+			Digest:   digest.FromString("lying"),
+			Platform: Platform{OS: "linux", Architecture: utils.ArchitectureAmd64, Variant: "v3"},
+			Reason:   DiscrepancyReasonPlatformMismatch,
+			Message:  "the config reports the linux/arm64 platform",
+		},
+	}
+	ia := newImageArchitecture("//quay.io/foo/bar:latest", &inspectionResult{
+		digest:        digest.Digest(testDigest),
+		platforms:     PlatformsOf(sets.New[string](utils.ArchitectureAmd64)),
+		discrepancies: discrepancies,
+	}, "hash")
+	s := &imageArchitectureStore{rules: newInspectionRules()}
+	got := s.discrepanciesOf(ia)
+	if len(got) != len(discrepancies) {
+		t.Fatalf("expected %d discrepancies, got %v", len(discrepancies), got)
+	}
+	for i := range got {
+		discrepancies[i].ImageReference = "quay.io/foo/bar:latest"
+		if got[i] != discrepancies[i] {
+			t.Errorf("expected the discrepancy %+v, got %+v", discrepancies[i], got[i])
+		}
+	}
+}
@@ -112,6 +112,14 @@ func (pod *Pod) EnsureAnnotation(annotation string, value string) {
 	pod.Annotations[annotation] = value
 }
 
+// EnsureNoAnnotation ensures that the pod does not have the given annotation.
+func (pod *Pod) EnsureNoAnnotation(annotation string) {
+	if pod.Annotations == nil {
+		return
+	}
+	delete(pod.Annotations, annotation)
+}
+
 // EnsureAndIncrementLabel ensures that the pod has the given label with the given value.
 // If the label is already set, it increments the value.
 func (pod *Pod) EnsureAndIncrementLabel(label string) {
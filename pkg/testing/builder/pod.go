@@ -82,6 +82,17 @@ func (p *PodBuilder) WithInitContainersImages(images ...string) *PodBuilder {
 	return p
 }
 
+// WithImageVolume adds an image volume of the given name mounting the given image reference.
+func (p *PodBuilder) WithImageVolume(name, reference string, pullPolicy v1.PullPolicy) *PodBuilder {
+	p.pod.Spec.Volumes = append(p.pod.Spec.Volumes, v1.Volume{
+		Name: name,
+		VolumeSource: v1.VolumeSource{
+			Image: &v1.ImageVolumeSource{Reference: reference, PullPolicy: pullPolicy},
+		},
+	})
+	return p
+}
+
 // WithAffinity adds the affinity to the pod. If initialAffinity is not nil, it is used as the initial value
 // of the pod's affinity. Otherwise, the pod's affinity is initialized to an empty affinity if it is nil.
 func (p *PodBuilder) WithAffinity(initialAffinity *v1.Affinity) *PodBuilder {
@@ -172,6 +183,11 @@ func (p *PodBuilder) WithNamespace(namespace string) *PodBuilder {
 	return p
 }
 
+func (p *PodBuilder) WithOS(os v1.OSName) *PodBuilder {
+	p.pod.Spec.OS = &v1.PodOS{Name: os}
+	return p
+}
+
 func (p *PodBuilder) WithNodeName(nodeName string) *PodBuilder {
 	p.pod.Spec.NodeName = nodeName
 	return p
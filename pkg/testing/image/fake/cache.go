@@ -5,6 +5,8 @@ import (
 	"errors"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
 )
 
 type cacheProxy struct {
@@ -23,6 +25,15 @@ func (c *cacheProxy) GetCompatibleArchitecturesSet(ctx context.Context, imageRef
 	return nil, errors.New("image not found")
 }
 
+func (c *cacheProxy) GetCompatiblePlatformsSet(ctx context.Context, imageReference string, skipCache bool,
+	secrets [][]byte) (supportedPlatforms sets.Set[image.Platform], err error) {
+	imageReference = imageReference[2:]
+	if platformSet, ok := MockImagesPlatformMap()[imageReference]; ok {
+		return platformSet, nil
+	}
+	return nil, errors.New("image not found")
+}
+
 func newCacheProxy() *cacheProxy {
 	return &cacheProxy{
 		imageRefsArchitectureMap: map[string]sets.Set[string]{},
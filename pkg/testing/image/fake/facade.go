@@ -24,6 +24,11 @@ func (i *Facade) GetCompatibleArchitecturesSet(ctx context.Context, imageReferen
 	return i.inspectionCache.GetCompatibleArchitecturesSet(ctx, imageReference, skipCache, secrets)
 }
 
+func (i *Facade) GetCompatiblePlatformsSet(ctx context.Context, imageReference string, skipCache bool,
+	secrets [][]byte) (platforms sets.Set[image.Platform], err error) {
+	return i.inspectionCache.GetCompatiblePlatformsSet(ctx, imageReference, skipCache, secrets)
+}
+
 func newImageFacade() *Facade {
 	inspectionCache := newCacheProxy()
 	return &Facade{
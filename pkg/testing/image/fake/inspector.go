@@ -6,6 +6,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	"github.com/openshift/multiarch-tuning-operator/pkg/image"
 	"github.com/openshift/multiarch-tuning-operator/pkg/utils"
 )
 
@@ -19,6 +20,14 @@ const (
 	SingleArchArm64Image = "my-registry.io/library/single-arch-arm64-image:latest"
 	MultiArchImage       = "my-registry.io/library/multi-arch-image:latest"
 	MultiArchImage2      = "my-registry.io/library/multi-arch-image2:latest"
+	// MultiArchAmd64V3Image ships a build for the x86-64-v3 microarchitecture level only for amd64.
+	MultiArchAmd64V3Image = "my-registry.io/library/multi-arch-amd64-v3-image:latest"
+	// MixedOSImage ships a windows/amd64 and a linux/arm64 build.
+	MixedOSImage = "my-registry.io/library/mixed-os-image:latest"
+	// MultiOSImage ships windows/amd64, linux/amd64 and linux/arm64 builds.
+	MultiOSImage = "my-registry.io/library/multi-os-image:latest"
+	// WasmImage is a WebAssembly image.
+	WasmImage = "my-registry.io/library/wasm-image:latest"
 )
 
 // MockImagesArchitectureMap returns a map of image references to their supported architectures
@@ -30,9 +39,34 @@ func MockImagesArchitectureMap() map[string]sets.Set[string] {
 		MultiArchImage:       sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64),
 		MultiArchImage2: sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64,
 			utils.ArchitecturePpc64le, utils.ArchitectureS390x),
+		MultiArchAmd64V3Image: sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64),
+		MixedOSImage:          sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64),
+		MultiOSImage:          sets.New[string](utils.ArchitectureAmd64, utils.ArchitectureArm64),
+		WasmImage:             sets.New[string](image.WasmPlatform.Architecture),
 	}
 }
 
+// MockImagesPlatformMap returns the platforms of the mock images. Images not built for specific CPU variants
+// support the baseline variant of their architectures on Linux.
+func MockImagesPlatformMap() map[string]sets.Set[image.Platform] {
+	platforms := map[string]sets.Set[image.Platform]{}
+	for imageReference, architectures := range MockImagesArchitectureMap() {
+		platforms[imageReference] = image.PlatformsOf(architectures)
+	}
+	platforms[MultiArchAmd64V3Image] = sets.New[image.Platform](
+		image.Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureAmd64, Variant: "v3"},
+		image.Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureArm64, Variant: "v8"})
+	platforms[MixedOSImage] = sets.New[image.Platform](
+		image.Platform{OS: utils.OSWindows, Architecture: utils.ArchitectureAmd64},
+		image.Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureArm64})
+	platforms[MultiOSImage] = sets.New[image.Platform](
+		image.Platform{OS: utils.OSWindows, Architecture: utils.ArchitectureAmd64},
+		image.Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureAmd64},
+		image.Platform{OS: utils.OSLinux, Architecture: utils.ArchitectureArm64})
+	platforms[WasmImage] = sets.New[image.Platform](image.WasmPlatform)
+	return platforms
+}
+
 func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, imageReference string,
 	skipCache bool, secrets [][]byte) (supportedArchitectures sets.Set[string], err error) {
 	// we expect the imageReference to start with `//`. Let's remove it
@@ -47,3 +81,12 @@ func (i *registryInspector) GetCompatibleArchitecturesSet(ctx context.Context, i
 func newRegistryInspector() *registryInspector {
 	return &registryInspector{}
 }
+
+func (i *registryInspector) GetCompatiblePlatformsSet(ctx context.Context, imageReference string,
+	skipCache bool, secrets [][]byte) (supportedPlatforms sets.Set[image.Platform], err error) {
+	imageReference = imageReference[2:]
+	if platformSet, ok := MockImagesPlatformMap()[imageReference]; ok {
+		return platformSet, nil
+	}
+	return nil, errors.New("image not found")
+}
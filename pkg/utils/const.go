@@ -20,8 +20,14 @@ const (
 	ArchitectureS390x   = "s390x"
 )
 
+const (
+	OSLinux   = "linux"
+	OSWindows = "windows"
+)
+
 const (
 	ArchLabel                  = "kubernetes.io/arch"
+	OSLabel                    = "kubernetes.io/os"
 	NodeAffinityLabel          = "multiarch.openshift.io/node-affinity"
 	PreferredNodeAffinityLabel = "multiarch.openshift.io/preferred-node-affinity"
 	// PreferredNodeAffinitySourcesAnnotation tracks the complete audit trail of which
@@ -63,6 +69,17 @@ const (
 	FallbackArchitectureLabel              = "multiarch.openshift.io/fallback-arch"
 	ImageInspectionErrorLabel              = "multiarch.openshift.io/image-inspect-error"
 	ImageInspectionErrorCountLabel         = "multiarch.openshift.io/image-inspect-error-count"
+	ImageInspectionRetryAfterAnnotation    = "multiarch.openshift.io/image-inspect-retry-after"
+	IgnoreImageVolumesAnnotation           = "multiarch.openshift.io/ignore-image-volumes"
+	OriginalImagesAnnotation               = "multiarch.openshift.io/original-images"
+	PinnedImagesAnnotation                 = "multiarch.openshift.io/pinned-images"
+	InspectionConfigHashAnnotation         = "multiarch.openshift.io/inspection-config-hash"
+	EmulationLabel                         = "multiarch.openshift.io/emulation"
+	EmulationLabelValueAllowed             = "allowed"
+	ArchitectureInferenceSourceLabel       = "multiarch.openshift.io/arch-inference-source"
+	ArchitectureInferenceSourceNodeImages  = "node-images"
+	ArchitectureInferenceSourceStaticRule  = "static-rule"
+	ArchitectureAgnosticRuleLabel          = "multiarch.openshift.io/arch-agnostic-rule"
 	LabelGroup                             = "multiarch.openshift.io"
 )
 
@@ -78,6 +95,9 @@ const (
 	PodMutatingWebhookName              = "pod-placement-scheduling-gate.multiarch.openshift.io"
 	PodPlacementControllerName          = "pod-placement-controller"
 	PodPlacementWebhookName             = "pod-placement-web-hook"
+	// OfflineImageSourcesMountPath is the path where the volume of the offline image sources is mounted in the pod
+	// placement controller.
+	OfflineImageSourcesMountPath = "/var/lib/offline-image-sources"
 )
 
 const (